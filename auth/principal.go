@@ -0,0 +1,67 @@
+// Package auth berisi identitas request yang sudah diautentikasi. Middleware mengubah JWT atau
+// API key menjadi Principal dan menaruhnya di context, sehingga service tidak bergantung pada library JWT.
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Principal user yang melakukan request.
+type Principal struct {
+	UserID uint
+	Roles  []string
+	// Scopes hanya terisi untuk API key, login biasa tidak dibatasi scope.
+	Scopes    []string
+	SessionID uint
+	APIKeyID  uint
+	// TokenID dan ExpiresAt milik access token (jti dan exp), dipakai untuk mencabut token saat logout.
+	TokenID   string
+	ExpiresAt time.Time
+	// IP dan UserAgent perangkat yang mengirim request, dicatat di riwayat keamanan.
+	IP        string
+	UserAgent string
+}
+
+// IsAPIKey true jika request diautentikasi dengan API key, bukan login.
+func (p Principal) IsAPIKey() bool {
+	return p.APIKeyID > 0
+}
+
+// HasRole true jika principal punya salah satu roles.
+func (p Principal) HasRole(roles ...string) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasScope selalu true untuk login biasa, scope hanya membatasi API key.
+func (p Principal) HasScope(scope string) bool {
+	if !p.IsAPIKey() {
+		return true
+	}
+	for _, val := range p.Scopes {
+		if val == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// NewContext menyimpan principal di ctx.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext mengambil principal dari ctx, ok false jika request belum diautentikasi.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
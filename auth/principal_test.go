@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal(t *testing.T) {
+	login := Principal{UserID: 1, Roles: []string{"admin"}}
+	assert.True(t, login.HasRole("user", "admin"))
+	assert.False(t, login.HasRole("user"))
+	assert.True(t, login.HasScope("books:write"))
+	assert.False(t, login.IsAPIKey())
+
+	key := Principal{UserID: 1, Roles: []string{"user"}, APIKeyID: 3, Scopes: []string{"books:read"}}
+	assert.True(t, key.IsAPIKey())
+	assert.True(t, key.HasScope("books:read"))
+	assert.False(t, key.HasScope("books:write"))
+}
+
+func TestContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := NewContext(context.Background(), Principal{UserID: 5, SessionID: 2})
+	p, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, uint(5), p.UserID)
+	assert.Equal(t, uint(2), p.SessionID)
+}
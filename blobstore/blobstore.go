@@ -0,0 +1,31 @@
+package blobstore
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotFound dikembalikan Get jika key tidak ada.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore penyimpanan file kecil (avatar dan sejenisnya) berdasarkan key berbentuk path, mis. "avatars/1/128.jpg".
+// Implementasi: Local untuk development, S3 untuk storage yang kompatibel dengan S3, untuk testing lihat paket s3test.
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) (data []byte, contentType string, err error)
+	// Delete tidak mengembalikan error jika key sudah tidak ada.
+	Delete(key string) error
+}
+
+// validKey menolak key kosong, absolut atau yang mengandung segmen "." dan "..".
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return errors.New("key blob tidak valid")
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return errors.New("key blob tidak valid")
+		}
+	}
+	return nil
+}
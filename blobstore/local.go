@@ -0,0 +1,92 @@
+package blobstore
+
+import (
+	"errors"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+type localStore struct {
+	dir string
+}
+
+// NewLocal menyimpan blob sebagai file di bawah dir. Content type ditentukan dari ekstensi key,
+// jadi key sebaiknya selalu memakai ekstensi yang sesuai.
+func NewLocal(dir string) BlobStore {
+	return &localStore{
+		dir: dir,
+	}
+}
+
+func (ls *localStore) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(ls.dir, filepath.FromSlash(key)), nil
+}
+
+func (ls *localStore) Put(key string, data []byte, contentType string) error {
+	name, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	// tulis ke file sementara lalu rename supaya pembaca tidak pernah melihat file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (ls *localStore) Get(key string) ([]byte, string, error) {
+	name, err := ls.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return data, contentType, nil
+}
+
+func (ls *localStore) Delete(key string) error {
+	name, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
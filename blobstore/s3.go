@@ -0,0 +1,259 @@
+package blobstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config koneksi ke storage yang kompatibel dengan S3 (AWS S3, MinIO, R2, dll).
+// Endpoint berupa URL dasar, mis. "https://s3.ap-southeast-1.amazonaws.com", bucket dipanggil dengan path-style.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3 BlobStore berbasis REST API S3 dengan signature V4, tanpa SDK.
+type S3 struct {
+	cfg  S3Config
+	http *http.Client
+	now  func() time.Time
+}
+
+func NewS3(cfg S3Config) *S3 {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &S3{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 10 * time.Second},
+		now:  time.Now,
+	}
+}
+
+func (s *S3) Put(key string, data []byte, contentType string) error {
+	resp, err := s.do(http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3) Get(key string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", s3Error(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+func (s *S3) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, s.cfg.Endpoint+"/"+s.cfg.Bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawPath = "/" + uriEscape(s.cfg.Bucket) + "/" + uriEscape(key)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	SignV4(req, PayloadHash(body), s.cfg.Region, s.cfg.AccessKey, s.cfg.SecretKey, s.now())
+	return s.http.Do(req)
+}
+
+// s3Error membaca kode error dari body XML tanpa mem-parse seluruh dokumen.
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	code := string(body)
+	if i := strings.Index(code, "<Code>"); i >= 0 {
+		code = code[i+len("<Code>"):]
+		if j := strings.Index(code, "</Code>"); j >= 0 {
+			code = code[:j]
+		}
+	} else {
+		code = ""
+	}
+	return fmt.Errorf("s3 %s: %s", resp.Status, code)
+}
+
+// PayloadHash hash SHA-256 (hex) body request untuk header x-amz-content-sha256.
+func PayloadHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignV4 menandatangani request dengan AWS Signature Version 4 untuk service s3: mengisi header
+// x-amz-date, x-amz-content-sha256 dan Authorization. Header yang ikut ditandatangani: host,
+// content-type, range dan semua x-amz-*.
+func SignV4(req *http.Request, payloadHash, region, accessKey, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			values[lower] = strings.TrimSpace(strings.Join(vals, ","))
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, k := range keys {
+		vals := query[k]
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, uriEscape(k)+"="+uriEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEscape encoding URI versi AWS: semua karakter selain A-Z a-z 0-9 - _ . ~ di-encode, kecuali "/" di path.
+func uriEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// ParseAuthorization memecah header Authorization SigV4 menjadi access key, scope, signed headers dan signature.
+func ParseAuthorization(header string) (accessKey, scope, signedHeaders, signature string, err error) {
+	const prefix = "AWS4-HMAC-SHA256 "
+	if !strings.HasPrefix(header, prefix) {
+		return "", "", "", "", errors.New("authorization bukan AWS4-HMAC-SHA256")
+	}
+
+	for _, part := range strings.Split(header[len(prefix):], ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "Credential":
+			cred := strings.SplitN(kv[1], "/", 2)
+			if len(cred) == 2 {
+				accessKey, scope = cred[0], cred[1]
+			}
+		case "SignedHeaders":
+			signedHeaders = kv[1]
+		case "Signature":
+			signature = kv[1]
+		}
+	}
+	if accessKey == "" || signature == "" {
+		return "", "", "", "", errors.New("authorization tidak lengkap")
+	}
+	return accessKey, scope, signedHeaders, signature, nil
+}
@@ -0,0 +1,128 @@
+// Package s3test menyediakan server in-process yang kompatibel dengan S3 (PUT, GET, DELETE object
+// dengan signature V4) untuk testing, mirip net/http/httptest.
+package s3test
+
+import (
+	"api/blobstore"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+}
+
+// Server menyimpan object di memori. Request yang signature-nya tidak cocok dengan
+// AccessKey/SecretKey ditolak dengan 403 SignatureDoesNotMatch.
+type Server struct {
+	*httptest.Server
+	Region    string
+	AccessKey string
+	SecretKey string
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+func NewServer(region, accessKey, secretKey string) *Server {
+	s := &Server{
+		Region:    region,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		objects:   map[string]object{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Object mengembalikan isi object bucket/key yang tersimpan, untuk assertion di test.
+func (s *Server) Object(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[bucket+"/"+key]
+	return obj.data, ok
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	if code := s.verify(r, body); code != "" {
+		writeError(w, http.StatusForbidden, code)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.Contains(name, "/") {
+		writeError(w, http.StatusBadRequest, "InvalidRequest")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[name] = object{data: body, contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		obj, ok := s.objects[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// verify menandatangani ulang request dengan secret server dan membandingkan signature-nya.
+func (s *Server) verify(r *http.Request, body []byte) string {
+	accessKey, scope, _, signature, err := blobstore.ParseAuthorization(r.Header.Get("Authorization"))
+	if err != nil || accessKey != s.AccessKey {
+		return "InvalidAccessKeyId"
+	}
+	if !strings.Contains(scope, "/"+s.Region+"/s3/") {
+		return "AuthorizationHeaderMalformed"
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != blobstore.PayloadHash(body) {
+		return "XAmzContentSHA256Mismatch"
+	}
+	date, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || time.Since(date) > 15*time.Minute || time.Until(date) > 15*time.Minute {
+		return "RequestTimeTooSkewed"
+	}
+
+	clone := r.Clone(r.Context())
+	clone.Header.Del("Authorization")
+	blobstore.SignV4(clone, payloadHash, s.Region, s.AccessKey, s.SecretKey, date)
+	_, _, _, expected, _ := blobstore.ParseAuthorization(clone.Header.Get("Authorization"))
+	if expected != signature {
+		return "SignatureDoesNotMatch"
+	}
+
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code></Error>", code)
+}
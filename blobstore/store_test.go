@@ -0,0 +1,77 @@
+package blobstore_test
+
+import (
+	"api/blobstore"
+	"api/blobstore/s3test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testStore menjalankan skenario yang sama untuk setiap implementasi BlobStore.
+func testStore(t *testing.T, store blobstore.BlobStore) {
+	t.Run("simpan dan ambil", func(t *testing.T) {
+		err := store.Put("avatars/1/128.jpg", []byte("jpeg"), "image/jpeg")
+		assert.Nil(t, err)
+
+		data, contentType, err := store.Get("avatars/1/128.jpg")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("jpeg"), data)
+		assert.Equal(t, "image/jpeg", contentType)
+	})
+
+	t.Run("timpa", func(t *testing.T) {
+		assert.Nil(t, store.Put("avatars/1/128.jpg", []byte("baru"), "image/jpeg"))
+
+		data, _, err := store.Get("avatars/1/128.jpg")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("baru"), data)
+	})
+
+	t.Run("hapus", func(t *testing.T) {
+		assert.Nil(t, store.Delete("avatars/1/128.jpg"))
+		assert.Nil(t, store.Delete("avatars/1/128.jpg"))
+
+		_, _, err := store.Get("avatars/1/128.jpg")
+		assert.ErrorIs(t, err, blobstore.ErrNotFound)
+	})
+
+	t.Run("key tidak valid", func(t *testing.T) {
+		for _, key := range []string{"", "/etc/passwd", "avatars/../../x.jpg", "avatars//1.jpg"} {
+			assert.Error(t, store.Put(key, []byte("x"), "image/jpeg"), key)
+		}
+	})
+}
+
+func TestLocal(t *testing.T) {
+	testStore(t, blobstore.NewLocal(t.TempDir()))
+}
+
+func TestS3(t *testing.T) {
+	srv := s3test.NewServer("ap-southeast-1", "AKIDTEST", "rahasia")
+	defer srv.Close()
+
+	store := blobstore.NewS3(blobstore.S3Config{
+		Endpoint:  srv.URL,
+		Region:    "ap-southeast-1",
+		Bucket:    "buku",
+		AccessKey: "AKIDTEST",
+		SecretKey: "rahasia",
+	})
+	testStore(t, store)
+
+	t.Run("object tersimpan di bucket", func(t *testing.T) {
+		assert.Nil(t, store.Put("avatars/2/64.jpg", []byte("jpeg"), "image/jpeg"))
+		data, ok := srv.Object("buku", "avatars/2/64.jpg")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("jpeg"), data)
+	})
+
+	t.Run("secret salah ditolak", func(t *testing.T) {
+		wrong := blobstore.NewS3(blobstore.S3Config{Endpoint: srv.URL, Region: "ap-southeast-1", Bucket: "buku", AccessKey: "AKIDTEST", SecretKey: "salah"})
+		err := wrong.Put("avatars/3/64.jpg", []byte("jpeg"), "image/jpeg")
+		assert.ErrorContains(t, err, "SignatureDoesNotMatch")
+		_, ok := srv.Object("buku", "avatars/3/64.jpg")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,22 @@
+package config
+
+import "api/blobstore"
+
+// InitBlobStore memakai storage S3 jika BLOB_STORE=s3, selain itu file lokal di BLOB_DIR (default ./uploads).
+func InitBlobStore(ac AppConfig) blobstore.BlobStore {
+	if ac.BlobStore == "s3" {
+		return blobstore.NewS3(blobstore.S3Config{
+			Endpoint:  ac.S3Endpoint,
+			Region:    ac.S3Region,
+			Bucket:    ac.S3Bucket,
+			AccessKey: ac.S3AccessKey,
+			SecretKey: ac.S3SecretKey,
+		})
+	}
+
+	dir := ac.BlobDir
+	if dir == "" {
+		dir = "uploads"
+	}
+	return blobstore.NewLocal(dir)
+}
@@ -4,21 +4,69 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 var (
-	JWT_KEY string = ""
+	JWT_KEY           string        = ""
+	ACCESS_TOKEN_TTL  time.Duration = 15 * time.Minute
+	REFRESH_TOKEN_TTL time.Duration = 7 * 24 * time.Hour
+	APP_URL           string        = "http://localhost:8000"
+
+	// DELETION_GRACE masa tenggang akun yang dihapus sebelum dihapus permanen
+	DELETION_GRACE time.Duration = 30 * 24 * time.Hour
+	// PURGE_BOOKS_TO id user penerima buku dari akun yang dihapus permanen, 0 berarti buku ikut dihapus
+	PURGE_BOOKS_TO uint = 0
+	// AVATAR_MAX_SIZE batas ukuran file upload avatar dalam byte
+	AVATAR_MAX_SIZE int64 = 2 << 20
 )
 
 type AppConfig struct {
-	DBUser string
-	DBPass string
-	DBHost string
-	DBPort int
-	DBName string
-	jwtKey string
+	DBUser               string
+	DBPass               string
+	DBHost               string
+	DBPort               int
+	DBName               string
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration
+	AppURL               string
+	Mailer               string
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUser             string
+	SMTPPass             string
+	MailFrom             string
+	MailFile             string
+	OIDCIssuer           string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	PasswordMinLength    int
+	PasswordRequire      string
+	PasswordHistory      int
+	PasswordBreachedFile string
+	JWTKeysDir           string
+	JWTActiveKID         string
+	DeletionGrace        time.Duration
+	PurgeBooksTo         uint
+	AvatarMaxSize        int64
+	BlobStore            string
+	BlobDir              string
+	S3Endpoint           string
+	S3Region             string
+	S3Bucket             string
+	S3AccessKey          string
+	S3SecretKey          string
+	SearchBackend        string
+	MetadataProvider     string
+	MetadataURL          string
+	MetadataFile         string
+	MetadataTimeout      time.Duration
+	MetadataCacheTTL     time.Duration
+	jwtKey               string
 }
 
 func InitConfig() *AppConfig {
@@ -54,6 +102,124 @@ func ReadEnv() *AppConfig {
 		app.DBName = val
 		isRead = false
 	}
+	if val, found := os.LookupEnv("ACCESS_TOKEN_TTL"); found {
+		cnv, _ := time.ParseDuration(val)
+		app.AccessTokenTTL = cnv
+	}
+	if val, found := os.LookupEnv("REFRESH_TOKEN_TTL"); found {
+		cnv, _ := time.ParseDuration(val)
+		app.RefreshTokenTTL = cnv
+	}
+	if val, found := os.LookupEnv("APP_URL"); found {
+		app.AppURL = val
+	}
+	if val, found := os.LookupEnv("MAILER"); found {
+		app.Mailer = val
+	}
+	if val, found := os.LookupEnv("SMTP_HOST"); found {
+		app.SMTPHost = val
+	}
+	if val, found := os.LookupEnv("SMTP_PORT"); found {
+		cnv, _ := strconv.Atoi(val)
+		app.SMTPPort = cnv
+	}
+	if val, found := os.LookupEnv("SMTP_USER"); found {
+		app.SMTPUser = val
+	}
+	if val, found := os.LookupEnv("SMTP_PASS"); found {
+		app.SMTPPass = val
+	}
+	if val, found := os.LookupEnv("MAIL_FROM"); found {
+		app.MailFrom = val
+	}
+	if val, found := os.LookupEnv("MAIL_FILE"); found {
+		app.MailFile = val
+	}
+
+	if val, found := os.LookupEnv("OIDC_ISSUER"); found {
+		app.OIDCIssuer = val
+	}
+	if val, found := os.LookupEnv("OIDC_CLIENT_ID"); found {
+		app.OIDCClientID = val
+	}
+	if val, found := os.LookupEnv("OIDC_CLIENT_SECRET"); found {
+		app.OIDCClientSecret = val
+	}
+	if val, found := os.LookupEnv("OIDC_REDIRECT_URL"); found {
+		app.OIDCRedirectURL = val
+	}
+	if val, found := os.LookupEnv("PASSWORD_MIN_LENGTH"); found {
+		cnv, _ := strconv.Atoi(val)
+		app.PasswordMinLength = cnv
+	}
+	if val, found := os.LookupEnv("PASSWORD_REQUIRE"); found {
+		app.PasswordRequire = val
+	}
+	if val, found := os.LookupEnv("PASSWORD_HISTORY"); found {
+		cnv, _ := strconv.Atoi(val)
+		app.PasswordHistory = cnv
+	}
+	if val, found := os.LookupEnv("PASSWORD_BREACHED_FILE"); found {
+		app.PasswordBreachedFile = val
+	}
+	if val, found := os.LookupEnv("JWT_KEYS_DIR"); found {
+		app.JWTKeysDir = val
+	}
+	if val, found := os.LookupEnv("JWT_ACTIVE_KID"); found {
+		app.JWTActiveKID = val
+	}
+	if val, found := os.LookupEnv("ACCOUNT_DELETION_GRACE"); found {
+		app.DeletionGrace = parseDuration("ACCOUNT_DELETION_GRACE", val)
+	}
+	if val, found := os.LookupEnv("ACCOUNT_PURGE_BOOKS_TO"); found {
+		cnv, _ := strconv.Atoi(val)
+		if cnv > 0 {
+			app.PurgeBooksTo = uint(cnv)
+		}
+	}
+	if val, found := os.LookupEnv("AVATAR_MAX_SIZE"); found {
+		cnv, _ := strconv.ParseInt(val, 10, 64)
+		app.AvatarMaxSize = cnv
+	}
+	if val, found := os.LookupEnv("BLOB_STORE"); found {
+		app.BlobStore = val
+	}
+	if val, found := os.LookupEnv("BLOB_DIR"); found {
+		app.BlobDir = val
+	}
+	if val, found := os.LookupEnv("S3_ENDPOINT"); found {
+		app.S3Endpoint = val
+	}
+	if val, found := os.LookupEnv("S3_REGION"); found {
+		app.S3Region = val
+	}
+	if val, found := os.LookupEnv("S3_BUCKET"); found {
+		app.S3Bucket = val
+	}
+	if val, found := os.LookupEnv("S3_ACCESS_KEY"); found {
+		app.S3AccessKey = val
+	}
+	if val, found := os.LookupEnv("S3_SECRET_KEY"); found {
+		app.S3SecretKey = val
+	}
+	if val, found := os.LookupEnv("SEARCH_BACKEND"); found {
+		app.SearchBackend = val
+	}
+	if val, found := os.LookupEnv("METADATA_PROVIDER"); found {
+		app.MetadataProvider = val
+	}
+	if val, found := os.LookupEnv("METADATA_URL"); found {
+		app.MetadataURL = val
+	}
+	if val, found := os.LookupEnv("METADATA_FILE"); found {
+		app.MetadataFile = val
+	}
+	if val, found := os.LookupEnv("METADATA_TIMEOUT"); found {
+		app.MetadataTimeout = parseDuration("METADATA_TIMEOUT", val)
+	}
+	if val, found := os.LookupEnv("METADATA_CACHE_TTL"); found {
+		app.MetadataCacheTTL = parseDuration("METADATA_CACHE_TTL", val)
+	}
 
 	if isRead {
 		viper.AddConfigPath(".")
@@ -73,5 +239,31 @@ func ReadEnv() *AppConfig {
 	}
 
 	JWT_KEY = app.jwtKey
+	if app.AccessTokenTTL > 0 {
+		ACCESS_TOKEN_TTL = app.AccessTokenTTL
+	}
+	if app.RefreshTokenTTL > 0 {
+		REFRESH_TOKEN_TTL = app.RefreshTokenTTL
+	}
+	if app.AppURL != "" {
+		APP_URL = strings.TrimRight(app.AppURL, "/")
+	}
+	if app.DeletionGrace > 0 {
+		DELETION_GRACE = app.DeletionGrace
+	}
+	PURGE_BOOKS_TO = app.PurgeBooksTo
+	if app.AvatarMaxSize > 0 {
+		AVATAR_MAX_SIZE = app.AvatarMaxSize
+	}
 	return &app
 }
+
+// parseDuration membaca durasi env, nilai yang salah dicatat di log dan dianggap kosong sehingga default dipakai.
+func parseDuration(name, val string) time.Duration {
+	cnv, err := time.ParseDuration(val)
+	if err != nil {
+		log.Println("format", name, "salah, memakai default :", err.Error())
+		return 0
+	}
+	return cnv
+}
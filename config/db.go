@@ -1,6 +1,7 @@
 package config
 
 import (
+	apikey "api/features/apikey/data"
 	book "api/features/book/data"
 	user "api/features/user/data"
 	"fmt"
@@ -23,6 +24,25 @@ func InitDB(ac AppConfig) *gorm.DB {
 }
 
 func Migrate(db *gorm.DB) {
+	// user lama dianggap sudah terverifikasi saat kolom verifikasi pertama kali dibuat
+	backfillVerified := db.Migrator().HasTable(&user.User{}) && !db.Migrator().HasColumn(&user.User{}, "EmailVerifiedAt")
 	db.AutoMigrate(user.User{})
+	if backfillVerified {
+		db.Unscoped().Model(&user.User{}).Where("email_verified_at IS NULL").Update("email_verified_at", gorm.Expr("created_at"))
+	}
+	db.AutoMigrate(user.RefreshToken{})
+	db.AutoMigrate(user.RevokedToken{})
+	db.AutoMigrate(user.PasswordReset{})
+	db.AutoMigrate(user.RecoveryCode{})
+	db.AutoMigrate(user.Session{})
+	db.AutoMigrate(user.PasswordHistory{})
+	db.AutoMigrate(user.LoginAttempt{})
+	db.AutoMigrate(user.EmailChange{})
+	db.AutoMigrate(user.SecurityEvent{})
+	// ISBN kosong dari versi sebelumnya jadi NULL sebelum unique index (user_id, isbn) dibuat
+	if db.Migrator().HasColumn(&book.Books{}, "ISBN") {
+		db.Unscoped().Model(&book.Books{}).Where("isbn = '' OR deleted_at IS NOT NULL").Update("isbn", nil)
+	}
 	db.AutoMigrate(book.Books{})
+	db.AutoMigrate(apikey.APIKey{})
 }
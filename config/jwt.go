@@ -0,0 +1,78 @@
+package config
+
+import (
+	"api/keyset"
+	"errors"
+	"sync"
+	"time"
+)
+
+// legacyKID kid untuk token HS256 lama yang diterbitkan sebelum ada key set (tanpa header kid).
+const legacyKID = ""
+
+var (
+	jwtKeys *keyset.KeySet
+
+	defaultKeys     *keyset.KeySet
+	defaultKeysOnce sync.Once
+)
+
+// JWTKeys key set untuk menandatangani dan memverifikasi access token. Sebelum InitJWTKeys
+// dipanggil (misalnya di unit test) dipakai HS256 dengan JWT_KEY, dibuat sekali saat pertama dipakai.
+func JWTKeys() *keyset.KeySet {
+	if jwtKeys != nil {
+		return jwtKeys
+	}
+	defaultKeysOnce.Do(func() {
+		ks := keyset.New()
+		ks.Add(keyset.NewHMAC(legacyKID, []byte(JWT_KEY)))
+		ks.SetActive(legacyKID)
+		defaultKeys = ks
+	})
+	return defaultKeys
+}
+
+// InitJWTKeys memuat kunci dari JWT_KEYS_DIR. Tanpa JWT_KEYS_DIR tetap memakai HS256 dengan JWT_KEY.
+// Saat pindah dari HS256, token lama tanpa kid masih diterima selama ACCESS_TOKEN_TTL.
+// JWT_KEY selalu wajib karena link email dan token internal lain (helper.GenerateSignedToken)
+// tetap ditandatangani dengan kunci ini, dengan atau tanpa JWT_KEYS_DIR.
+func InitJWTKeys(ac AppConfig) error {
+	if JWT_KEY == "" {
+		return errors.New("JWT_KEY wajib diisi")
+	}
+	if ac.JWTKeysDir == "" {
+		jwtKeys = nil
+		return nil
+	}
+
+	keys, err := keyset.LoadDir(ac.JWTKeysDir)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("JWT_KEYS_DIR tidak berisi file .pem")
+	}
+
+	ks := keyset.New()
+	for _, key := range keys {
+		ks.Add(key)
+	}
+
+	active := ac.JWTActiveKID
+	if active == "" {
+		if len(keys) > 1 {
+			return errors.New("JWT_ACTIVE_KID wajib diisi jika ada lebih dari satu kunci")
+		}
+		active = keys[0].ID
+	}
+	if err := ks.SetActive(active); err != nil {
+		return err
+	}
+
+	legacy := keyset.NewHMAC(legacyKID, []byte(JWT_KEY))
+	legacy.NotAfter = time.Now().Add(ACCESS_TOKEN_TTL)
+	ks.Add(legacy)
+
+	jwtKeys = ks
+	return nil
+}
@@ -0,0 +1,11 @@
+package config
+
+import "api/mailer"
+
+func InitMailer(ac AppConfig) mailer.Mailer {
+	if ac.Mailer == "smtp" {
+		return mailer.NewSMTP(ac.SMTPHost, ac.SMTPPort, ac.SMTPUser, ac.SMTPPass, ac.MailFrom)
+	}
+
+	return mailer.NewFile(ac.MailFile)
+}
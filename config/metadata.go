@@ -0,0 +1,47 @@
+package config
+
+import (
+	"api/metadata"
+	"log"
+	"time"
+)
+
+const (
+	// metadataFailures jumlah kegagalan berturut-turut sebelum provider metadata diputus
+	metadataFailures = 5
+	metadataCooldown = time.Minute
+)
+
+// InitMetadata mengembalikan nil jika METADATA_PROVIDER tidak diisi, buku ditambah tanpa metadata.
+// "openlibrary" memakai METADATA_URL (default openlibrary.org), "local" membaca fixture METADATA_FILE.
+func InitMetadata(ac AppConfig) metadata.Provider {
+	var provider metadata.Provider
+	switch ac.MetadataProvider {
+	case "":
+		return nil
+	case "local":
+		local, err := metadata.LoadLocal(ac.MetadataFile)
+		if err != nil {
+			log.Println("load metadata fixture error", err.Error())
+			return nil
+		}
+		provider = local
+	default:
+		url := ac.MetadataURL
+		if url == "" {
+			url = "https://openlibrary.org"
+		}
+		provider = metadata.NewOpenLibrary(url)
+	}
+
+	timeout := ac.MetadataTimeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	ttl := ac.MetadataCacheTTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return metadata.NewCache(metadata.NewBreaker(provider, timeout, metadataFailures, metadataCooldown), ttl)
+}
@@ -0,0 +1,17 @@
+package config
+
+import "api/oidc"
+
+// InitOIDC mengembalikan nil jika OIDC_ISSUER tidak diisi, login OIDC jadi nonaktif.
+func InitOIDC(ac AppConfig) oidc.IdentityProvider {
+	if ac.OIDCIssuer == "" {
+		return nil
+	}
+
+	redirectURL := ac.OIDCRedirectURL
+	if redirectURL == "" {
+		redirectURL = APP_URL + "/login/oidc/callback"
+	}
+
+	return oidc.NewClient(ac.OIDCIssuer, ac.OIDCClientID, ac.OIDCClientSecret, redirectURL)
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"api/pwpolicy"
+	"log"
+	"strings"
+)
+
+// InitPasswordPolicy memakai pwpolicy.Default dengan override dari env.
+// PASSWORD_REQUIRE berisi daftar upper,lower,digit,symbol dipisah koma, "none" untuk tanpa syarat.
+func InitPasswordPolicy(ac AppConfig) *pwpolicy.Policy {
+	policy := pwpolicy.Default()
+	if ac.PasswordMinLength > 0 {
+		policy.MinLength = ac.PasswordMinLength
+	}
+	if ac.PasswordHistory > 0 {
+		policy.History = ac.PasswordHistory
+	}
+
+	if ac.PasswordRequire != "" {
+		policy.RequireUpper = false
+		policy.RequireLower = false
+		policy.RequireDigit = false
+		policy.RequireSymbol = false
+		for _, rule := range strings.Split(ac.PasswordRequire, ",") {
+			switch strings.TrimSpace(rule) {
+			case "upper":
+				policy.RequireUpper = true
+			case "lower":
+				policy.RequireLower = true
+			case "digit":
+				policy.RequireDigit = true
+			case "symbol":
+				policy.RequireSymbol = true
+			}
+		}
+	}
+
+	if ac.PasswordBreachedFile != "" {
+		if err := policy.LoadBreachedList(ac.PasswordBreachedFile); err != nil {
+			log.Println("load breached password list error : ", err.Error())
+		}
+	}
+
+	return policy
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"api/features/book"
+	bd "api/features/book/data"
+	"api/search"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// InitSearcher memakai FULLTEXT index MySQL, atau index di memori jika SEARCH_BACKEND=memory
+// untuk database tanpa FULLTEXT. Index memori diisi dari tabel books saat aplikasi start.
+func InitSearcher(ac AppConfig, db *gorm.DB) search.Searcher {
+	if ac.SearchBackend != "memory" {
+		return search.NewMySQL(db, "books", book.SearchFields...)
+	}
+
+	idx := search.NewMemory(book.SearchFields...)
+	rows := []bd.Books{}
+	if err := db.Find(&rows).Error; err != nil {
+		log.Println("load search index error", err.Error())
+		return idx
+	}
+	for _, row := range rows {
+		idx.Index(book.ToDocument(bd.ToCore(row)))
+	}
+
+	return idx
+}
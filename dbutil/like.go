@@ -0,0 +1,11 @@
+// Package dbutil berisi utilitas kecil untuk query database.
+package dbutil
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike meng-escape \, % dan _ supaya input user dicari sebagai teks biasa pada LIKE.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
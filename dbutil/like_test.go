@@ -0,0 +1,14 @@
+package dbutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, "naruto", EscapeLike("naruto"))
+	assert.Equal(t, `100\%`, EscapeLike("100%"))
+	assert.Equal(t, `a\_b`, EscapeLike("a_b"))
+	assert.Equal(t, `c:\\dir`, EscapeLike(`c:\dir`))
+}
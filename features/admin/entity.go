@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"api/auth"
+	"api/features/user"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminHandler interface {
+	ListUsers() echo.HandlerFunc
+	GetUser() echo.HandlerFunc
+	Suspend() echo.HandlerFunc
+	Unsuspend() echo.HandlerFunc
+	Restore() echo.HandlerFunc
+	ForcePasswordReset() echo.HandlerFunc
+	Purge() echo.HandlerFunc
+	SecurityEvents() echo.HandlerFunc
+}
+
+type AdminService interface {
+	ListUsers(filter user.UserFilter) ([]user.Core, int64, error)
+	GetUser(userID uint) (user.Core, error)
+	Suspend(p auth.Principal, userID uint) error
+	Unsuspend(p auth.Principal, userID uint) error
+	Restore(p auth.Principal, userID uint) error
+	ForcePasswordReset(p auth.Principal, userID uint) error
+	Purge(p auth.Principal, userID uint) error
+	SecurityEvents(filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"api/features/admin"
+	"api/helper"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type adminControll struct {
+	srv admin.AdminService
+}
+
+func New(srv admin.AdminService) admin.AdminHandler {
+	return &adminControll{
+		srv: srv,
+	}
+}
+
+func paramID(c echo.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, errors.New("format id user salah")
+	}
+	return uint(id), nil
+}
+
+func (ac *adminControll) ListUsers() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter := ToFilter(c)
+
+		res, total, err := ac.srv.ListUsers(filter)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		paging := helper.Pagination{Page: filter.Page, Limit: filter.Limit, Total: total}
+		return c.JSON(helper.PrintPagingResponse(http.StatusOK, "sukses menampilkan user", ListToResponse(res), paging))
+	}
+}
+
+func (ac *adminControll) GetUser() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, err := ac.srv.GetUser(id)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan user", ToResponse(res)))
+	}
+}
+
+func (ac *adminControll) Suspend() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		if err := ac.srv.Suspend(helper.Principal(c), id); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menangguhkan user"))
+	}
+}
+
+func (ac *adminControll) Unsuspend() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		if err := ac.srv.Unsuspend(helper.Principal(c), id); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses mengaktifkan kembali user"))
+	}
+}
+
+func (ac *adminControll) Restore() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		if err := ac.srv.Restore(helper.Principal(c), id); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses memulihkan user"))
+	}
+}
+
+func (ac *adminControll) ForcePasswordReset() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		if err := ac.srv.ForcePasswordReset(helper.Principal(c), id); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "user wajib reset password"))
+	}
+}
+
+func (ac *adminControll) Purge() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		if err := ac.srv.Purge(helper.Principal(c), id); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menghapus permanen user"))
+	}
+}
+
+func (ac *adminControll) SecurityEvents() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter, err := ToEventFilter(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, total, err := ac.srv.SecurityEvents(filter)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		paging := helper.Pagination{Page: filter.Page, Limit: filter.Limit, Total: total}
+		return c.JSON(helper.PrintPagingResponse(http.StatusOK, "sukses menampilkan riwayat keamanan", ListToEventResponse(res), paging))
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"api/features/user"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ToFilter(c echo.Context) user.UserFilter {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	filter := user.UserFilter{
+		Search: c.QueryParam("q"),
+		Role:   c.QueryParam("role"),
+		Status: c.QueryParam("status"),
+		Page:   page,
+		Limit:  limit,
+	}
+	filter.Normalize()
+
+	return filter
+}
+
+// ToEventFilter membaca filter riwayat keamanan. from/to boleh RFC3339 atau tanggal (2006-01-02),
+// to berupa tanggal ikut mencakup seluruh hari tersebut.
+func ToEventFilter(c echo.Context) (user.SecurityEventFilter, error) {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	filter := user.SecurityEventFilter{
+		Type:  c.QueryParam("type"),
+		Page:  page,
+		Limit: limit,
+	}
+	if val := c.QueryParam("user_id"); val != "" {
+		id, err := strconv.Atoi(val)
+		if err != nil || id <= 0 {
+			return user.SecurityEventFilter{}, errors.New("format id user salah")
+		}
+		filter.UserID = uint(id)
+	}
+
+	var err error
+	if filter.From, err = parseTime(c.QueryParam("from"), false); err != nil {
+		return user.SecurityEventFilter{}, errors.New("format tanggal from salah")
+	}
+	if filter.To, err = parseTime(c.QueryParam("to"), true); err != nil {
+		return user.SecurityEventFilter{}, errors.New("format tanggal to salah")
+	}
+	filter.Normalize()
+
+	return filter, nil
+}
+
+func parseTime(val string, endOfDay bool) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", val)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
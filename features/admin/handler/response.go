@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"api/features/user"
+	"time"
+)
+
+type UserResponse struct {
+	ID                    uint      `json:"id"`
+	Name                  string    `json:"nama"`
+	Email                 string    `json:"email"`
+	Alamat                string    `json:"alamat"`
+	HP                    string    `json:"hp"`
+	Role                  string    `json:"role"`
+	Suspended             bool      `json:"suspended"`
+	PasswordResetRequired bool      `json:"password_reset_required"`
+	Deleted               bool      `json:"deleted"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+func ToResponse(data user.Core) UserResponse {
+	return UserResponse{
+		ID:                    data.ID,
+		Name:                  data.Name,
+		Email:                 data.Email,
+		Alamat:                data.Alamat,
+		HP:                    data.HP,
+		Role:                  data.Role,
+		Suspended:             data.Suspended,
+		PasswordResetRequired: data.PasswordResetRequired,
+		Deleted:               data.Deleted,
+		CreatedAt:             data.CreatedAt,
+	}
+}
+
+func ListToResponse(data []user.Core) []UserResponse {
+	res := []UserResponse{}
+	for _, value := range data {
+		res = append(res, ToResponse(value))
+	}
+	return res
+}
+
+type SecurityEventResponse struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ListToEventResponse(data []user.SecurityEventCore) []SecurityEventResponse {
+	res := []SecurityEventResponse{}
+	for _, v := range data {
+		res = append(res, SecurityEventResponse{
+			ID:        v.ID,
+			UserID:    v.UserID,
+			Type:      v.Type,
+			IP:        v.IP,
+			UserAgent: v.UserAgent,
+			Detail:    v.Detail,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+	return res
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"api/auth"
+	"api/blobstore"
+	"api/config"
+	"api/features/admin"
+	"api/features/user"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type adminSrv struct {
+	qry user.UserData
+	bs  blobstore.BlobStore
+}
+
+func New(ud user.UserData, bs blobstore.BlobStore) admin.AdminService {
+	return &adminSrv{
+		qry: ud,
+		bs:  bs,
+	}
+}
+
+func (as *adminSrv) ListUsers(filter user.UserFilter) ([]user.Core, int64, error) {
+	filter.Normalize()
+	switch filter.Status {
+	case "", "active", "suspended", "deleted", "all":
+	default:
+		return nil, 0, errors.New("format status salah")
+	}
+
+	res, total, err := as.qry.ListUsers(filter)
+	if err != nil {
+		return nil, 0, errors.New("terdapat masalah pada server")
+	}
+
+	return res, total, nil
+}
+
+func (as *adminSrv) GetUser(userID uint) (user.Core, error) {
+	res, err := as.qry.GetByID(userID)
+	if err != nil {
+		return user.Core{}, errorMsg(err)
+	}
+
+	return res, nil
+}
+
+func (as *adminSrv) Suspend(p auth.Principal, userID uint) error {
+	if err := checkSelf(p, userID); err != nil {
+		return err
+	}
+
+	if err := as.qry.SetSuspended(userID, true); err != nil {
+		return errorMsg(err)
+	}
+	if err := as.qry.RevokeUserRefreshTokens(userID); err != nil {
+		log.Println("revoke refresh token error", err.Error())
+	}
+	as.recordEvent(p, userID, user.EventSuspended)
+
+	return nil
+}
+
+func (as *adminSrv) Unsuspend(p auth.Principal, userID uint) error {
+	if err := as.qry.SetSuspended(userID, false); err != nil {
+		return errorMsg(err)
+	}
+	as.recordEvent(p, userID, user.EventUnsuspended)
+
+	return nil
+}
+
+func (as *adminSrv) Restore(p auth.Principal, userID uint) error {
+	if err := as.qry.Restore(userID); err != nil {
+		return errorMsg(err)
+	}
+	as.recordEvent(p, userID, user.EventRestored)
+
+	return nil
+}
+
+func (as *adminSrv) ForcePasswordReset(p auth.Principal, userID uint) error {
+	if err := checkSelf(p, userID); err != nil {
+		return err
+	}
+
+	if err := as.qry.SetPasswordResetRequired(userID, true); err != nil {
+		return errorMsg(err)
+	}
+	if err := as.qry.RevokeUserRefreshTokens(userID); err != nil {
+		log.Println("revoke refresh token error", err.Error())
+	}
+	as.recordEvent(p, userID, user.EventPasswordResetForced)
+
+	return nil
+}
+
+func (as *adminSrv) Purge(p auth.Principal, userID uint) error {
+	if err := checkSelf(p, userID); err != nil {
+		return err
+	}
+
+	usr, err := as.qry.GetByID(userID)
+	if err != nil {
+		return errorMsg(err)
+	}
+	if err := as.qry.Purge(userID, config.PURGE_BOOKS_TO); err != nil {
+		return errorMsg(err)
+	}
+	// riwayat keamanan disimpan purge (hanya dianonimkan), jadi jejak penghapusan tetap bisa diaudit
+	as.recordEvent(p, userID, user.EventPurged)
+
+	// avatar bukan bagian dari database, hapus setelah data user benar-benar terhapus
+	if usr.Avatar != "" {
+		for _, size := range user.AvatarSizes {
+			if err := as.bs.Delete(user.AvatarKey(usr.Avatar, size)); err != nil {
+				log.Println("avatar delete error", err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordEvent mencatat aksi admin di riwayat keamanan user target, gagal menulis tidak menggagalkan aksi.
+func (as *adminSrv) recordEvent(p auth.Principal, userID uint, eventType string) {
+	event := user.SecurityEventCore{
+		UserID:    userID,
+		Type:      eventType,
+		IP:        p.IP,
+		UserAgent: p.UserAgent,
+		Detail:    fmt.Sprintf("admin %d", p.UserID),
+		CreatedAt: time.Now(),
+	}
+	if err := as.qry.SaveSecurityEvent(event); err != nil {
+		log.Println("save security event error", err.Error())
+	}
+}
+
+// checkSelf mencegah admin mengunci atau menghapus akunnya sendiri.
+func checkSelf(p auth.Principal, userID uint) error {
+	if int(p.UserID) == int(userID) {
+		return errors.New("akses ditolak, tidak bisa dilakukan pada akun sendiri")
+	}
+	return nil
+}
+
+func errorMsg(err error) error {
+	if strings.Contains(err.Error(), "not found") {
+		return errors.New("data user not found")
+	}
+	return errors.New("terdapat masalah pada server")
+}
+
+// SecurityEvents riwayat keamanan seluruh user, bisa difilter per user, jenis dan rentang waktu.
+func (as *adminSrv) SecurityEvents(filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error) {
+	filter.Normalize()
+	if filter.Type != "" && !user.ValidEventType(filter.Type) {
+		return nil, 0, errors.New("format type event salah")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, 0, errors.New("format rentang waktu salah")
+	}
+
+	res, total, err := as.qry.ListSecurityEvents(filter)
+	if err != nil {
+		return nil, 0, errors.New("terdapat masalah pada server")
+	}
+
+	return res, total, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"api/auth"
+	"api/features/user"
+	"api/helper"
+	"api/mocks"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func adminToken(id int) auth.Principal {
+	return auth.Principal{UserID: uint(id), Roles: []string{helper.RoleAdmin}, IP: "127.0.0.1", UserAgent: "test-agent"}
+}
+
+// expectEvent memastikan aksi admin 1 tercatat di riwayat keamanan user target.
+func expectEvent(repo *mocks.UserData, userID uint, eventType string) {
+	repo.On("SaveSecurityEvent", mock.MatchedBy(func(event user.SecurityEventCore) bool {
+		return event.UserID == userID && event.Type == eventType && event.Detail == "admin 1" && event.IP == "127.0.0.1"
+	})).Return(nil).Once()
+}
+
+func TestListUsers(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, nil)
+
+	t.Run("Sukses list user dengan paging default", func(t *testing.T) {
+		resData := []user.Core{{ID: 1, Name: "jerry"}, {ID: 2, Name: "fajar"}}
+		repo.On("ListUsers", user.UserFilter{Search: "j", Page: 1, Limit: 20}).Return(resData, int64(2), nil).Once()
+
+		res, total, err := srv.ListUsers(user.UserFilter{Search: "j"})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, res, 2)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("limit dibatasi maksimal", func(t *testing.T) {
+		repo.On("ListUsers", user.UserFilter{Page: 3, Limit: 100}).Return([]user.Core{}, int64(0), nil).Once()
+
+		_, _, err := srv.ListUsers(user.UserFilter{Page: 3, Limit: 1000})
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("status tidak dikenal", func(t *testing.T) {
+		res, _, err := srv.ListUsers(user.UserFilter{Status: "banned"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format")
+		assert.Nil(t, res)
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("ListUsers", mock.Anything).Return(nil, int64(0), errors.New("database error")).Once()
+
+		res, _, err := srv.ListUsers(user.UserFilter{})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		assert.Nil(t, res)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, nil)
+
+	t.Run("Sukses lihat user yang sudah dihapus", func(t *testing.T) {
+		repo.On("GetByID", uint(2)).Return(user.Core{ID: 2, Deleted: true}, nil).Once()
+
+		res, err := srv.GetUser(2)
+		assert.Nil(t, err)
+		assert.True(t, res.Deleted)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("user tidak ditemukan", func(t *testing.T) {
+		repo.On("GetByID", uint(9)).Return(user.Core{}, errors.New("record not found")).Once()
+
+		_, err := srv.GetUser(9)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSuspend(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, nil)
+
+	t.Run("Sukses suspend user", func(t *testing.T) {
+		repo.On("SetSuspended", uint(2), true).Return(nil).Once()
+		repo.On("RevokeUserRefreshTokens", uint(2)).Return(nil).Once()
+		expectEvent(repo, 2, user.EventSuspended)
+
+		err := srv.Suspend(adminToken(1), 2)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("tidak bisa suspend diri sendiri", func(t *testing.T) {
+		err := srv.Suspend(adminToken(1), 1)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditolak")
+	})
+
+	t.Run("user tidak ditemukan", func(t *testing.T) {
+		repo.On("SetSuspended", uint(9), true).Return(errors.New("user not found")).Once()
+
+		err := srv.Suspend(adminToken(1), 9)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Sukses unsuspend user", func(t *testing.T) {
+		repo.On("SetSuspended", uint(2), false).Return(nil).Once()
+		expectEvent(repo, 2, user.EventUnsuspended)
+
+		err := srv.Unsuspend(adminToken(1), 2)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, nil)
+
+	t.Run("Sukses restore user", func(t *testing.T) {
+		repo.On("Restore", uint(2)).Return(nil).Once()
+		expectEvent(repo, 2, user.EventRestored)
+
+		err := srv.Restore(adminToken(1), 2)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("user tidak pernah dihapus", func(t *testing.T) {
+		repo.On("Restore", uint(3)).Return(errors.New("deleted user not found")).Once()
+
+		err := srv.Restore(adminToken(1), 3)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestForcePasswordReset(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, nil)
+
+	t.Run("Sukses paksa reset password", func(t *testing.T) {
+		repo.On("SetPasswordResetRequired", uint(2), true).Return(nil).Once()
+		repo.On("RevokeUserRefreshTokens", uint(2)).Return(nil).Once()
+		expectEvent(repo, 2, user.EventPasswordResetForced)
+
+		err := srv.ForcePasswordReset(adminToken(1), 2)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPurge(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	blobs := mocks.NewBlobStore(t)
+	srv := New(repo, blobs)
+
+	t.Run("Sukses hapus permanen", func(t *testing.T) {
+		repo.On("GetByID", uint(2)).Return(user.Core{ID: 2}, nil).Once()
+		repo.On("Purge", uint(2), uint(0)).Return(nil).Once()
+		expectEvent(repo, 2, user.EventPurged)
+
+		err := srv.Purge(adminToken(1), 2)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("avatar ikut dihapus", func(t *testing.T) {
+		repo.On("GetByID", uint(2)).Return(user.Core{ID: 2, Avatar: "avatars/2/abc"}, nil).Once()
+		repo.On("Purge", uint(2), uint(0)).Return(nil).Once()
+		expectEvent(repo, 2, user.EventPurged)
+		for _, size := range user.AvatarSizes {
+			blobs.On("Delete", user.AvatarKey("avatars/2/abc", size)).Return(nil).Once()
+		}
+
+		err := srv.Purge(adminToken(1), 2)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("tidak bisa hapus diri sendiri", func(t *testing.T) {
+		err := srv.Purge(adminToken(1), 1)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditolak")
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("GetByID", uint(2)).Return(user.Core{ID: 2, Avatar: "avatars/2/abc"}, nil).Once()
+		repo.On("Purge", uint(2), uint(0)).Return(errors.New("database error")).Once()
+
+		err := srv.Purge(adminToken(1), 2)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSecurityEvents(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, nil)
+
+	t.Run("Sukses lihat riwayat keamanan semua user", func(t *testing.T) {
+		events := []user.SecurityEventCore{{ID: 1, UserID: 2, Type: user.EventLoginFailed}}
+		repo.On("ListSecurityEvents", user.SecurityEventFilter{Page: 1, Limit: 20}).Return(events, int64(1), nil).Once()
+
+		res, total, err := srv.SecurityEvents(user.SecurityEventFilter{})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, res, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("filter user, jenis dan rentang waktu", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		filter := user.SecurityEventFilter{UserID: 2, Type: user.EventEmailChanged, From: from, To: to}
+		repo.On("ListSecurityEvents", user.SecurityEventFilter{UserID: 2, Type: user.EventEmailChanged, From: from, To: to, Page: 1, Limit: 20}).Return([]user.SecurityEventCore{}, int64(0), nil).Once()
+
+		_, _, err := srv.SecurityEvents(filter)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rentang waktu terbalik", func(t *testing.T) {
+		from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+		_, _, err := srv.SecurityEvents(user.SecurityEventFilter{From: from, To: from.AddDate(0, 0, -1)})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format")
+	})
+
+	t.Run("jenis event tidak dikenal", func(t *testing.T) {
+		_, _, err := srv.SecurityEvents(user.SecurityEventFilter{Type: "hack"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format")
+	})
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"api/features/apikey"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKey struct {
+	gorm.Model
+	UserID     uint   `gorm:"index"`
+	Name       string `gorm:"type:varchar(100)"`
+	Prefix     string `gorm:"type:varchar(16)"`
+	KeyHash    string `gorm:"type:varchar(64);uniqueIndex"`
+	Scopes     string
+	LastUsedAt *time.Time
+}
+
+func ToCore(data APIKey) apikey.Core {
+	res := apikey.Core{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		Name:      data.Name,
+		Prefix:    data.Prefix,
+		KeyHash:   data.KeyHash,
+		Scopes:    []string{},
+		CreatedAt: data.CreatedAt,
+	}
+	if data.Scopes != "" {
+		res.Scopes = strings.Split(data.Scopes, ",")
+	}
+	if data.LastUsedAt != nil {
+		res.LastUsedAt = *data.LastUsedAt
+	}
+	return res
+}
+
+func CoreToData(data apikey.Core) APIKey {
+	return APIKey{
+		Model:   gorm.Model{ID: data.ID},
+		UserID:  data.UserID,
+		Name:    data.Name,
+		Prefix:  data.Prefix,
+		KeyHash: data.KeyHash,
+		Scopes:  strings.Join(data.Scopes, ","),
+	}
+}
+
+func ListToCore(data []APIKey) []apikey.Core {
+	res := []apikey.Core{}
+	for _, value := range data {
+		res = append(res, ToCore(value))
+	}
+	return res
+}
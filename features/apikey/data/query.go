@@ -0,0 +1,76 @@
+package data
+
+import (
+	"api/features/apikey"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyQuery struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) apikey.APIKeyData {
+	return &apiKeyQuery{
+		db: db,
+	}
+}
+
+func (aq *apiKeyQuery) Create(newKey apikey.Core) (apikey.Core, error) {
+	cnv := CoreToData(newKey)
+	if err := aq.db.Create(&cnv).Error; err != nil {
+		log.Println("create api key query error", err.Error())
+		return apikey.Core{}, err
+	}
+
+	return ToCore(cnv), nil
+}
+
+func (aq *apiKeyQuery) List(userID uint) ([]apikey.Core, error) {
+	res := []APIKey{}
+	if err := aq.db.Where("user_id = ?", userID).Order("id").Find(&res).Error; err != nil {
+		log.Println("list api key query error", err.Error())
+		return nil, err
+	}
+
+	return ListToCore(res), nil
+}
+
+// GetByHash hanya mengembalikan key yang belum dicabut (soft delete).
+func (aq *apiKeyQuery) GetByHash(keyHash string) (apikey.Core, error) {
+	res := APIKey{}
+	if err := aq.db.Where("key_hash = ?", keyHash).First(&res).Error; err != nil {
+		log.Println("get api key query error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apikey.Core{}, errors.New("data not found")
+		}
+		return apikey.Core{}, err
+	}
+
+	return ToCore(res), nil
+}
+
+func (aq *apiKeyQuery) Revoke(userID uint, keyID uint) error {
+	del := aq.db.Where("user_id = ?", userID).Delete(&APIKey{}, keyID)
+	if del.Error != nil {
+		log.Println("revoke api key query error", del.Error.Error())
+		return del.Error
+	}
+	if del.RowsAffected == 0 {
+		return errors.New("api key not found")
+	}
+
+	return nil
+}
+
+func (aq *apiKeyQuery) Touch(keyID uint, usedAt time.Time) error {
+	if err := aq.db.Model(&APIKey{}).Where("id = ?", keyID).Update("last_used_at", usedAt).Error; err != nil {
+		log.Println("touch api key query error", err.Error())
+		return err
+	}
+
+	return nil
+}
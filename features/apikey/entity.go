@@ -0,0 +1,49 @@
+package apikey
+
+import (
+	"api/auth"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ScopeBooksRead  = "books:read"
+	ScopeBooksWrite = "books:write"
+)
+
+// Scopes yang boleh diminta saat membuat API key. Key yang dibuat tanpa
+// scope otomatis hanya mendapat books:read.
+var Scopes = []string{ScopeBooksRead, ScopeBooksWrite}
+
+type Core struct {
+	ID         uint
+	UserID     uint
+	Name       string `validate:"required,max=100"`
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+type APIKeyHandler interface {
+	Create() echo.HandlerFunc
+	List() echo.HandlerFunc
+	Revoke() echo.HandlerFunc
+}
+
+type APIKeyService interface {
+	Create(p auth.Principal, newKey Core) (Core, string, error)
+	List(p auth.Principal) ([]Core, error)
+	Revoke(p auth.Principal, keyID uint) error
+	CheckAPIKey(key string) (auth.Principal, error)
+}
+
+type APIKeyData interface {
+	Create(newKey Core) (Core, error)
+	List(userID uint) ([]Core, error)
+	GetByHash(keyHash string) (Core, error)
+	Revoke(userID uint, keyID uint) error
+	Touch(keyID uint, usedAt time.Time) error
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"api/features/apikey"
+	"api/helper"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type apiKeyHandle struct {
+	srv apikey.APIKeyService
+}
+
+func New(srv apikey.APIKeyService) apikey.APIKeyHandler {
+	return &apiKeyHandle{
+		srv: srv,
+	}
+}
+
+func (ah *apiKeyHandle) Create() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := CreateRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		res, key, err := ah.srv.Create(helper.Principal(c), ToCore(input))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusCreated, "sukses membuat api key, simpan key ini karena tidak akan ditampilkan lagi", CreateResponse{APIKeyResponse: ToResponse(res), Key: key}))
+	}
+}
+
+func (ah *apiKeyHandle) List() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res, err := ah.srv.List(helper.Principal(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan api key", ListToResponse(res)))
+	}
+}
+
+func (ah *apiKeyHandle) Revoke() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		keyID, err := strconv.Atoi(c.Param("id"))
+		if err != nil || keyID <= 0 {
+			return c.JSON(helper.PrintErrorResponse("format id api key salah"))
+		}
+
+		if err := ah.srv.Revoke(helper.Principal(c), uint(keyID)); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses mencabut api key"))
+	}
+}
@@ -0,0 +1,15 @@
+package handler
+
+import "api/features/apikey"
+
+type CreateRequest struct {
+	Name   string   `json:"name" form:"name"`
+	Scopes []string `json:"scopes" form:"scopes"`
+}
+
+func ToCore(data CreateRequest) apikey.Core {
+	return apikey.Core{
+		Name:   data.Name,
+		Scopes: data.Scopes,
+	}
+}
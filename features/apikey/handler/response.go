@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"api/features/apikey"
+	"time"
+)
+
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func ToResponse(data apikey.Core) APIKeyResponse {
+	res := APIKeyResponse{
+		ID:        data.ID,
+		Name:      data.Name,
+		Prefix:    data.Prefix,
+		Scopes:    data.Scopes,
+		CreatedAt: data.CreatedAt,
+	}
+	if !data.LastUsedAt.IsZero() {
+		res.LastUsedAt = &data.LastUsedAt
+	}
+	return res
+}
+
+func ListToResponse(data []apikey.Core) []APIKeyResponse {
+	res := []APIKeyResponse{}
+	for _, value := range data {
+		res = append(res, ToResponse(value))
+	}
+	return res
+}
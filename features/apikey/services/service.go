@@ -0,0 +1,155 @@
+package services
+
+import (
+	"api/auth"
+	"api/features/apikey"
+	"api/features/user"
+	"api/helper"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	keyPrefix     = "bk_"
+	touchInterval = time.Minute
+)
+
+type apiKeySrv struct {
+	data     apikey.APIKeyData
+	users    user.UserData
+	validasi *validator.Validate
+}
+
+func New(d apikey.APIKeyData, ud user.UserData) apikey.APIKeyService {
+	return &apiKeySrv{
+		data:     d,
+		users:    ud,
+		validasi: validator.New(),
+	}
+}
+
+// Create mengembalikan key utuh hanya sekali, yang disimpan hanya hash dan prefix-nya.
+func (as *apiKeySrv) Create(p auth.Principal, newKey apikey.Core) (apikey.Core, string, error) {
+	userID := int(p.UserID)
+	if userID <= 0 {
+		return apikey.Core{}, "", errors.New("user not found")
+	}
+	if p.IsAPIKey() {
+		return apikey.Core{}, "", errors.New("akses ditolak, API key tidak bisa membuat API key baru")
+	}
+
+	if err := as.validasi.Struct(newKey); err != nil {
+		return apikey.Core{}, "", errors.New("format nama api key salah")
+	}
+	if len(newKey.Scopes) == 0 {
+		newKey.Scopes = []string{apikey.ScopeBooksRead}
+	}
+	for _, scope := range newKey.Scopes {
+		if !validScope(scope) {
+			return apikey.Core{}, "", errors.New("format scope salah: " + scope)
+		}
+	}
+
+	secret, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return apikey.Core{}, "", errors.New("internal server error")
+	}
+	key := keyPrefix + secret
+
+	newKey.UserID = uint(userID)
+	newKey.Prefix = key[:len(keyPrefix)+6]
+	newKey.KeyHash = helper.HashToken(key)
+	res, err := as.data.Create(newKey)
+	if err != nil {
+		return apikey.Core{}, "", errors.New("internal server error")
+	}
+
+	return res, key, nil
+}
+
+func validScope(scope string) bool {
+	for _, val := range apikey.Scopes {
+		if val == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (as *apiKeySrv) List(p auth.Principal) ([]apikey.Core, error) {
+	userID := int(p.UserID)
+	if userID <= 0 {
+		return nil, errors.New("user not found")
+	}
+
+	res, err := as.data.List(uint(userID))
+	if err != nil {
+		return nil, errors.New("internal server error")
+	}
+
+	return res, nil
+}
+
+func (as *apiKeySrv) Revoke(p auth.Principal, keyID uint) error {
+	userID := int(p.UserID)
+	if userID <= 0 {
+		return errors.New("user not found")
+	}
+
+	if err := as.data.Revoke(uint(userID), keyID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("api key not found")
+		}
+		return errors.New("internal server error")
+	}
+
+	return nil
+}
+
+// CheckAPIKey dipanggil middleware untuk setiap request yang memakai header X-API-Key.
+func (as *apiKeySrv) CheckAPIKey(key string) (auth.Principal, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return auth.Principal{}, errors.New("unauthorized, api key tidak valid")
+	}
+
+	res, err := as.data.GetByHash(helper.HashToken(key))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return auth.Principal{}, errors.New("unauthorized, api key tidak valid")
+		}
+		return auth.Principal{}, errors.New("internal server error")
+	}
+
+	usr, err := as.users.Profile(res.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return auth.Principal{}, errors.New("unauthorized, api key tidak valid")
+		}
+		return auth.Principal{}, errors.New("internal server error")
+	}
+	if err := usr.CheckStatus(); err != nil {
+		return auth.Principal{}, err
+	}
+
+	// last used cukup akurat per menit, tidak perlu menulis ke database setiap request
+	if time.Since(res.LastUsedAt) > touchInterval {
+		if err := as.data.Touch(res.ID, time.Now()); err != nil {
+			log.Println("touch api key error", err.Error())
+		}
+	}
+
+	role := usr.Role
+	if role == "" {
+		role = helper.RoleUser
+	}
+	return auth.Principal{
+		UserID:   usr.ID,
+		Roles:    []string{role},
+		Scopes:   res.Scopes,
+		APIKeyID: res.ID,
+	}, nil
+}
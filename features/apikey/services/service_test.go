@@ -0,0 +1,152 @@
+package services
+
+import (
+	"api/auth"
+	"api/features/apikey"
+	"api/features/user"
+	"api/helper"
+	"api/mocks"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func userToken(id int) auth.Principal {
+	return auth.Principal{UserID: uint(id), Roles: []string{helper.RoleUser}}
+}
+
+func TestCreate(t *testing.T) {
+	data := mocks.NewAPIKeyData(t)
+	srv := New(data, mocks.NewUserData(t))
+
+	t.Run("Berhasil membuat api key", func(t *testing.T) {
+		data.On("Create", mock.MatchedBy(func(newKey apikey.Core) bool {
+			return newKey.UserID == 1 && len(newKey.KeyHash) == 64 && strings.HasPrefix(newKey.Prefix, "bk_")
+		})).Return(apikey.Core{ID: 1, UserID: 1, Name: "script", Scopes: []string{apikey.ScopeBooksWrite}}, nil).Once()
+
+		res, key, err := srv.Create(userToken(1), apikey.Core{Name: "script", Scopes: []string{apikey.ScopeBooksWrite}})
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(key, "bk_"))
+		assert.Equal(t, uint(1), res.ID)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("scope default books:read", func(t *testing.T) {
+		data.On("Create", mock.MatchedBy(func(newKey apikey.Core) bool {
+			return len(newKey.Scopes) == 1 && newKey.Scopes[0] == apikey.ScopeBooksRead
+		})).Return(apikey.Core{ID: 2}, nil).Once()
+
+		_, _, err := srv.Create(userToken(1), apikey.Core{Name: "script"})
+		assert.Nil(t, err)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("scope tidak dikenal", func(t *testing.T) {
+		_, key, err := srv.Create(userToken(1), apikey.Core{Name: "script", Scopes: []string{"admin"}})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format scope")
+		assert.Empty(t, key)
+	})
+
+	t.Run("nama kosong", func(t *testing.T) {
+		_, _, err := srv.Create(userToken(1), apikey.Core{})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format nama")
+	})
+
+	t.Run("api key tidak bisa membuat api key", func(t *testing.T) {
+		token := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, APIKeyID: 1, Scopes: []string{apikey.ScopeBooksWrite}}
+
+		_, _, err := srv.Create(token, apikey.Core{Name: "script"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditolak")
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	data := mocks.NewAPIKeyData(t)
+	srv := New(data, mocks.NewUserData(t))
+
+	t.Run("Berhasil mencabut api key", func(t *testing.T) {
+		data.On("Revoke", uint(1), uint(3)).Return(nil).Once()
+
+		err := srv.Revoke(userToken(1), 3)
+		assert.Nil(t, err)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("api key milik user lain", func(t *testing.T) {
+		data.On("Revoke", uint(1), uint(4)).Return(errors.New("api key not found")).Once()
+
+		err := srv.Revoke(userToken(1), 4)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		data.AssertExpectations(t)
+	})
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	data := mocks.NewAPIKeyData(t)
+	users := mocks.NewUserData(t)
+	srv := New(data, users)
+
+	t.Run("api key valid", func(t *testing.T) {
+		stored := apikey.Core{ID: 3, UserID: 1, Scopes: []string{apikey.ScopeBooksRead}}
+		data.On("GetByHash", helper.HashToken("bk_rahasia")).Return(stored, nil).Once()
+		users.On("Profile", uint(1)).Return(user.Core{ID: 1, Role: helper.RoleUser, Verified: true}, nil).Once()
+		data.On("Touch", uint(3), mock.Anything).Return(nil).Once()
+
+		p, err := srv.CheckAPIKey("bk_rahasia")
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), p.UserID)
+		assert.Equal(t, uint(3), p.APIKeyID)
+		assert.True(t, p.HasScope(apikey.ScopeBooksRead))
+		assert.False(t, p.HasScope(apikey.ScopeBooksWrite))
+		data.AssertExpectations(t)
+		users.AssertExpectations(t)
+	})
+
+	t.Run("last used tidak ditulis ulang dalam satu menit", func(t *testing.T) {
+		stored := apikey.Core{ID: 3, UserID: 1, LastUsedAt: time.Now()}
+		data.On("GetByHash", mock.Anything).Return(stored, nil).Once()
+		users.On("Profile", uint(1)).Return(user.Core{ID: 1, Verified: true}, nil).Once()
+
+		_, err := srv.CheckAPIKey("bk_rahasia")
+		assert.Nil(t, err)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("api key dicabut", func(t *testing.T) {
+		data.On("GetByHash", mock.Anything).Return(apikey.Core{}, errors.New("data not found")).Once()
+
+		p, err := srv.CheckAPIKey("bk_rahasia")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "api key tidak valid")
+		assert.Equal(t, uint(0), p.UserID)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("akun ditangguhkan", func(t *testing.T) {
+		data.On("GetByHash", mock.Anything).Return(apikey.Core{ID: 3, UserID: 1}, nil).Once()
+		users.On("Profile", uint(1)).Return(user.Core{ID: 1, Suspended: true}, nil).Once()
+
+		_, err := srv.CheckAPIKey("bk_rahasia")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditolak")
+		data.AssertExpectations(t)
+	})
+
+	t.Run("email belum diverifikasi", func(t *testing.T) {
+		data.On("GetByHash", mock.Anything).Return(apikey.Core{ID: 3, UserID: 1}, nil).Once()
+		users.On("Profile", uint(1)).Return(user.Core{ID: 1}, nil).Once()
+
+		_, err := srv.CheckAPIKey("bk_rahasia")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "belum diverifikasi")
+		data.AssertExpectations(t)
+	})
+}
@@ -2,16 +2,22 @@ package data
 
 import (
 	"api/features/book"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type Books struct {
 	gorm.Model
-	Judul       string
+	Judul       string `gorm:"index:idx_books_search,class:FULLTEXT"`
 	TahunTerbit int
-	Penulis     string
-	UserID      uint
+	Penulis     string `gorm:"index:idx_books_search,class:FULLTEXT"`
+	// ISBN NULL jika tidak diisi atau bukunya sudah dihapus, satu user tidak boleh punya dua buku aktif dengan ISBN sama
+	ISBN     *string `gorm:"column:isbn;size:13;index;uniqueIndex:idx_books_owner_isbn,priority:2"`
+	Penerbit string
+	Halaman  int
+	CoverURL string
+	UserID   uint `gorm:"uniqueIndex:idx_books_owner_isbn,priority:1"`
 }
 
 type BookPemilik struct {
@@ -19,6 +25,13 @@ type BookPemilik struct {
 	Judul       string
 	TahunTerbit int
 	Penulis     string
+	ISBN        *string `gorm:"column:isbn"`
+	Penerbit    string
+	Halaman     int
+	CoverURL    string
+	UserID      uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 	Name        string
 }
 
@@ -28,6 +41,13 @@ func ToCore(data Books) book.Core {
 		Judul:       data.Judul,
 		TahunTerbit: data.TahunTerbit,
 		Penulis:     data.Penulis,
+		ISBN:        deref(data.ISBN),
+		Penerbit:    data.Penerbit,
+		Halaman:     data.Halaman,
+		CoverURL:    data.CoverURL,
+		OwnerID:     data.UserID,
+		CreatedAt:   data.CreatedAt,
+		UpdatedAt:   data.UpdatedAt,
 	}
 }
 
@@ -37,7 +57,14 @@ func (dataModel *BookPemilik) ModelsToCore() book.Core { //fungsi yang mengambil
 		Judul:       dataModel.Judul,
 		Penulis:     dataModel.Penulis,
 		TahunTerbit: dataModel.TahunTerbit,
+		ISBN:        deref(dataModel.ISBN),
+		Penerbit:    dataModel.Penerbit,
+		Halaman:     dataModel.Halaman,
+		CoverURL:    dataModel.CoverURL,
 		Pemilik:     dataModel.Name,
+		OwnerID:     dataModel.UserID,
+		CreatedAt:   dataModel.CreatedAt,
+		UpdatedAt:   dataModel.UpdatedAt,
 	}
 }
 
@@ -55,5 +82,24 @@ func CoreToData(data book.Core) Books {
 		Judul:       data.Judul,
 		Penulis:     data.Penulis,
 		TahunTerbit: data.TahunTerbit,
+		ISBN:        isbnPtr(data.ISBN),
+		Penerbit:    data.Penerbit,
+		Halaman:     data.Halaman,
+		CoverURL:    data.CoverURL,
+	}
+}
+
+// isbnPtr ISBN kosong disimpan sebagai NULL supaya tidak bentrok di unique index.
+func isbnPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
 	}
+	return *s
 }
@@ -1,14 +1,26 @@
 package data
 
 import (
+	"api/dbutil"
 	"api/features/book"
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
+// errISBNDuplicated dikembalikan Add/Update jika user sudah punya buku aktif dengan ISBN yang sama.
+var errISBNDuplicated = errors.New("isbn sudah dipakai buku lain milik user ini")
+
+func isDuplicate(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1062
+}
+
 type bookData struct {
 	db *gorm.DB
 }
@@ -21,11 +33,26 @@ func New(db *gorm.DB) book.BookData {
 	}
 }
 
+// bookColumns kolom BookPemilik untuk query buku yang di-join dengan users.
+const bookColumns = "books.id, books.judul, books.tahun_terbit, books.penulis, books.isbn, books.penerbit, books.halaman, books.cover_url, " +
+	"books.user_id, books.created_at, books.updated_at, users.name"
+
+// ownerScope membatasi query ke buku milik userID, kecuali book.AnyOwner (admin).
+func (bd *bookData) ownerScope(userID int) *gorm.DB {
+	if userID == book.AnyOwner {
+		return bd.db
+	}
+	return bd.db.Where("user_id = ?", userID)
+}
+
 func (bd *bookData) Add(userID int, newBook book.Core) (book.Core, error) {
 	cnv := CoreToData(newBook)
 	cnv.UserID = uint(userID)
 	err := bd.db.Create(&cnv).Error
 	if err != nil {
+		if isDuplicate(err) {
+			return book.Core{}, errISBNDuplicated
+		}
 		return book.Core{}, err
 	}
 
@@ -33,12 +60,15 @@ func (bd *bookData) Add(userID int, newBook book.Core) (book.Core, error) {
 
 	return newBook, nil
 }
-func (bd *bookData) Update(userID int, bookID int, updatedData book.Core) (book.Core, error) {
+func (bd *bookData) Update(userID int, bookID int, updatedData book.Core, fields []string) (book.Core, error) {
 	cnv := CoreToData(updatedData)
 
-	// DB Update(value)
-	tx := bd.db.Where("id = ? && user_id = ?", bookID, userID).Updates(&cnv)
+	// DB Update(value), hanya kolom di fields
+	tx := bd.ownerScope(userID).Model(&Books{}).Where("id = ?", bookID).Select(append([]string{"UpdatedAt"}, fields...)).Updates(&cnv)
 	if tx.Error != nil {
+		if isDuplicate(tx.Error) {
+			return book.Core{}, errISBNDuplicated
+		}
 		log.Println("update book query error :", tx.Error)
 		return book.Core{}, tx.Error
 	}
@@ -53,12 +83,23 @@ func (bd *bookData) Update(userID int, bookID int, updatedData book.Core) (book.
 	return ToCore(cnv), nil
 }
 
+// Get buku milik userID, atau buku siapa pun untuk book.AnyOwner.
+func (bd *bookData) Get(userID int, bookID int) (book.Core, error) {
+	res := Books{}
+	if err := bd.ownerScope(userID).Where("id = ?", bookID).First(&res).Error; err != nil {
+		log.Println("get book query error :", err)
+		return book.Core{}, errors.New("not found")
+	}
+
+	return ToCore(res), nil
+}
+
 //	func (bd *bookData) Delete(bookID int, userID int) error {
 //		return nil
 //	}
 func (bd *bookData) MyBook(userID int) ([]book.Core, error) {
 	var myBooks []BookPemilik
-	err := bd.db.Raw("SELECT books.id, books.judul, books.tahun_terbit, books.penulis, users.name FROM books JOIN users ON users.id = books.user_id WHERE books.user_id = ?", userID).Find(&myBooks).Error
+	err := bd.db.Raw("SELECT "+bookColumns+" FROM books JOIN users ON users.id = books.user_id WHERE books.user_id = ? AND books.deleted_at IS NULL", userID).Find(&myBooks).Error
 	if err != nil {
 		return nil, err
 	}
@@ -68,23 +109,140 @@ func (bd *bookData) MyBook(userID int) ([]book.Core, error) {
 	return dataCore, nil
 }
 
-// All implements book.BookData
-func (bd *bookData) AllBook() ([]book.Core, error) {
+func (bd *bookData) CountByOwner(userID int) (int64, error) {
+	var total int64
+	if err := bd.db.Model(&Books{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// sortColumns kolom database untuk setiap book.SortFields.
+var sortColumns = map[string]string{
+	book.SortJudul:       "books.judul",
+	book.SortTahunTerbit: "books.tahun_terbit",
+	book.SortPenulis:     "books.penulis",
+	book.SortCreatedAt:   "books.created_at",
+}
+
+// AllBook daftar buku per halaman beserta total buku yang cocok dengan filter.
+// Dengan Cursor, halaman dimulai setelah posisi cursor dan Page diabaikan.
+func (bd *bookData) AllBook(filter book.BookFilter) ([]book.Core, int64, error) {
+	qry := bd.db.Table("books").Joins("JOIN users ON users.id = books.user_id").
+		Where("books.deleted_at IS NULL AND users.deleted_at IS NULL")
+	if filter.OwnerID > 0 {
+		qry = qry.Where("books.user_id = ?", filter.OwnerID)
+	}
+	if filter.Penulis != "" {
+		qry = qry.Where("books.penulis LIKE ?", "%"+dbutil.EscapeLike(filter.Penulis)+"%")
+	}
+	if filter.YearFrom > 0 {
+		qry = qry.Where("books.tahun_terbit >= ?", filter.YearFrom)
+	}
+	if filter.YearTo > 0 {
+		qry = qry.Where("books.tahun_terbit <= ?", filter.YearTo)
+	}
+	qry = qry.Session(&gorm.Session{})
+
+	var total int64
+	if err := qry.Count(&total).Error; err != nil {
+		log.Println("count books query error :", err)
+		return nil, 0, err
+	}
+
+	col, ok := sortColumns[filter.Sort]
+	if !ok {
+		col = sortColumns[book.SortCreatedAt]
+	}
+	dir, cmp := "ASC", ">"
+	if filter.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	page := qry.Select(bookColumns).
+		Order(col + " " + dir).Order("books.id " + dir).Limit(filter.Limit)
+	if filter.Cursor != "" {
+		cur, err := book.ParseCursor(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		var val interface{} = cur.Value
+		switch filter.Sort {
+		case book.SortTahunTerbit:
+			val, err = strconv.Atoi(cur.Value)
+		case book.SortCreatedAt:
+			val, err = time.Parse(time.RFC3339Nano, cur.Value)
+		}
+		if err != nil {
+			return nil, 0, errors.New("format cursor salah")
+		}
+		page = page.Where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND books.id %[2]s ?))", col, cmp), val, val, cur.ID)
+	} else {
+		page = page.Offset((filter.Page - 1) * filter.Limit)
+	}
+
+	var buku []BookPemilik
+	if err := page.Find(&buku).Error; err != nil {
+		log.Println("list books query error :", err)
+		return nil, 0, err
+	}
+
+	return ListModelTOCore(buku), total, nil
+}
+
+func (bd *bookData) GetByIDs(ids []uint) ([]book.Core, error) {
+	if len(ids) == 0 {
+		return []book.Core{}, nil
+	}
+
 	var buku []BookPemilik
-	fmt.Println("ini query", buku)
-	tx := bd.db.Raw("SELECT books.id, books.judul, books.tahun_terbit, books.penulis, users.name FROM books JOIN users ON users.id = books.user_id WHERE books.deleted_at IS NULL").Find(&buku)
+	err := bd.db.Table("books").Select(bookColumns).
+		Joins("JOIN users ON users.id = books.user_id").
+		Where("books.id IN ? AND books.deleted_at IS NULL AND users.deleted_at IS NULL", ids).Find(&buku).Error
+	if err != nil {
+		log.Println("get books by ids query error :", err)
+		return nil, err
+	}
 
-	fmt.Println("ini TX", tx)
+	return ListModelTOCore(buku), nil
+}
+
+func (bd *bookData) Detail(bookID int) (book.Core, error) {
+	res := BookPemilik{}
+	tx := bd.db.Table("books").Select(bookColumns).
+		Joins("JOIN users ON users.id = books.user_id").
+		Where("books.id = ? AND books.deleted_at IS NULL AND users.deleted_at IS NULL", bookID).Limit(1).Find(&res)
 	if tx.Error != nil {
-		return nil, tx.Error
+		log.Println("detail book query error :", tx.Error)
+		return book.Core{}, tx.Error
+	}
+	if tx.RowsAffected <= 0 {
+		return book.Core{}, errors.New("book not found")
 	}
-	var dataCore = ListModelTOCore(buku)
 
-	return dataCore, nil
+	return res.ModelsToCore(), nil
 }
+
+func (bd *bookData) GetByISBN(isbn string) ([]book.Core, error) {
+	var buku []BookPemilik
+	err := bd.db.Table("books").Select(bookColumns).
+		Joins("JOIN users ON users.id = books.user_id").
+		Where("books.isbn = ? AND books.deleted_at IS NULL AND users.deleted_at IS NULL", isbn).
+		Order("books.created_at, books.id").Find(&buku).Error
+	if err != nil {
+		log.Println("get books by isbn query error :", err)
+		return nil, err
+	}
+
+	return ListModelTOCore(buku), nil
+}
+
+// Delete soft delete buku. ISBN dikosongkan supaya edisi yang sama bisa ditambahkan lagi
+// tanpa bentrok dengan unique index (user_id, isbn).
 func (bd *bookData) Delete(userID int, bookID int) error {
-	buku := Books{}
-	del := bd.db.Where("id = ? AND user_id = ?", bookID, userID).Delete(&buku, bookID)
+	del := bd.ownerScope(userID).Model(&Books{}).Where("id = ?", bookID).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "isbn": nil})
 	if del.Error != nil {
 		log.Println("delete book query error :", del.Error)
 		return del.Error
@@ -1,35 +1,196 @@
 package book
 
-import "github.com/labstack/echo/v4"
+import (
+	"api/auth"
+	"api/search"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
 
 type Core struct {
 	ID          uint
 	Judul       string `validate:"required"`
 	TahunTerbit int    `validate:"required"`
 	Penulis     string `validate:"required"`
+	ISBN        string // ISBN-13 tanpa pemisah, kosong jika tidak diisi
+	Penerbit    string
+	Halaman     int    `validate:"gte=0"`
+	CoverURL    string `validate:"omitempty,url,startswith=http"`
 	Pemilik     string
+	OwnerID     uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// OwnerCore profil publik user, dipakai sebagai pemilik pada detail buku dan sebagai profile.Core.
+// Didefinisikan di sini karena package profile bergantung pada book. Alamat dan HP mengikuti
+// pengaturan privasi pemilik.
+type OwnerCore struct {
+	ID        uint
+	Name      string
+	JoinedAt  time.Time
+	BookCount int
+	Alamat    string
+	HP        string
+	HasAvatar bool
+}
+
+// DetailCore satu buku beserta pemiliknya. Available false jika akun pemilik disuspend,
+// Owner nil karena profilnya tidak lagi publik.
+type DetailCore struct {
+	Core
+	Owner     *OwnerCore
+	Available bool
+}
+
+// Kolom yang bisa dipakai mengurutkan daftar buku.
+const (
+	SortJudul       = "judul"
+	SortTahunTerbit = "tahun_terbit"
+	SortPenulis     = "penulis"
+	SortCreatedAt   = "created_at"
+)
+
+var SortFields = []string{SortJudul, SortTahunTerbit, SortPenulis, SortCreatedAt}
+
+// BookFilter query daftar buku. Cursor dipakai sebagai pengganti Page untuk keyset pagination,
+// OwnerID 0 berarti buku semua user.
+type BookFilter struct {
+	OwnerID  uint
+	Penulis  string
+	YearFrom int
+	YearTo   int
+	Sort     string
+	Desc     bool
+	Cursor   string
+	Page     int
+	Limit    int
+}
+
+// Normalize mengisi page/limit/sort default dan membatasi limit maksimal 100.
+func (f *BookFilter) Normalize() {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	} else if f.Limit > 100 {
+		f.Limit = 100
+	}
+	if f.Sort == "" {
+		f.Sort = SortCreatedAt
+	}
+}
+
+// PageCore metadata halaman daftar buku, NextCursor kosong jika sudah halaman terakhir.
+type PageCore struct {
+	Page       int
+	Limit      int
+	Total      int64
+	NextCursor string
 }
 
+// CursorCore posisi terakhir pada urutan Sort/Desc, Value berisi nilai kolom sort dalam bentuk string.
+type CursorCore struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// NewCursor membuat cursor dari buku terakhir di halaman.
+func NewCursor(filter BookFilter, last Core) string {
+	cur := CursorCore{Sort: filter.Sort, Desc: filter.Desc, ID: last.ID}
+	switch filter.Sort {
+	case SortJudul:
+		cur.Value = last.Judul
+	case SortPenulis:
+		cur.Value = last.Penulis
+	case SortTahunTerbit:
+		cur.Value = strconv.Itoa(last.TahunTerbit)
+	default:
+		cur.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// ParseCursor membaca cursor dari NewCursor, cursor harus dibuat dengan urutan yang sama.
+func ParseCursor(filter BookFilter) (CursorCore, error) {
+	cur := CursorCore{}
+	raw, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+	if err != nil || json.Unmarshal(raw, &cur) != nil || cur.ID == 0 {
+		return CursorCore{}, errors.New("format cursor salah")
+	}
+	if cur.Sort != filter.Sort || cur.Desc != filter.Desc {
+		return CursorCore{}, errors.New("format cursor salah, urutan tidak sama dengan halaman sebelumnya")
+	}
+	return cur, nil
+}
+
+// SearchFields field buku yang diindeks untuk pencarian, judul lebih berbobot dari penulis.
+var SearchFields = []search.Field{{Name: "judul", Weight: 2}, {Name: "penulis", Weight: 1}}
+
+// ToDocument dokumen pencarian untuk satu buku.
+func ToDocument(data Core) search.Document {
+	return search.Document{ID: data.ID, Fields: map[string]string{"judul": data.Judul, "penulis": data.Penulis}}
+}
+
+// SearchCore hasil pencarian buku, Highlights per field dari search.Hit.
+type SearchCore struct {
+	Core
+	Score      float64
+	Highlights map[string]string
+}
+
+// AnyOwner dipakai sebagai userID pada BookData.Update/Delete untuk admin,
+// sehingga query tidak difilter berdasarkan pemilik buku.
+const AnyOwner = 0
+
 type BookHandler interface {
 	Add() echo.HandlerFunc
 	Update() echo.HandlerFunc
+	Patch() echo.HandlerFunc
 	AllBook() echo.HandlerFunc
 	Delete() echo.HandlerFunc
 	MyBook() echo.HandlerFunc
+	Search() echo.HandlerFunc
+	Detail() echo.HandlerFunc
+	ByISBN() echo.HandlerFunc
 }
 
 type BookService interface {
-	Add(token interface{}, newBook Core) (Core, error)
-	Update(token interface{}, bookID int, updatedData Core) (Core, error)
-	AllBook() ([]Core, error)
-	Delete(token interface{}, bookID int) error
-	MyBook(token interface{}) ([]Core, error)
+	Add(p auth.Principal, newBook Core) (Core, error)
+	Update(p auth.Principal, bookID int, updatedData Core) (Core, error)
+	Patch(p auth.Principal, bookID int, patch []byte) (Core, error)
+	AllBook(filter BookFilter) ([]Core, PageCore, error)
+	Delete(p auth.Principal, bookID int) error
+	MyBook(p auth.Principal, filter BookFilter) ([]Core, PageCore, error)
+	Search(query string, page, limit int) ([]SearchCore, PageCore, error)
+	Detail(bookID int) (DetailCore, error)
+	// ByISBN semua eksemplar satu edisi dari semua user, isbn boleh ISBN-10 atau ISBN-13.
+	ByISBN(isbn string) ([]Core, error)
 }
 
 type BookData interface {
 	Add(userID int, newBook Core) (Core, error)
-	Update(userID int, bookID int, updatedData Core) (Core, error)
-	AllBook() ([]Core, error)
+	// Update hanya menulis field Core yang disebut di fields, nilai kosong ikut ditulis.
+	Update(userID int, bookID int, updatedData Core, fields []string) (Core, error)
+	Get(userID int, bookID int) (Core, error)
+	AllBook(filter BookFilter) ([]Core, int64, error)
 	Delete(userID int, bookID int) error
 	MyBook(userID int) ([]Core, error)
+	// CountByOwner jumlah buku user yang belum dihapus, tanpa memuat datanya.
+	CountByOwner(userID int) (int64, error)
+	// GetByIDs buku yang belum dihapus dan pemiliknya masih aktif, urutan tidak dijamin.
+	GetByIDs(ids []uint) ([]Core, error)
+	// Detail buku yang belum dihapus dan pemiliknya belum menghapus akun.
+	Detail(bookID int) (Core, error)
+	// GetByISBN buku dengan ISBN-13 yang sama dari pemilik yang masih aktif.
+	GetByISBN(isbn string) ([]Core, error)
 }
@@ -3,7 +3,7 @@ package handler
 import (
 	"api/features/book"
 	"api/helper"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -23,6 +23,25 @@ func New(bs book.BookService) book.BookHandler {
 	}
 }
 
+// paramID id buku dari path, selain angka positif dianggap format salah.
+func paramID(c echo.Context) (int, error) {
+	bookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || bookID <= 0 {
+		return 0, errors.New("format id buku salah")
+	}
+	return bookID, nil
+}
+
+// printError seperti helper.PrintErrorResponse, tapi ValidationError dikirim lengkap dengan detail per field.
+func printError(err error) (int, interface{}) {
+	var ve *helper.ValidationError
+	if errors.As(err, &ve) {
+		return helper.PrintValidationResponse(ve)
+	}
+
+	return helper.PrintErrorResponse(err.Error())
+}
+
 func (bh *bookHandle) Add() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		input := AddUpdateBookRequest{}
@@ -32,10 +51,10 @@ func (bh *bookHandle) Add() echo.HandlerFunc {
 
 		cnv := ToCore(input)
 
-		res, err := bh.srv.Add(c.Get("user"), *cnv)
+		res, err := bh.srv.Add(helper.Principal(c), *cnv)
 		if err != nil {
 			log.Println("trouble :  ", err.Error())
-			return c.JSON(helper.PrintErrorResponse(err.Error()))
+			return c.JSON(printError(err))
 		}
 
 		book := ToResponse("add", res)
@@ -48,18 +67,18 @@ func (bh *bookHandle) Update() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		input := AddUpdateBookRequest{}
 		if err := c.Bind(&input); err != nil {
-			return c.JSON(helper.PrintErrorResponse(err.Error()))
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
 		}
 		cnv := ToCore(input)
 
-		bookID, err := strconv.Atoi(c.Param("id"))
+		bookID, err := paramID(c)
 		if err != nil {
 			return c.JSON(helper.PrintErrorResponse(err.Error()))
 		}
 
-		res, err := bh.srv.Update(c.Get("user"), bookID, *cnv)
+		res, err := bh.srv.Update(helper.Principal(c), bookID, *cnv)
 		if err != nil {
-			return c.JSON(helper.PrintErrorResponse(err.Error()))
+			return c.JSON(printError(err))
 		}
 
 		book := ToResponse("update", res)
@@ -68,33 +87,108 @@ func (bh *bookHandle) Update() echo.HandlerFunc {
 	}
 }
 
-func (bh *bookHandle) MyBook() echo.HandlerFunc {
+func (bh *bookHandle) Patch() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		bookID, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		patch, err := helper.ReadMergePatch(c.Request())
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
 
-		res, _ := bh.srv.MyBook(c.Get("user"))
+		res, err := bh.srv.Patch(helper.Principal(c), bookID, patch)
+		if err != nil {
+			return c.JSON(printError(err))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses mengubah buku", ToResponse("", res)))
+	}
+}
+
+func (bh *bookHandle) MyBook() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter, err := ToFilter(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
 
-		listRes := ListBookCoreToBooksRespon(res)
+		res, page, err := bh.srv.MyBook(helper.Principal(c), filter)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
 
-		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan user buku", listRes))
+		return c.JSON(helper.PrintPagingResponse(http.StatusOK, "sukses menampilkan user buku", ListBookCoreToBooksRespon(res), ToPagination(page)))
 	}
 }
 func (bh *bookHandle) AllBook() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		result, _ := bh.srv.AllBook()
+		filter, err := ToFilter(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
 
-		listRes := ListBookCoreToBooksRespon(result)
-		fmt.Println("ini handler", listRes)
-		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan  buku", listRes))
+		res, page, err := bh.srv.AllBook(filter)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintPagingResponse(http.StatusOK, "sukses menampilkan  buku", ListBookCoreToBooksRespon(res), ToPagination(page)))
 	}
+}
+
+func (bh *bookHandle) Search() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		page, _ := strconv.Atoi(c.QueryParam("page"))
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+		res, paging, err := bh.srv.Search(c.QueryParam("q"), page, limit)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
 
+		return c.JSON(helper.PrintPagingResponse(http.StatusOK, "sukses mencari buku", ToSearchResponse(res), ToPagination(paging)))
+	}
+}
+
+func (bh *bookHandle) Detail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		bookID, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, err := bh.srv.Detail(bookID)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan detail buku", ToDetailResponse(res)))
+	}
+}
+
+func (bh *bookHandle) ByISBN() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res, err := bh.srv.ByISBN(c.Param("isbn"))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan buku dengan isbn", ListBookCoreToBooksRespon(res)))
+	}
 }
 
 // Delete implements book.BookHandler
 func (bh *bookHandle) Delete() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		bookID, _ := strconv.Atoi(c.Param("id"))
+		bookID, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
 
-		del := bh.srv.Delete(c.Get("user"), bookID)
+		del := bh.srv.Delete(helper.Principal(c), bookID)
 		if del != nil {
 			return c.JSON(helper.PrintErrorResponse(del.Error()))
 		}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"api/auth"
+	"api/features/book"
+	"api/features/book/services"
+	"api/helper"
+	"api/metadata"
+	"api/mocks"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// request menjalankan handler dengan principal user 1 dan mengembalikan recorder-nya.
+func request(h echo.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/books/1", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	h(c)
+	return rec
+}
+
+func TestUpdate(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	hdl := New(services.New(repo, nil, nil, nil))
+
+	t.Run("input tidak valid dibalas 400 dengan detail field", func(t *testing.T) {
+		rec := request(hdl.Update(), http.MethodPut, `{"judul":"Naruto","tahun_terbit":1999}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		resp := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Contains(t, resp["errors"], "penulis")
+	})
+
+	t.Run("body bukan json dibalas 400", func(t *testing.T) {
+		rec := request(hdl.Update(), http.MethodPut, `{"judul":`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+// failProvider metadata provider yang selalu gagal.
+type failProvider struct{}
+
+func (failProvider) Lookup(isbn string) (metadata.Book, error) {
+	return metadata.Book{}, metadata.ErrUnavailable
+}
+
+func TestAdd(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	hdl := New(services.New(repo, nil, nil, failProvider{}))
+
+	t.Run("hanya isbn dan provider gagal dibalas 400 dengan detail field", func(t *testing.T) {
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{}, nil).Once()
+
+		rec := request(hdl.Add(), http.MethodPost, `{"isbn":"9780306406157"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		resp := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Contains(t, resp["errors"], "judul")
+		assert.Contains(t, resp["errors"], "penulis")
+		repo.AssertExpectations(t)
+	})
+}
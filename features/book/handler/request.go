@@ -1,11 +1,21 @@
 package handler
 
-import "api/features/book"
+import (
+	"api/features/book"
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
 
 type AddUpdateBookRequest struct {
 	Judul       string `json:"judul" form:"judul"`
 	TahunTerbit int    `json:"tahun_terbit" form:"tahun"`
 	Penulis     string `json:"penulis" form:"penulis"`
+	ISBN        string `json:"isbn" form:"isbn"`
+	Penerbit    string `json:"penerbit" form:"penerbit"`
+	Halaman     int    `json:"halaman" form:"halaman"`
+	CoverURL    string `json:"cover_url" form:"cover_url"`
 }
 
 func ToCore(data interface{}) *book.Core {
@@ -17,9 +27,60 @@ func ToCore(data interface{}) *book.Core {
 		res.Judul = cnv.Judul
 		res.TahunTerbit = cnv.TahunTerbit
 		res.Penulis = cnv.Penulis
+		res.ISBN = cnv.ISBN
+		res.Penerbit = cnv.Penerbit
+		res.Halaman = cnv.Halaman
+		res.CoverURL = cnv.CoverURL
 	default:
 		return nil
 	}
 
 	return &res
 }
+
+// ToFilter membaca query daftar buku: page, limit, cursor, sort, order (asc/desc),
+// penulis, tahun_min, tahun_max dan user_id (pemilik).
+func ToFilter(c echo.Context) (book.BookFilter, error) {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	filter := book.BookFilter{
+		Penulis: c.QueryParam("penulis"),
+		Sort:    c.QueryParam("sort"),
+		Cursor:  c.QueryParam("cursor"),
+		Page:    page,
+		Limit:   limit,
+	}
+	switch c.QueryParam("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return book.BookFilter{}, errors.New("format order salah")
+	}
+
+	var err error
+	if filter.YearFrom, err = queryInt(c, "tahun_min"); err != nil {
+		return book.BookFilter{}, errors.New("format tahun_min salah")
+	}
+	if filter.YearTo, err = queryInt(c, "tahun_max"); err != nil {
+		return book.BookFilter{}, errors.New("format tahun_max salah")
+	}
+	owner, err := queryInt(c, "user_id")
+	if err != nil || owner < 0 {
+		return book.BookFilter{}, errors.New("format id user salah")
+	}
+	filter.OwnerID = uint(owner)
+	filter.Normalize()
+
+	return filter, nil
+}
+
+// queryInt membaca query param angka, param kosong dianggap 0.
+func queryInt(c echo.Context, name string) (int, error) {
+	val := c.QueryParam(name)
+	if val == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(val)
+}
@@ -1,6 +1,11 @@
 package handler
 
-import "api/features/book"
+import (
+	"api/features/book"
+	"api/helper"
+	"api/isbn"
+	"time"
+)
 
 type BookResponse struct {
 	ID          uint   `json:"id"`
@@ -8,16 +13,31 @@ type BookResponse struct {
 	TahunTerbit int    `json:"tahun_terbit"`
 	Penulis     string `json:"penulis"`
 	Pemilik     string `json:"pemilik"`
+	ISBN        string `json:"isbn,omitempty"`
+	ISBN10      string `json:"isbn_10,omitempty"`
+	Penerbit    string `json:"penerbit,omitempty"`
+	Halaman     int    `json:"halaman,omitempty"`
+	CoverURL    string `json:"cover_url,omitempty"`
 }
 type AddBookResponse struct {
 	Judul       string `json:"judul"`
 	TahunTerbit int    `json:"tahun_terbit"`
 	Penulis     string `json:"penulis"`
+	ISBN        string `json:"isbn,omitempty"`
+	ISBN10      string `json:"isbn_10,omitempty"`
+	Penerbit    string `json:"penerbit,omitempty"`
+	Halaman     int    `json:"halaman,omitempty"`
+	CoverURL    string `json:"cover_url,omitempty"`
 }
 type updateBookResponse struct {
 	Judul       string `json:"judul"`
 	TahunTerbit int    `json:"tahun_terbit"`
 	Penulis     string `json:"penulis"`
+	ISBN        string `json:"isbn,omitempty"`
+	ISBN10      string `json:"isbn_10,omitempty"`
+	Penerbit    string `json:"penerbit,omitempty"`
+	Halaman     int    `json:"halaman,omitempty"`
+	CoverURL    string `json:"cover_url,omitempty"`
 }
 
 func ToResponse(feature string, book book.Core) interface{} {
@@ -27,12 +47,22 @@ func ToResponse(feature string, book book.Core) interface{} {
 			Judul:       book.Judul,
 			TahunTerbit: book.TahunTerbit,
 			Penulis:     book.Penulis,
+			ISBN:        book.ISBN,
+			ISBN10:      isbn10(book.ISBN),
+			Penerbit:    book.Penerbit,
+			Halaman:     book.Halaman,
+			CoverURL:    book.CoverURL,
 		}
 	case "update":
 		return updateBookResponse{
 			Judul:       book.Judul,
 			TahunTerbit: book.TahunTerbit,
 			Penulis:     book.Penulis,
+			ISBN:        book.ISBN,
+			ISBN10:      isbn10(book.ISBN),
+			Penerbit:    book.Penerbit,
+			Halaman:     book.Halaman,
+			CoverURL:    book.CoverURL,
 		}
 	default:
 		return BookResponse{
@@ -41,10 +71,21 @@ func ToResponse(feature string, book book.Core) interface{} {
 			TahunTerbit: book.TahunTerbit,
 			Penulis:     book.Penulis,
 			Pemilik:     book.Pemilik,
+			ISBN:        book.ISBN,
+			ISBN10:      isbn10(book.ISBN),
+			Penerbit:    book.Penerbit,
+			Halaman:     book.Halaman,
+			CoverURL:    book.CoverURL,
 		}
 	}
 }
 
+// isbn10 bentuk ISBN-10 untuk ditampilkan, kosong jika tidak ada (ISBN kosong atau awalan 979).
+func isbn10(val string) string {
+	res, _ := isbn.To10(val)
+	return res
+}
+
 func ListBookCoreToBookRespon(dataCore book.Core) BookResponse { // data user core yang ada di controller yang memanggil user repository
 	return BookResponse{
 		ID:          dataCore.ID,
@@ -52,13 +93,97 @@ func ListBookCoreToBookRespon(dataCore book.Core) BookResponse { // data user co
 		TahunTerbit: dataCore.TahunTerbit,
 		Penulis:     dataCore.Penulis,
 		Pemilik:     dataCore.Pemilik,
+		ISBN:        dataCore.ISBN,
+		ISBN10:      isbn10(dataCore.ISBN),
+		Penerbit:    dataCore.Penerbit,
+		Halaman:     dataCore.Halaman,
+		CoverURL:    dataCore.CoverURL,
 	}
 }
 func ListBookCoreToBooksRespon(dataCore []book.Core) []BookResponse {
-	var ResponData []BookResponse
+	ResponData := []BookResponse{}
 
 	for _, value := range dataCore {
 		ResponData = append(ResponData, ListBookCoreToBookRespon(value))
 	}
 	return ResponData
 }
+
+func ToPagination(page book.PageCore) helper.Pagination {
+	return helper.Pagination{Page: page.Page, Limit: page.Limit, Total: page.Total, NextCursor: page.NextCursor}
+}
+
+type SearchResponse struct {
+	BookResponse
+	Score     float64           `json:"score"`
+	Highlight map[string]string `json:"highlight"`
+}
+
+func ToSearchResponse(data []book.SearchCore) []SearchResponse {
+	res := []SearchResponse{}
+	for _, val := range data {
+		res = append(res, SearchResponse{
+			BookResponse: ListBookCoreToBookRespon(val.Core),
+			Score:        val.Score,
+			Highlight:    val.Highlights,
+		})
+	}
+	return res
+}
+
+type OwnerResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"nama"`
+	JoinedAt  time.Time `json:"joined_at"`
+	BookCount int       `json:"book_count"`
+	Alamat    string    `json:"alamat,omitempty"`
+	HP        string    `json:"hp,omitempty"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+}
+
+type DetailResponse struct {
+	ID          uint           `json:"id"`
+	Judul       string         `json:"judul"`
+	TahunTerbit int            `json:"tahun_terbit"`
+	Penulis     string         `json:"penulis"`
+	ISBN        string         `json:"isbn,omitempty"`
+	ISBN10      string         `json:"isbn_10,omitempty"`
+	Penerbit    string         `json:"penerbit,omitempty"`
+	Halaman     int            `json:"halaman,omitempty"`
+	CoverURL    string         `json:"cover_url,omitempty"`
+	Pemilik     *OwnerResponse `json:"pemilik"`
+	Tersedia    bool           `json:"tersedia"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func ToDetailResponse(data book.DetailCore) DetailResponse {
+	res := DetailResponse{
+		ID:          data.ID,
+		Judul:       data.Judul,
+		TahunTerbit: data.TahunTerbit,
+		Penulis:     data.Penulis,
+		ISBN:        data.ISBN,
+		ISBN10:      isbn10(data.ISBN),
+		Penerbit:    data.Penerbit,
+		Halaman:     data.Halaman,
+		CoverURL:    data.CoverURL,
+		Tersedia:    data.Available,
+		CreatedAt:   data.CreatedAt,
+		UpdatedAt:   data.UpdatedAt,
+	}
+	if data.Owner != nil {
+		res.Pemilik = &OwnerResponse{
+			ID:        data.Owner.ID,
+			Name:      data.Owner.Name,
+			JoinedAt:  data.Owner.JoinedAt,
+			BookCount: data.Owner.BookCount,
+			Alamat:    data.Owner.Alamat,
+			HP:        data.Owner.HP,
+		}
+		if data.Owner.HasAvatar {
+			res.Pemilik.AvatarURL = helper.AvatarURL(data.Owner.ID)
+		}
+	}
+	return res
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"api/auth"
 	"api/features/book"
+	"api/features/profile"
 	"api/helper"
+	"api/isbn"
+	"api/metadata"
+	"api/search"
 	"errors"
-	"fmt"
 	"log"
 	"strings"
 
@@ -13,6 +17,9 @@ import (
 
 type bookSrv struct {
 	data     book.BookData
+	srch     search.Searcher
+	profiles profile.ProfileService
+	meta     metadata.Provider
 	validasi *validator.Validate
 }
 
@@ -20,30 +27,123 @@ type bookSrv struct {
 
 // Update implements book.BookService
 
-func New(d book.BookData) book.BookService {
+func New(d book.BookData, s search.Searcher, profiles profile.ProfileService, meta metadata.Provider) book.BookService {
 	return &bookSrv{
 		data:     d,
+		srch:     s,
+		profiles: profiles,
+		meta:     meta,
 		validasi: validator.New(),
 	}
 }
 
-func (bs *bookSrv) Add(token interface{}, newBook book.Core) (book.Core, error) {
-	userID := helper.ExtractToken(token)
-	if userID <= 0 {
-		return book.Core{}, errors.New("user not found")
+// index menyamakan index pencarian dengan buku yang baru ditulis, gagal index hanya dicatat di log.
+func (bs *bookSrv) index(data book.Core) {
+	if bs.srch == nil {
+		return
+	}
+	if err := bs.srch.Index(book.ToDocument(data)); err != nil {
+		log.Println("index book error", err.Error())
+	}
+}
+
+// ownerFilter: admin boleh mengubah dan menghapus buku siapa pun. API key milik admin tetap
+// dibatasi ke buku pemiliknya, scope books:write tidak memberi akses ke buku user lain.
+func ownerFilter(p auth.Principal, userID int) int {
+	if p.HasRole(helper.RoleAdmin) && !p.IsAPIKey() {
+		return book.AnyOwner
+	}
+	return userID
+}
+
+// checkISBN menormalkan ISBN data ke ISBN-13 dan menolak ISBN yang sudah dipakai buku lain
+// milik ownerID. ISBN kosong tidak dicek karena tidak wajib.
+func (bs *bookSrv) checkISBN(ownerID uint, bookID uint, data *book.Core) error {
+	if strings.TrimSpace(data.ISBN) == "" {
+		data.ISBN = ""
+		return nil
+	}
+	norm, err := isbn.Normalize(data.ISBN)
+	if err != nil {
+		return err
 	}
+	data.ISBN = norm
 
-	err := bs.validasi.Struct(newBook)
+	copies, err := bs.data.GetByISBN(norm)
 	if err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
-			log.Println(err)
+		return errors.New("terdapat masalah pada server")
+	}
+	for _, val := range copies {
+		if val.OwnerID == ownerID && val.ID != bookID {
+			return errors.New("isbn sudah dipakai buku lain milik user ini")
 		}
-		return book.Core{}, errors.New("validation error")
+	}
+	return nil
+}
+
+// enrich mengisi field buku yang kosong dari metadata ISBN. Provider yang gagal atau
+// tidak mengenal ISBN tidak menggagalkan penambahan buku, field tetap seperti input user.
+func (bs *bookSrv) enrich(data *book.Core) {
+	if bs.meta == nil || data.ISBN == "" {
+		return
+	}
+	if data.Judul != "" && data.Penulis != "" && data.TahunTerbit != 0 &&
+		data.Penerbit != "" && data.Halaman != 0 && data.CoverURL != "" {
+		return
+	}
+
+	meta, err := bs.meta.Lookup(data.ISBN)
+	if err != nil {
+		// kegagalan provider sudah dicatat Breaker
+		if !errors.Is(err, metadata.ErrNotFound) && !errors.Is(err, metadata.ErrUnavailable) {
+			log.Println("metadata lookup error", err.Error())
+		}
+		return
+	}
+	if data.Judul == "" {
+		data.Judul = meta.Title
+	}
+	if data.Penulis == "" {
+		data.Penulis = strings.Join(meta.Authors, ", ")
+	}
+	if data.TahunTerbit == 0 {
+		data.TahunTerbit = meta.Year
+	}
+	if data.Penerbit == "" {
+		data.Penerbit = meta.Publisher
+	}
+	if data.Halaman == 0 {
+		data.Halaman = meta.Pages
+	}
+	if data.CoverURL == "" {
+		data.CoverURL = meta.CoverURL
+	}
+}
+
+// Add menambah buku. Jika ISBN diisi, judul, penulis dan tahun boleh dikosongkan
+// selama metadata provider bisa melengkapinya.
+func (bs *bookSrv) Add(p auth.Principal, newBook book.Core) (book.Core, error) {
+	userID := int(p.UserID)
+	if userID <= 0 {
+		return book.Core{}, errors.New("user not found")
+	}
+
+	if err := bs.checkISBN(p.UserID, 0, &newBook); err != nil {
+		return book.Core{}, err
+	}
+	bs.enrich(&newBook)
+
+	// field yang tetap kosong karena provider gagal atau tidak mengenal ISBN dilaporkan per field
+	if err := bs.validasi.Struct(newBook); err != nil {
+		return book.Core{}, validationError(err)
 	}
 
 	res, err := bs.data.Add(userID, newBook)
 	if err != nil {
 		msg := ""
+		if strings.Contains(err.Error(), "sudah") {
+			return book.Core{}, err
+		}
 		if strings.Contains(err.Error(), "not found") {
 			msg = "Book not found"
 		} else {
@@ -51,68 +151,260 @@ func (bs *bookSrv) Add(token interface{}, newBook book.Core) (book.Core, error)
 		}
 		return book.Core{}, errors.New(msg)
 	}
+	bs.index(res)
 
 	return res, nil
 
 }
 
-func (bs *bookSrv) MyBook(token interface{}) ([]book.Core, error) {
-	userID := helper.ExtractToken(token)
+func (bs *bookSrv) MyBook(p auth.Principal, filter book.BookFilter) ([]book.Core, book.PageCore, error) {
+	userID := int(p.UserID)
 	if userID <= 0 {
-		return nil, errors.New("user not found")
+		return nil, book.PageCore{}, errors.New("user not found")
 	}
 
-	res, _ := bs.data.MyBook(userID)
-
-	return res, nil
+	filter.OwnerID = uint(userID)
+	return bs.list(filter)
 }
-func (bs *bookSrv) Update(token interface{}, bookID int, updatedData book.Core) (book.Core, error) {
-	userID := helper.ExtractToken(token)
+func (bs *bookSrv) Update(p auth.Principal, bookID int, updatedData book.Core) (book.Core, error) {
+	userID := int(p.UserID)
 	if userID <= 0 {
 		return book.Core{}, errors.New("id user not found")
 	}
-	if validasieror := bs.validasi.Struct(updatedData); validasieror != nil {
-		return book.Core{}, nil
+	if err := bs.validasi.Struct(updatedData); err != nil {
+		return book.Core{}, validationError(err)
+	}
+	owner := ownerFilter(p, userID)
+	if updatedData.ISBN != "" {
+		// keunikan ISBN dicek terhadap pemilik buku, admin bisa mengubah buku user lain
+		cur, err := bs.data.Get(owner, bookID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return book.Core{}, errors.New("Book not found")
+			}
+			return book.Core{}, errors.New("internal server error")
+		}
+		if err := bs.checkISBN(cur.OwnerID, uint(bookID), &updatedData); err != nil {
+			return book.Core{}, err
+		}
+		// metadata yang tidak dikirim tetap memakai nilai tersimpan, yang masih kosong dilengkapi dari ISBN
+		if updatedData.Penerbit == "" {
+			updatedData.Penerbit = cur.Penerbit
+		}
+		if updatedData.Halaman == 0 {
+			updatedData.Halaman = cur.Halaman
+		}
+		if updatedData.CoverURL == "" {
+			updatedData.CoverURL = cur.CoverURL
+		}
+		bs.enrich(&updatedData)
 	}
 
-	res, err := bs.data.Update(userID, bookID, updatedData)
+	// field opsional hanya ditulis jika terisi, client lama yang hanya mengirim judul, tahun dan penulis
+	// tidak menghapus ISBN dan metadata. Untuk mengosongkannya gunakan PATCH dengan null.
+	fields := []string{"Judul", "TahunTerbit", "Penulis"}
+	if updatedData.ISBN != "" {
+		fields = append(fields, "ISBN")
+	}
+	if updatedData.Penerbit != "" {
+		fields = append(fields, "Penerbit")
+	}
+	if updatedData.Halaman != 0 {
+		fields = append(fields, "Halaman")
+	}
+	if updatedData.CoverURL != "" {
+		fields = append(fields, "CoverURL")
+	}
+	res, err := bs.data.Update(owner, bookID, updatedData, fields)
 	if err != nil {
 		msg := ""
+		if strings.Contains(err.Error(), "sudah") {
+			return book.Core{}, err
+		}
 		if strings.Contains(err.Error(), "not found") {
-			msg = "book or user not found"
+			msg = "Book not found"
 		} else {
 			msg = "internal server error"
 		}
 		return book.Core{}, errors.New(msg)
 	}
+	updatedData.ID = uint(bookID)
+	bs.index(updatedData)
 
 	return res, nil
 }
 
-// All implements book.BookService
-func (bs *bookSrv) AllBook() ([]book.Core, error) {
-	All, err := bs.data.AllBook()
-	fmt.Println("ini service", All)
+// bookPatch dokumen buku yang bisa diubah lewat PATCH /books/:id.
+type bookPatch struct {
+	Judul       string `json:"judul"`
+	TahunTerbit int    `json:"tahun_terbit"`
+	Penulis     string `json:"penulis"`
+	ISBN        string `json:"isbn"`
+	Penerbit    string `json:"penerbit"`
+	Halaman     int    `json:"halaman"`
+	CoverURL    string `json:"cover_url"`
+}
+
+// Patch menerapkan JSON Merge Patch ke buku, hasil akhirnya tetap harus lolos validasi Core.
+func (bs *bookSrv) Patch(p auth.Principal, bookID int, patch []byte) (book.Core, error) {
+	userID := int(p.UserID)
+	if userID <= 0 {
+		return book.Core{}, errors.New("id user not found")
+	}
+	owner := ownerFilter(p, userID)
 
+	cur, err := bs.data.Get(owner, bookID)
 	if err != nil {
-		msg := ""
 		if strings.Contains(err.Error(), "not found") {
-			msg = "Book not found"
+			return book.Core{}, errors.New("book not found")
+		}
+		return book.Core{}, errors.New("internal server error")
+	}
+
+	doc := bookPatch{Judul: cur.Judul, TahunTerbit: cur.TahunTerbit, Penulis: cur.Penulis, ISBN: cur.ISBN,
+		Penerbit: cur.Penerbit, Halaman: cur.Halaman, CoverURL: cur.CoverURL}
+	if err := helper.ApplyMergePatch(&doc, patch); err != nil {
+		return book.Core{}, err
+	}
+
+	updated := book.Core{ID: cur.ID, Judul: doc.Judul, TahunTerbit: doc.TahunTerbit, Penulis: doc.Penulis, ISBN: doc.ISBN,
+		Penerbit: doc.Penerbit, Halaman: doc.Halaman, CoverURL: doc.CoverURL, OwnerID: cur.OwnerID}
+	if err := bs.validasi.Struct(updated); err != nil {
+		return book.Core{}, validationError(err)
+	}
+
+	fields := []string{}
+	if updated.Judul != cur.Judul {
+		fields = append(fields, "Judul")
+	}
+	if updated.TahunTerbit != cur.TahunTerbit {
+		fields = append(fields, "TahunTerbit")
+	}
+	if updated.Penulis != cur.Penulis {
+		fields = append(fields, "Penulis")
+	}
+	if updated.Penerbit != cur.Penerbit {
+		fields = append(fields, "Penerbit")
+	}
+	if updated.Halaman != cur.Halaman {
+		fields = append(fields, "Halaman")
+	}
+	if updated.CoverURL != cur.CoverURL {
+		fields = append(fields, "CoverURL")
+	}
+	if updated.ISBN != cur.ISBN {
+		if err := bs.checkISBN(cur.OwnerID, cur.ID, &updated); err != nil {
+			if errors.Is(err, isbn.ErrInvalid) {
+				ve := &helper.ValidationError{}
+				ve.Add("isbn", err.Error())
+				return book.Core{}, ve
+			}
+			return book.Core{}, err
+		}
+		// ISBN yang sama dalam bentuk lain tidak dianggap perubahan
+		if updated.ISBN != cur.ISBN {
+			fields = append(fields, "ISBN")
+		}
+	}
+	if len(fields) == 0 {
+		return cur, nil
+	}
+
+	if _, err := bs.data.Update(owner, bookID, updated, fields); err != nil {
+		if strings.Contains(err.Error(), "sudah") {
+			return book.Core{}, err
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return book.Core{}, errors.New("book not found")
+		}
+		return book.Core{}, errors.New("internal server error")
+	}
+	bs.index(updated)
+
+	return updated, nil
+}
+
+// validationError mengubah hasil validator menjadi helper.ValidationError per field (dibalas 400).
+func validationError(err error) error {
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		log.Println(err)
+		return errors.New("internal server error")
+	}
+
+	ve := &helper.ValidationError{}
+	for _, fe := range errs {
+		if fe.Tag() == "required" {
+			ve.Add(patchField[fe.Field()], patchField[fe.Field()]+" wajib diisi")
 		} else {
-			msg = "internal server error"
+			ve.Add(patchField[fe.Field()], "format "+patchField[fe.Field()]+" salah")
 		}
-		return nil, errors.New(msg)
 	}
+	return ve
+}
 
-	return All, nil
+// patchField nama field Core ke nama key JSON untuk pesan validasi.
+var patchField = map[string]string{
+	"Judul":       "judul",
+	"TahunTerbit": "tahun_terbit",
+	"Penulis":     "penulis",
+	"ISBN":        "isbn",
+	"Penerbit":    "penerbit",
+	"Halaman":     "halaman",
+	"CoverURL":    "cover_url",
 }
-func (bs *bookSrv) Delete(token interface{}, bookID int) error {
-	userID := helper.ExtractToken(token)
+
+// All implements book.BookService
+func (bs *bookSrv) AllBook(filter book.BookFilter) ([]book.Core, book.PageCore, error) {
+	return bs.list(filter)
+}
+
+// list memvalidasi filter lalu mengambil satu halaman buku. NextCursor hanya diisi
+// jika halaman penuh, sehingga halaman terakhir tidak punya cursor berikutnya.
+func (bs *bookSrv) list(filter book.BookFilter) ([]book.Core, book.PageCore, error) {
+	filter.Normalize()
+	filter.Penulis = strings.TrimSpace(filter.Penulis)
+	if !validSort(filter.Sort) {
+		return nil, book.PageCore{}, errors.New("format sort salah")
+	}
+	if filter.YearFrom < 0 || filter.YearTo < 0 || (filter.YearTo > 0 && filter.YearFrom > filter.YearTo) {
+		return nil, book.PageCore{}, errors.New("format rentang tahun salah")
+	}
+	if filter.Cursor != "" {
+		if _, err := book.ParseCursor(filter); err != nil {
+			return nil, book.PageCore{}, err
+		}
+	}
+
+	res, total, err := bs.data.AllBook(filter)
+	if err != nil {
+		return nil, book.PageCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	page := book.PageCore{Page: filter.Page, Limit: filter.Limit, Total: total}
+	if len(res) == filter.Limit && (filter.Cursor != "" || int64(filter.Page*filter.Limit) < total) {
+		page.NextCursor = book.NewCursor(filter, res[len(res)-1])
+	}
+
+	return res, page, nil
+}
+
+func validSort(sort string) bool {
+	for _, val := range book.SortFields {
+		if val == sort {
+			return true
+		}
+	}
+	return false
+}
+
+func (bs *bookSrv) Delete(p auth.Principal, bookID int) error {
+	userID := int(p.UserID)
 	if userID <= 0 {
 		return errors.New("user not found")
 	}
 
-	err := bs.data.Delete(userID, bookID)
+	err := bs.data.Delete(ownerFilter(p, userID), bookID)
 	if err != nil {
 		msg := ""
 		if strings.Contains(err.Error(), "not found") {
@@ -123,5 +415,119 @@ func (bs *bookSrv) Delete(token interface{}, bookID int) error {
 		}
 		return errors.New(msg)
 	}
+	if bs.srch != nil {
+		if err := bs.srch.Remove(uint(bookID)); err != nil {
+			log.Println("remove book index error", err.Error())
+		}
+	}
 	return nil
 }
+
+// maxQueryLen batas panjang query pencarian.
+const maxQueryLen = 200
+
+// maxSearchHits batas hit yang diambil dari searcher untuk satu pencarian. Semua hit dicocokkan
+// dulu ke database (buku terhapus atau pemiliknya nonaktif dibuang) sebelum total dan halaman dihitung.
+const maxSearchHits = 500
+
+// Search mencari buku berdasarkan judul dan penulis. Hit yang bukunya sudah dihapus atau
+// pemiliknya dinonaktifkan dilewati, sehingga index yang sedikit tertinggal tidak membocorkan data.
+func (bs *bookSrv) Search(query string, page, limit int) ([]book.SearchCore, book.PageCore, error) {
+	if bs.srch == nil {
+		return nil, book.PageCore{}, errors.New("terdapat masalah pada server, pencarian belum dikonfigurasi")
+	}
+	query = strings.TrimSpace(query)
+	if query == "" || len([]rune(query)) > maxQueryLen || len(search.Terms(query)) == 0 {
+		return nil, book.PageCore{}, errors.New("format query pencarian salah")
+	}
+	filter := book.BookFilter{Page: page, Limit: limit}
+	filter.Normalize()
+
+	// index bisa berisi buku yang sudah tidak tampil, jadi total dihitung setelah hit dicocokkan ke database
+	hits, _, err := bs.srch.Search(query, maxSearchHits, 0)
+	if err != nil {
+		return nil, book.PageCore{}, errors.New("terdapat masalah pada server")
+	}
+	paging := book.PageCore{Page: filter.Page, Limit: filter.Limit}
+	if len(hits) == 0 {
+		return []book.SearchCore{}, paging, nil
+	}
+
+	ids := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	books, err := bs.data.GetByIDs(ids)
+	if err != nil {
+		return nil, book.PageCore{}, errors.New("terdapat masalah pada server")
+	}
+	byID := map[uint]book.Core{}
+	for _, val := range books {
+		byID[val.ID] = val
+	}
+
+	found := []book.SearchCore{}
+	for _, hit := range hits {
+		if val, ok := byID[hit.ID]; ok {
+			found = append(found, book.SearchCore{Core: val, Score: hit.Score, Highlights: hit.Highlights})
+		}
+	}
+	paging.Total = int64(len(found))
+
+	offset := (filter.Page - 1) * filter.Limit
+	if offset >= len(found) {
+		return []book.SearchCore{}, paging, nil
+	}
+	end := offset + filter.Limit
+	if end > len(found) {
+		end = len(found)
+	}
+
+	return found[offset:end], paging, nil
+}
+
+// Detail satu buku beserta profil publik pemiliknya. Buku milik akun yang disuspend tetap
+// bisa dilihat tetapi tidak tersedia dan tanpa data pemilik.
+func (bs *bookSrv) Detail(bookID int) (book.DetailCore, error) {
+	if bookID <= 0 {
+		return book.DetailCore{}, errors.New("book not found")
+	}
+
+	res, err := bs.data.Detail(bookID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return book.DetailCore{}, errors.New("book not found")
+		}
+		return book.DetailCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	detail := book.DetailCore{Core: res}
+	if bs.profiles == nil {
+		return detail, nil
+	}
+	owner, err := bs.profiles.Profile(res.OwnerID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return detail, nil
+		}
+		return book.DetailCore{}, errors.New("terdapat masalah pada server")
+	}
+	detail.Owner = &owner
+	detail.Available = true
+
+	return detail, nil
+}
+
+func (bs *bookSrv) ByISBN(val string) ([]book.Core, error) {
+	norm, err := isbn.Normalize(val)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := bs.data.GetByISBN(norm)
+	if err != nil {
+		return nil, errors.New("terdapat masalah pada server")
+	}
+
+	return res, nil
+}
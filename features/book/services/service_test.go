@@ -1,14 +1,18 @@
 package services
 
 import (
+	"api/auth"
 	"api/features/book"
+	"api/features/profile"
 	"api/helper"
+	"api/metadata"
 	"api/mocks"
+	"api/search"
 	"errors"
 	"testing"
 
-	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestAdd(t *testing.T) {
@@ -39,12 +43,10 @@ func TestAdd(t *testing.T) {
 			Pemilik:     Input.Pemilik,
 		}
 
-		_, token := helper.GenerateJWT(sample.ID)
-		useToken := token.(*jwt.Token)
-		useToken.Valid = true
+		useToken := auth.Principal{UserID: uint(sample.ID), Roles: []string{helper.RoleUser}}
 
 		data.On("Add", sample.ID, Input).Return(Respon, nil).Once()
-		svc := New(data)
+		svc := New(data, nil, nil, nil)
 
 		res, err := svc.Add(useToken, Input)
 		assert.Nil(t, err)
@@ -68,9 +70,9 @@ func TestAdd(t *testing.T) {
 			Pemilik:     sample.Name,
 		}
 
-		srv := New(data)
+		srv := New(data, nil, nil, nil)
 
-		_, token := helper.GenerateJWT(sample.ID)
+		token := auth.Principal{}
 
 		res, err := srv.Add(token, Input)
 		assert.NotNil(t, err)
@@ -95,15 +97,13 @@ func TestAdd(t *testing.T) {
 
 		//yang di coba testing
 
-		srv := New(data)
+		srv := New(data, nil, nil, nil)
 
-		_, token := helper.GenerateJWT(sample.ID)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		pToken := auth.Principal{UserID: uint(sample.ID), Roles: []string{helper.RoleUser}}
 		res, err := srv.Add(pToken, Input)
 
 		assert.NotNil(t, err)
-		assert.ErrorContains(t, err, "validation error")
+		assert.ErrorContains(t, err, "judul wajib diisi")
 		assert.Equal(t, uint(0), res.ID)
 	})
 	t.Run("data not found", func(t *testing.T) {
@@ -124,11 +124,9 @@ func TestAdd(t *testing.T) {
 		}
 		data.On("Add", sample.ID, Input).Return(book.Core{}, errors.New("data not found")).Once() ///data yang akan di testinng//once data yang di pakai saat add buku
 
-		srv := New(data)
+		srv := New(data, nil, nil, nil)
 
-		_, token := helper.GenerateJWT(sample.ID)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		pToken := auth.Principal{UserID: uint(sample.ID), Roles: []string{helper.RoleUser}}
 		res, err := srv.Add(pToken, Input)
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "Book not found")
@@ -153,11 +151,9 @@ func TestAdd(t *testing.T) {
 			Pemilik:     sample.Name,
 		}
 		data.On("Add", sample.ID, Input).Return(book.Core{}, errors.New("internal server error")).Once() ///data yang akan di testinng//once data yang di pakai saat add buku
-		srv := New(data)                                                                                 //new service
+		srv := New(data, nil, nil, nil)                                                                  //new service
 
-		_, token := helper.GenerateJWT(sample.ID)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		pToken := auth.Principal{UserID: uint(sample.ID), Roles: []string{helper.RoleUser}}
 		res, err := srv.Add(pToken, Input)
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "server")
@@ -168,7 +164,7 @@ func TestAdd(t *testing.T) {
 
 func TestAllBook(t *testing.T) {
 	data := mocks.NewBookData(t)
-	svc := New(data)
+	svc := New(data, nil, nil, nil)
 	t.Run("Berhasil Melihat semua Buku", func(t *testing.T) {
 
 		type SampleUsers struct {
@@ -202,9 +198,9 @@ func TestAllBook(t *testing.T) {
 				Pemilik:     sample.Name,
 			},
 		}
-		data.On("AllBook").Return(Respon, nil).Once()
-		svc := New(data)
-		actual, err := svc.AllBook()
+		data.On("AllBook", book.BookFilter{Sort: book.SortCreatedAt, Page: 1, Limit: 20}).Return(Respon, int64(3), nil).Once()
+		svc := New(data, nil, nil, nil)
+		actual, page, err := svc.AllBook(book.BookFilter{})
 		assert.Nil(t, err)
 		assert.Equal(t, Respon[0].ID, actual[0].ID)
 		assert.Equal(t, Respon[0].Judul, actual[0].Judul)
@@ -213,38 +209,80 @@ func TestAllBook(t *testing.T) {
 		assert.Equal(t, Respon[1].Pemilik, actual[1].Pemilik)
 		assert.Equal(t, Respon[2].ID, actual[2].ID)
 		assert.Equal(t, Respon[2].Pemilik, actual[2].Pemilik)
+		assert.Equal(t, int64(3), page.Total)
+		assert.Empty(t, page.NextCursor)
 	})
 
-	// Case: user ingin melihat list buku yang, tetapi buku tidak ada buku yang ditemukan
-	t.Run(" all book not found", func(t *testing.T) {
-		// Programming input and return repo
-		data.On("AllBook").Return(nil, errors.New("Book not found")).Once()
+	t.Run("halaman penuh mengembalikan next cursor", func(t *testing.T) {
+		filter := book.BookFilter{Penulis: "Masashi", YearFrom: 1990, YearTo: 2010, Sort: book.SortTahunTerbit, Desc: true, Page: 1, Limit: 2}
+		Respon := []book.Core{{ID: 4, TahunTerbit: 2005}, {ID: 1, TahunTerbit: 2000}}
+		data.On("AllBook", filter).Return(Respon, int64(5), nil).Once()
 
-		// Program service
-		actual, err := svc.AllBook()
+		_, page, err := svc.AllBook(book.BookFilter{Penulis: " Masashi ", YearFrom: 1990, YearTo: 2010, Sort: book.SortTahunTerbit, Desc: true, Limit: 2})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, page.NextCursor)
 
-		// Test
+		// cursor berikutnya dimulai setelah buku terakhir di halaman
+		filter.Cursor = page.NextCursor
+		cur, err := book.ParseCursor(filter)
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), cur.ID)
+		assert.Equal(t, "2000", cur.Value)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("halaman terakhir tanpa next cursor", func(t *testing.T) {
+		data.On("AllBook", book.BookFilter{Sort: book.SortJudul, Page: 2, Limit: 2}).Return([]book.Core{{ID: 5}, {ID: 6}}, int64(4), nil).Once()
+
+		_, page, err := svc.AllBook(book.BookFilter{Sort: book.SortJudul, Page: 2, Limit: 2})
+		assert.Nil(t, err)
+		assert.Empty(t, page.NextCursor)
+		data.AssertExpectations(t)
+	})
+
+	t.Run("sort tidak dikenal", func(t *testing.T) {
+		actual, _, err := svc.AllBook(book.BookFilter{Sort: "harga"})
 		assert.NotNil(t, err)
-		assert.EqualError(t, err, "Book not found")
+		assert.ErrorContains(t, err, "format sort")
 		assert.Nil(t, actual)
+	})
 
+	t.Run("rentang tahun terbalik", func(t *testing.T) {
+		_, _, err := svc.AllBook(book.BookFilter{YearFrom: 2010, YearTo: 2000})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format rentang tahun")
+	})
+
+	t.Run("cursor dari urutan lain", func(t *testing.T) {
+		cursor := book.NewCursor(book.BookFilter{Sort: book.SortJudul}, book.Core{ID: 3, Judul: "Naruto"})
+
+		_, _, err := svc.AllBook(book.BookFilter{Sort: book.SortPenulis, Cursor: cursor})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format cursor")
+	})
+
+	t.Run("cursor rusak", func(t *testing.T) {
+		_, _, err := svc.AllBook(book.BookFilter{Cursor: "bukan-cursor"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format cursor")
 	})
+
 	t.Run("Get all book error server", func(t *testing.T) {
 		// Programming input and return repo
-		data.On("AllBook").Return([]book.Core{}, errors.New("internal server error")).Once()
+		data.On("AllBook", mock.Anything).Return(nil, int64(0), errors.New("database error")).Once()
 
 		// Program service
-		actual, err := svc.AllBook()
+		actual, _, err := svc.AllBook(book.BookFilter{})
 
 		// Test
 		assert.NotNil(t, err)
-		assert.EqualError(t, err, "internal server error")
+		assert.ErrorContains(t, err, "server")
 		assert.Nil(t, actual)
 
 	})
 }
 func TestUpdateBook(t *testing.T) {
-	input := book.Core{Judul: "One Piece"}
+	input := book.Core{Judul: "One Piece", TahunTerbit: 1997, Penulis: "Eiichiro Oda"}
 	resData := book.Core{
 		ID:      1,
 		Judul:   "Naruto",
@@ -254,15 +292,13 @@ func TestUpdateBook(t *testing.T) {
 
 	repo := mocks.NewBookData(t)
 
-	srv := New(repo)
+	srv := New(repo, nil, nil, nil)
 
 	t.Run("Update successfully", func(t *testing.T) {
-		repo.On("Update", 1, 1, input).Return(resData, nil).Once()
+		repo.On("Update", 1, 1, input, []string{"Judul", "TahunTerbit", "Penulis"}).Return(resData, nil).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		actual, err := srv.Update(token, 1, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		actual, err := srv.Update(pToken, 1, input)
 
 		assert.Nil(t, err)
 		assert.Equal(t, resData.Judul, actual.Judul)
@@ -274,7 +310,7 @@ func TestUpdateBook(t *testing.T) {
 
 	t.Run("Update error user not found", func(t *testing.T) {
 
-		token := jwt.New(jwt.SigningMethodHS256)
+		token := auth.Principal{}
 		actual, err := srv.Update(token, 1, input)
 
 		// Test
@@ -286,31 +322,28 @@ func TestUpdateBook(t *testing.T) {
 	t.Run("Update error invalid", func(t *testing.T) {
 		input := book.Core{
 			Judul:       "nar",
-			Penulis:     "mas",
 			TahunTerbit: 2000,
 		}
 
 		// Program service
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		actual, err := srv.Update(token, 1, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		actual, err := srv.Update(pToken, 1, input)
 
 		// Test
 		assert.NotNil(t, err)
-		assert.ErrorContains(t, err, "input update book invalid")
+		var ve *helper.ValidationError
+		assert.ErrorAs(t, err, &ve)
+		assert.Equal(t, []string{"penulis wajib diisi"}, ve.Fields["penulis"])
 		assert.Empty(t, actual)
 	})
 
 	t.Run("Update error book not found", func(t *testing.T) {
 		// Programming input and return repo
-		repo.On("Update", 1, 1, input).Return(book.Core{}, errors.New("not found")).Once()
+		repo.On("Update", 1, 1, input, []string{"Judul", "TahunTerbit", "Penulis"}).Return(book.Core{}, errors.New("not found")).Once()
 
 		// Program service
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		actual, err := srv.Update(token, 1, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		actual, err := srv.Update(pToken, 1, input)
 
 		// Test
 		assert.NotNil(t, err)
@@ -321,13 +354,11 @@ func TestUpdateBook(t *testing.T) {
 
 	t.Run("Update error internal server", func(t *testing.T) {
 		// Programming input and return repo
-		repo.On("Update", 1, 1, input).Return(book.Core{}, errors.New("internal server error")).Once()
+		repo.On("Update", 1, 1, input, []string{"Judul", "TahunTerbit", "Penulis"}).Return(book.Core{}, errors.New("internal server error")).Once()
 
 		// Program service
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		actual, err := srv.Update(token, 1, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		actual, err := srv.Update(pToken, 1, input)
 
 		// Test
 		assert.NotNil(t, err)
@@ -339,14 +370,12 @@ func TestUpdateBook(t *testing.T) {
 func TestDeleteBook(t *testing.T) {
 	repo := mocks.NewBookData(t)
 
-	srv := New(repo)
+	srv := New(repo, nil, nil, nil)
 	t.Run("Delete Success", func(t *testing.T) {
 		repo.On("Delete", 1, 1).Return(nil).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		err := srv.Delete(token, 1)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		err := srv.Delete(pToken, 1)
 
 		assert.Nil(t, err)
 
@@ -356,10 +385,8 @@ func TestDeleteBook(t *testing.T) {
 	t.Run("Delete Error", func(t *testing.T) {
 		repo.On("Delete", 1, 1).Return(errors.New("user id not found")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		err := srv.Delete(token, 1)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		err := srv.Delete(pToken, 1)
 
 		assert.NotNil(t, err)
 
@@ -368,22 +395,36 @@ func TestDeleteBook(t *testing.T) {
 	t.Run("Delete Error", func(t *testing.T) {
 		repo.On("Delete", 1, 1).Return(errors.New("Book not found")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		err := srv.Delete(token, 1)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		err := srv.Delete(pToken, 1)
 
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "Book not found")
 		repo.AssertExpectations(t)
 	})
+	t.Run("Admin menghapus buku user lain", func(t *testing.T) {
+		repo.On("Delete", book.AnyOwner, 7).Return(nil).Once()
+
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleAdmin}}
+		err := srv.Delete(pToken, 7)
+
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+	t.Run("API key admin hanya menghapus buku sendiri", func(t *testing.T) {
+		repo.On("Delete", 1, 7).Return(errors.New("Book not found")).Once()
+
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleAdmin}, APIKeyID: 3, Scopes: []string{"books:write"}}
+		err := srv.Delete(pToken, 7)
+
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
 	t.Run("Delete server error", func(t *testing.T) {
 		repo.On("Delete", 1, 1).Return(errors.New("internal server error")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		err := srv.Delete(token, 1)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		err := srv.Delete(pToken, 1)
 
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "server")
@@ -394,7 +435,7 @@ func TestDeleteBook(t *testing.T) {
 func TestMyBook(t *testing.T) {
 	repo := mocks.NewBookData(t)
 
-	srv := New(repo)
+	srv := New(repo, nil, nil, nil)
 
 	// Case: user ingin melihat list buku yang dimilikinya
 	t.Run("MyBook list succesfully", func(t *testing.T) {
@@ -413,13 +454,11 @@ func TestMyBook(t *testing.T) {
 			},
 		}
 
-		// Programming input and return repo
-		repo.On("MyBook", 1).Return(resData, nil).Once()
+		// Programming input and return repo, user_id dari query diganti pemilik token
+		repo.On("AllBook", book.BookFilter{OwnerID: 1, Sort: book.SortCreatedAt, Page: 1, Limit: 20}).Return(resData, int64(2), nil).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		actual, err := srv.MyBook(token)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		actual, page, err := srv.MyBook(pToken, book.BookFilter{OwnerID: 2})
 
 		// Test
 		assert.Nil(t, err)
@@ -427,5 +466,415 @@ func TestMyBook(t *testing.T) {
 		assert.Equal(t, resData[0].Judul, actual[0].Judul)
 		assert.Equal(t, resData[1].ID, actual[1].ID)
 		assert.Equal(t, resData[1].Judul, actual[1].Judul)
+		assert.Equal(t, int64(2), page.Total)
+	})
+}
+
+func TestPatchBook(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	srv := New(repo, nil, nil, nil)
+
+	current := book.Core{ID: 1, Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto"}
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Patch hanya kolom yang berubah", func(t *testing.T) {
+		repo.On("Get", 1, 1).Return(current, nil).Once()
+		repo.On("Update", 1, 1, book.Core{ID: 1, Judul: "Boruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto"}, []string{"Judul"}).Return(book.Core{}, nil).Once()
+
+		actual, err := srv.Patch(pToken, 1, []byte(`{"judul":"Boruto"}`))
+		assert.Nil(t, err)
+		assert.Equal(t, "Boruto", actual.Judul)
+		assert.Equal(t, 1999, actual.TahunTerbit)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Patch tanpa perubahan", func(t *testing.T) {
+		repo.On("Get", 1, 1).Return(current, nil).Once()
+
+		actual, err := srv.Patch(pToken, 1, []byte(`{"judul":"Naruto"}`))
+		assert.Nil(t, err)
+		assert.Equal(t, current, actual)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("field wajib dihapus", func(t *testing.T) {
+		repo.On("Get", 1, 1).Return(current, nil).Once()
+
+		_, err := srv.Patch(pToken, 1, []byte(`{"penulis":null}`))
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "penulis")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("field tidak bisa diubah", func(t *testing.T) {
+		repo.On("Get", 1, 1).Return(current, nil).Once()
+
+		_, err := srv.Patch(pToken, 1, []byte(`{"pemilik":"jerry"}`))
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "pemilik")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("buku milik user lain", func(t *testing.T) {
+		repo.On("Get", 1, 2).Return(book.Core{}, errors.New("not found")).Once()
+
+		_, err := srv.Patch(pToken, 2, []byte(`{"judul":"Boruto"}`))
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("admin boleh patch buku siapa pun", func(t *testing.T) {
+		aToken := auth.Principal{UserID: 9, Roles: []string{helper.RoleAdmin}}
+		repo.On("Get", book.AnyOwner, 2).Return(current, nil).Once()
+		repo.On("Update", book.AnyOwner, 2, mock.Anything, []string{"TahunTerbit"}).Return(book.Core{}, nil).Once()
+
+		actual, err := srv.Patch(aToken, 2, []byte(`{"tahun_terbit":2000}`))
+		assert.Nil(t, err)
+		assert.Equal(t, 2000, actual.TahunTerbit)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	idx := search.NewMemory(book.SearchFields...)
+	srv := New(repo, idx, nil, nil)
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	naruto := book.Core{ID: 1, Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", Pemilik: "jerry"}
+	repo.On("Add", 1, book.Core{Judul: naruto.Judul, TahunTerbit: naruto.TahunTerbit, Penulis: naruto.Penulis}).Return(naruto, nil).Once()
+	_, err := srv.Add(pToken, book.Core{Judul: naruto.Judul, TahunTerbit: naruto.TahunTerbit, Penulis: naruto.Penulis})
+	assert.Nil(t, err)
+	idx.Index(book.ToDocument(book.Core{ID: 2, Judul: "Boruto", Penulis: "Ukyo Kodachi"}))
+
+	t.Run("buku yang baru ditambah langsung bisa dicari", func(t *testing.T) {
+		repo.On("GetByIDs", []uint{1}).Return([]book.Core{naruto}, nil).Once()
+
+		res, page, err := srv.Search("narto", 0, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), page.Total)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "jerry", res[0].Pemilik)
+		assert.Equal(t, "<em>Naruto</em>", res[0].Highlights["judul"])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("index ikut berubah saat buku di-patch", func(t *testing.T) {
+		repo.On("Get", 1, 1).Return(naruto, nil).Once()
+		repo.On("Update", 1, 1, mock.Anything, []string{"Judul"}).Return(book.Core{}, nil).Once()
+
+		_, err := srv.Patch(pToken, 1, []byte(`{"judul":"Naruto Shippuden"}`))
+		assert.Nil(t, err)
+
+		repo.On("GetByIDs", []uint{1}).Return([]book.Core{naruto}, nil).Once()
+		res, _, err := srv.Search("shippuden", 1, 10)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("buku yang dihapus hilang dari index", func(t *testing.T) {
+		repo.On("Delete", 1, 1).Return(nil).Once()
+
+		assert.Nil(t, srv.Delete(pToken, 1))
+		res, page, err := srv.Search("naruto", 1, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), page.Total)
+		assert.Empty(t, res)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("hit yang bukunya sudah tidak ada dilewati", func(t *testing.T) {
+		repo.On("GetByIDs", []uint{2}).Return([]book.Core{}, nil).Once()
+
+		res, page, err := srv.Search("boruto", 1, 10)
+		assert.Nil(t, err)
+		assert.Empty(t, res)
+		assert.Equal(t, int64(0), page.Total)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("total dan halaman hanya menghitung buku yang masih ada", func(t *testing.T) {
+		idx := search.NewMemory(book.SearchFields...)
+		srv := New(repo, idx, nil, nil)
+		for id := uint(10); id < 15; id++ {
+			idx.Index(book.ToDocument(book.Core{ID: id, Judul: "One Piece", Penulis: "Eiichiro Oda"}))
+		}
+		// buku 11 sudah tidak tampil (mis. pemiliknya nonaktif) tetapi masih ada di index
+		repo.On("GetByIDs", []uint{10, 11, 12, 13, 14}).Return([]book.Core{{ID: 10}, {ID: 12}, {ID: 13}, {ID: 14}}, nil).Once()
+
+		res, page, err := srv.Search("one piece", 2, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), page.Total)
+		assert.Len(t, res, 1)
+		assert.Equal(t, uint(14), res[0].ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("query kosong", func(t *testing.T) {
+		_, _, err := srv.Search("  ", 1, 10)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format query")
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		srch := mocks.NewSearcher(t)
+		srch.On("Search", "naruto", maxSearchHits, 0).Return(nil, 0, errors.New("database error")).Once()
+
+		_, _, err := New(repo, srch, nil, nil).Search("naruto", 1, 0)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		srch.AssertExpectations(t)
+	})
+}
+
+func TestDetail(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	profiles := mocks.NewProfileService(t)
+	srv := New(repo, nil, profiles, nil)
+
+	resData := book.Core{ID: 1, Judul: "Naruto", Penulis: "Masashi Kishimoto", Pemilik: "fajar", OwnerID: 2}
+
+	t.Run("detail buku beserta pemilik", func(t *testing.T) {
+		repo.On("Detail", 1).Return(resData, nil).Once()
+		profiles.On("Profile", uint(2)).Return(profile.Core{ID: 2, Name: "fajar", BookCount: 3, HasAvatar: true}, nil).Once()
+
+		res, err := srv.Detail(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "Naruto", res.Judul)
+		assert.True(t, res.Available)
+		assert.Equal(t, uint(2), res.Owner.ID)
+		assert.Equal(t, 3, res.Owner.BookCount)
+		assert.True(t, res.Owner.HasAvatar)
+		repo.AssertExpectations(t)
+		profiles.AssertExpectations(t)
+	})
+
+	t.Run("pemilik disuspend", func(t *testing.T) {
+		repo.On("Detail", 1).Return(resData, nil).Once()
+		profiles.On("Profile", uint(2)).Return(profile.Core{}, errors.New("data user not found")).Once()
+
+		res, err := srv.Detail(1)
+		assert.Nil(t, err)
+		assert.False(t, res.Available)
+		assert.Nil(t, res.Owner)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("buku sudah dihapus", func(t *testing.T) {
+		repo.On("Detail", 3).Return(book.Core{}, errors.New("book not found")).Once()
+
+		_, err := srv.Detail(3)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("id tidak valid", func(t *testing.T) {
+		_, err := srv.Detail(0)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("Detail", 1).Return(book.Core{}, errors.New("database error")).Once()
+
+		_, err := srv.Detail(1)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestISBN(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	srv := New(repo, nil, nil, nil)
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("isbn-10 disimpan sebagai isbn-13", func(t *testing.T) {
+		input := book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "0-306-40615-2"}
+		saved := input
+		saved.ISBN = "9780306406157"
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{{ID: 5, OwnerID: 2, ISBN: "9780306406157"}}, nil).Once()
+		repo.On("Add", 1, saved).Return(saved, nil).Once()
+
+		res, err := srv.Add(pToken, input)
+		assert.Nil(t, err)
+		assert.Equal(t, "9780306406157", res.ISBN)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("isbn sudah dipakai buku lain milik user", func(t *testing.T) {
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{{ID: 3, OwnerID: 1, ISBN: "9780306406157"}}, nil).Once()
+
+		_, err := srv.Add(pToken, book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "978-0-306-40615-7"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("isbn bentrok di unique index database", func(t *testing.T) {
+		input := book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "9780306406157"}
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{}, nil).Once()
+		repo.On("Add", 1, input).Return(book.Core{}, errors.New("isbn sudah dipakai buku lain milik user ini")).Once()
+
+		_, err := srv.Add(pToken, input)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("check digit salah", func(t *testing.T) {
+		_, err := srv.Add(pToken, book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "978-0-306-40615-8"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format isbn")
+	})
+
+	t.Run("update buku dengan isbn yang sama", func(t *testing.T) {
+		input := book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "9780306406157"}
+		repo.On("Get", 1, 3).Return(book.Core{ID: 3, OwnerID: 1, ISBN: "9780306406157"}, nil).Once()
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{{ID: 3, OwnerID: 1, ISBN: "9780306406157"}}, nil).Once()
+		repo.On("Update", 1, 3, input, []string{"Judul", "TahunTerbit", "Penulis", "ISBN"}).Return(input, nil).Once()
+
+		_, err := srv.Update(pToken, 3, input)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("patch isbn tidak valid", func(t *testing.T) {
+		repo.On("Get", 1, 3).Return(book.Core{ID: 3, Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", OwnerID: 1}, nil).Once()
+
+		_, err := srv.Patch(pToken, 3, []byte(`{"isbn":"12345"}`))
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "isbn")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("patch isbn bentuk lain dari edisi yang sama", func(t *testing.T) {
+		current := book.Core{ID: 3, Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "9780306406157", OwnerID: 1}
+		repo.On("Get", 1, 3).Return(current, nil).Once()
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{current}, nil).Once()
+
+		res, err := srv.Patch(pToken, 3, []byte(`{"isbn":"0306406152"}`))
+		assert.Nil(t, err)
+		assert.Equal(t, "9780306406157", res.ISBN)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("semua eksemplar satu edisi", func(t *testing.T) {
+		copies := []book.Core{{ID: 3, OwnerID: 1}, {ID: 5, OwnerID: 2}}
+		repo.On("GetByISBN", "9780306406157").Return(copies, nil).Once()
+
+		res, err := srv.ByISBN("0-306-40615-2")
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cari dengan isbn tidak valid", func(t *testing.T) {
+		_, err := srv.ByISBN("abc")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format isbn")
+	})
+}
+
+// failProvider metadata provider yang selalu gagal, seperti Breaker yang sedang diputus.
+type failProvider struct{}
+
+func (failProvider) Lookup(isbn string) (metadata.Book, error) {
+	return metadata.Book{}, metadata.ErrUnavailable
+}
+
+func TestAddMetadata(t *testing.T) {
+	repo := mocks.NewBookData(t)
+	local := metadata.NewLocal(metadata.Book{
+		ISBN:      "9780306406157",
+		Title:     "Naruto",
+		Authors:   []string{"Masashi Kishimoto", "Jo Duffy"},
+		Publisher: "Shueisha",
+		Year:      1999,
+		Pages:     192,
+		CoverURL:  "https://covers.example.com/naruto.jpg",
+	})
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("field kosong diisi dari metadata", func(t *testing.T) {
+		expected := book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto, Jo Duffy", ISBN: "9780306406157",
+			Penerbit: "Shueisha", Halaman: 192, CoverURL: "https://covers.example.com/naruto.jpg"}
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{}, nil).Once()
+		repo.On("Add", 1, expected).Return(expected, nil).Once()
+
+		res, err := New(repo, nil, nil, local).Add(pToken, book.Core{ISBN: "0-306-40615-2"})
+		assert.Nil(t, err)
+		assert.Equal(t, "Shueisha", res.Penerbit)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("input user tidak ditimpa", func(t *testing.T) {
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{}, nil).Once()
+		repo.On("Add", 1, mock.MatchedBy(func(data book.Core) bool {
+			return data.Judul == "Naruto Vol. 1" && data.Penulis == "Masashi Kishimoto, Jo Duffy" && data.Halaman == 200
+		})).Return(book.Core{ID: 1}, nil).Once()
+
+		_, err := New(repo, nil, nil, local).Add(pToken, book.Core{ISBN: "9780306406157", Judul: "Naruto Vol. 1", Halaman: 200})
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("isbn tidak dikenal provider", func(t *testing.T) {
+		repo.On("GetByISBN", "9780804429573").Return([]book.Core{}, nil).Once()
+
+		_, err := New(repo, nil, nil, local).Add(pToken, book.Core{ISBN: "9780804429573"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "judul wajib diisi")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("provider gagal dan field wajib kosong", func(t *testing.T) {
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{}, nil).Once()
+
+		_, err := New(repo, nil, nil, failProvider{}).Add(pToken, book.Core{ISBN: "9780306406157"})
+		var ve *helper.ValidationError
+		assert.ErrorAs(t, err, &ve)
+		assert.Equal(t, []string{"judul wajib diisi"}, ve.Fields["judul"])
+		assert.Equal(t, []string{"penulis wajib diisi"}, ve.Fields["penulis"])
+		assert.Contains(t, ve.Fields, "tahun_terbit")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("provider gagal tidak menghalangi tambah buku", func(t *testing.T) {
+		input := book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "9780306406157"}
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{}, nil).Once()
+		repo.On("Add", 1, input).Return(input, nil).Once()
+
+		_, err := New(repo, nil, nil, failProvider{}).Add(pToken, input)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("put dengan isbn mempertahankan metadata tersimpan", func(t *testing.T) {
+		input := book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", ISBN: "9780306406157"}
+		stored := book.Core{ID: 3, OwnerID: 1, ISBN: "9780306406157", Penerbit: "Elex Media"}
+		expected := input
+		expected.Penerbit = "Elex Media"
+		expected.Halaman = 192
+		expected.CoverURL = "https://covers.example.com/naruto.jpg"
+		repo.On("Get", 1, 3).Return(stored, nil).Once()
+		repo.On("GetByISBN", "9780306406157").Return([]book.Core{stored}, nil).Once()
+		repo.On("Update", 1, 3, expected, []string{"Judul", "TahunTerbit", "Penulis", "ISBN", "Penerbit", "Halaman", "CoverURL"}).Return(expected, nil).Once()
+
+		_, err := New(repo, nil, nil, local).Update(pToken, 3, input)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("cover url tidak valid", func(t *testing.T) {
+		_, err := New(repo, nil, nil, nil).Add(pToken, book.Core{Judul: "Naruto", TahunTerbit: 1999, Penulis: "Masashi Kishimoto", CoverURL: "javascript:alert(1)"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format cover_url salah")
 	})
 }
@@ -0,0 +1,21 @@
+package profile
+
+import (
+	"api/features/book"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Core profil publik user, tipe yang sama dengan pemilik di detail buku. Alamat dan HP hanya
+// terisi jika pemilik mengizinkan lewat pengaturan privasi.
+type Core = book.OwnerCore
+
+type ProfileHandler interface {
+	Profile() echo.HandlerFunc
+	Books() echo.HandlerFunc
+}
+
+type ProfileService interface {
+	Profile(userID uint) (Core, error)
+	Books(userID uint) ([]book.Core, error)
+}
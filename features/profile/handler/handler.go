@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"api/features/profile"
+	"api/helper"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type profileControll struct {
+	srv profile.ProfileService
+}
+
+func New(srv profile.ProfileService) profile.ProfileHandler {
+	return &profileControll{
+		srv: srv,
+	}
+}
+
+func paramID(c echo.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, errors.New("format id user salah")
+	}
+	return uint(id), nil
+}
+
+func (pc *profileControll) Profile() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, err := pc.srv.Profile(id)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan profil", ToResponse(res)))
+	}
+}
+
+func (pc *profileControll) Books() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := paramID(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, err := pc.srv.Books(id)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(helper.PrintSuccessReponse(http.StatusOK, "sukses menampilkan buku", ListToBookResponse(res)))
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"api/features/book"
+	"api/features/profile"
+	"api/helper"
+	"time"
+)
+
+type ProfileResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"nama"`
+	JoinedAt  time.Time `json:"joined_at"`
+	BookCount int       `json:"book_count"`
+	Alamat    string    `json:"alamat,omitempty"`
+	HP        string    `json:"hp,omitempty"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+}
+
+type BookResponse struct {
+	ID          uint   `json:"id"`
+	Judul       string `json:"judul"`
+	TahunTerbit int    `json:"tahun_terbit"`
+	Penulis     string `json:"penulis"`
+}
+
+func ToResponse(data profile.Core) ProfileResponse {
+	res := ProfileResponse{
+		ID:        data.ID,
+		Name:      data.Name,
+		JoinedAt:  data.JoinedAt,
+		BookCount: data.BookCount,
+		Alamat:    data.Alamat,
+		HP:        data.HP,
+	}
+	if data.HasAvatar {
+		res.AvatarURL = helper.AvatarURL(data.ID)
+	}
+	return res
+}
+
+func ListToBookResponse(data []book.Core) []BookResponse {
+	res := []BookResponse{}
+	for _, value := range data {
+		res = append(res, BookResponse{
+			ID:          value.ID,
+			Judul:       value.Judul,
+			TahunTerbit: value.TahunTerbit,
+			Penulis:     value.Penulis,
+		})
+	}
+	return res
+}
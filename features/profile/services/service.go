@@ -0,0 +1,81 @@
+package services
+
+import (
+	"api/features/book"
+	"api/features/profile"
+	"api/features/user"
+	"errors"
+	"log"
+	"strings"
+)
+
+type profileSrv struct {
+	ud user.UserData
+	bd book.BookData
+}
+
+func New(ud user.UserData, bd book.BookData) profile.ProfileService {
+	return &profileSrv{
+		ud: ud,
+		bd: bd,
+	}
+}
+
+func (ps *profileSrv) Profile(userID uint) (profile.Core, error) {
+	usr, err := ps.publicUser(userID)
+	if err != nil {
+		return profile.Core{}, err
+	}
+
+	total, err := ps.bd.CountByOwner(int(userID))
+	if err != nil {
+		log.Println("profile book count query error", err.Error())
+		return profile.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	res := profile.Core{
+		ID:        usr.ID,
+		Name:      usr.Name,
+		JoinedAt:  usr.CreatedAt,
+		BookCount: int(total),
+		HasAvatar: usr.Avatar != "",
+	}
+	if usr.ShowAlamat {
+		res.Alamat = usr.Alamat
+	}
+	if usr.ShowHP {
+		res.HP = usr.HP
+	}
+
+	return res, nil
+}
+
+func (ps *profileSrv) Books(userID uint) ([]book.Core, error) {
+	if _, err := ps.publicUser(userID); err != nil {
+		return nil, err
+	}
+
+	res, err := ps.bd.MyBook(int(userID))
+	if err != nil {
+		log.Println("profile book query error", err.Error())
+		return nil, errors.New("terdapat masalah pada server")
+	}
+
+	return res, nil
+}
+
+// publicUser mengambil user yang boleh dilihat publik, akun yang disuspend dianggap tidak ada.
+func (ps *profileSrv) publicUser(userID uint) (user.Core, error) {
+	usr, err := ps.ud.Profile(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.Core{}, errors.New("data user not found")
+		}
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	if usr.Suspended {
+		return user.Core{}, errors.New("data user not found")
+	}
+
+	return usr, nil
+}
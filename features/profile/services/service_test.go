@@ -0,0 +1,99 @@
+package services
+
+import (
+	"api/features/book"
+	"api/features/user"
+	"api/mocks"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile(t *testing.T) {
+	ud := mocks.NewUserData(t)
+	bd := mocks.NewBookData(t)
+	srv := New(ud, bd)
+
+	joined := time.Now().Add(-48 * time.Hour)
+	t.Run("alamat dan hp disembunyikan", func(t *testing.T) {
+		ud.On("Profile", uint(1)).Return(user.Core{ID: 1, Name: "jerry", Alamat: "Jakarta", HP: "08123456", CreatedAt: joined}, nil).Once()
+		bd.On("CountByOwner", 1).Return(int64(2), nil).Once()
+
+		res, err := srv.Profile(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "jerry", res.Name)
+		assert.Equal(t, joined, res.JoinedAt)
+		assert.Equal(t, 2, res.BookCount)
+		assert.Empty(t, res.Alamat)
+		assert.Empty(t, res.HP)
+		ud.AssertExpectations(t)
+		bd.AssertExpectations(t)
+	})
+
+	t.Run("hp ditampilkan", func(t *testing.T) {
+		ud.On("Profile", uint(1)).Return(user.Core{ID: 1, Name: "jerry", Alamat: "Jakarta", HP: "08123456", ShowHP: true}, nil).Once()
+		bd.On("CountByOwner", 1).Return(int64(0), nil).Once()
+
+		res, err := srv.Profile(1)
+		assert.Nil(t, err)
+		assert.Empty(t, res.Alamat)
+		assert.Equal(t, "08123456", res.HP)
+		assert.Equal(t, 0, res.BookCount)
+		ud.AssertExpectations(t)
+		bd.AssertExpectations(t)
+	})
+
+	t.Run("user tidak ditemukan", func(t *testing.T) {
+		ud.On("Profile", uint(5)).Return(user.Core{}, errors.New("record not found")).Once()
+
+		_, err := srv.Profile(5)
+		assert.ErrorContains(t, err, "not found")
+		ud.AssertExpectations(t)
+	})
+
+	t.Run("user disuspend", func(t *testing.T) {
+		ud.On("Profile", uint(3)).Return(user.Core{ID: 3, Suspended: true}, nil).Once()
+
+		_, err := srv.Profile(3)
+		assert.ErrorContains(t, err, "not found")
+		ud.AssertExpectations(t)
+	})
+
+	t.Run("masalah server", func(t *testing.T) {
+		ud.On("Profile", uint(1)).Return(user.Core{ID: 1}, nil).Once()
+		bd.On("CountByOwner", 1).Return(int64(0), errors.New("connection refused")).Once()
+
+		_, err := srv.Profile(1)
+		assert.ErrorContains(t, err, "server")
+		ud.AssertExpectations(t)
+		bd.AssertExpectations(t)
+	})
+}
+
+func TestBooks(t *testing.T) {
+	ud := mocks.NewUserData(t)
+	bd := mocks.NewBookData(t)
+	srv := New(ud, bd)
+
+	t.Run("sukses", func(t *testing.T) {
+		ud.On("Profile", uint(1)).Return(user.Core{ID: 1, Name: "jerry"}, nil).Once()
+		bd.On("MyBook", 1).Return([]book.Core{{ID: 1, Judul: "Bumi", Pemilik: "jerry"}}, nil).Once()
+
+		res, err := srv.Books(1)
+		assert.Nil(t, err)
+		assert.Len(t, res, 1)
+		ud.AssertExpectations(t)
+		bd.AssertExpectations(t)
+	})
+
+	t.Run("user tidak ditemukan", func(t *testing.T) {
+		ud.On("Profile", uint(5)).Return(user.Core{}, errors.New("record not found")).Once()
+
+		res, err := srv.Books(5)
+		assert.ErrorContains(t, err, "not found")
+		assert.Nil(t, res)
+		ud.AssertExpectations(t)
+	})
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"api/features/book/data"
 	"api/features/user"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -10,11 +11,118 @@ import (
 type User struct {
 	gorm.Model
 	Name     string
-	Email    string
+	Email    string `gorm:"type:varchar(255);uniqueIndex"`
 	Alamat   string
 	HP       string
 	Password string
+	Role     string `gorm:"type:varchar(20);default:user"`
 	Book     []data.Books
+
+	SuspendedAt           *time.Time
+	PasswordResetRequired bool
+	EmailVerifiedAt       *time.Time
+	VerificationSentAt    *time.Time
+	MFASecret             string `gorm:"type:varchar(64)"`
+	MFAEnabled            bool
+	MFALastStep           int64
+	OIDCSubject           *string `gorm:"type:varchar(255);uniqueIndex"`
+	ShowAlamat            bool
+	ShowHP                bool
+	Avatar                string `gorm:"type:varchar(255)"`
+}
+
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex"`
+	FamilyID  string `gorm:"type:varchar(64);index"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+type PasswordReset struct {
+	gorm.Model
+	UserID    uint
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type RecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index"`
+	CodeHash  string `gorm:"type:varchar(64)"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RevokedToken adalah denylist access token (jti) yang sudah logout,
+// baris dihapus oleh CleanupExpiredTokens setelah token-nya kedaluwarsa.
+type RevokedToken struct {
+	ID        uint   `gorm:"primarykey"`
+	JTI       string `gorm:"type:varchar(64);uniqueIndex"`
+	UserID    uint
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+// Session satu perangkat yang login, terhubung ke refresh token family lewat FamilyID.
+type Session struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     uint   `gorm:"index"`
+	FamilyID   string `gorm:"type:varchar(64);uniqueIndex"`
+	UserAgent  string `gorm:"type:varchar(255)"`
+	IP         string `gorm:"type:varchar(45)"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// EmailChange permintaan ganti email yang menunggu konfirmasi dari alamat baru.
+type EmailChange struct {
+	ID              uint   `gorm:"primarykey"`
+	UserID          uint   `gorm:"index"`
+	OldEmail        string `gorm:"type:varchar(255)"`
+	NewEmail        string `gorm:"type:varchar(255)"`
+	TokenHash       string `gorm:"type:varchar(64);uniqueIndex"`
+	RevertHash      string `gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt       time.Time
+	RevertExpiresAt time.Time
+	ConfirmedAt     *time.Time
+	RevertedAt      *time.Time
+	CreatedAt       time.Time
+}
+
+// PasswordHistory hash password lama, diisi setiap kali password diganti.
+type PasswordHistory struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index"`
+	Hash      string `gorm:"type:varchar(100)"`
+	CreatedAt time.Time
+}
+
+// LoginAttempt audit setiap percobaan login, juga dipakai menghitung jeda dan lockout.
+type LoginAttempt struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index"`
+	Email     string `gorm:"type:varchar(255);index:idx_login_attempt_email"`
+	IP        string `gorm:"type:varchar(45);index:idx_login_attempt_ip"`
+	UserAgent string `gorm:"type:varchar(255)"`
+	Success   bool
+	Reason    string    `gorm:"type:varchar(20)"`
+	CreatedAt time.Time `gorm:"index:idx_login_attempt_email;index:idx_login_attempt_ip"`
+}
+
+// SecurityEvent riwayat aktivitas penting akun (login, ganti password, ganti email, dsb).
+type SecurityEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"index"`
+	Type      string    `gorm:"type:varchar(40);index"`
+	IP        string    `gorm:"type:varchar(45)"`
+	UserAgent string    `gorm:"type:varchar(255)"`
+	Detail    string    `gorm:"type:varchar(255)"`
+	CreatedAt time.Time `gorm:"index"`
 }
 
 func ToCore(data User) user.Core {
@@ -25,9 +133,47 @@ func ToCore(data User) user.Core {
 		Alamat:   data.Alamat,
 		HP:       data.HP,
 		Password: data.Password,
+		Role:     data.Role,
+
+		Suspended:             data.SuspendedAt != nil,
+		PasswordResetRequired: data.PasswordResetRequired,
+		Deleted:               data.DeletedAt.Valid,
+		DeletedAt:             data.DeletedAt.Time,
+		CreatedAt:             data.CreatedAt,
+		Verified:              data.EmailVerifiedAt != nil,
+		VerificationSentAt:    timeValue(data.VerificationSentAt),
+		MFASecret:             data.MFASecret,
+		MFAEnabled:            data.MFAEnabled,
+		MFALastStep:           data.MFALastStep,
+		OIDCSubject:           stringValue(data.OIDCSubject),
+		ShowAlamat:            data.ShowAlamat,
+		ShowHP:                data.ShowHP,
+		Avatar:                data.Avatar,
 	}
 }
 
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func ListToCore(data []User) []user.Core {
+	var dataCore []user.Core
+	for _, value := range data {
+		dataCore = append(dataCore, ToCore(value))
+	}
+	return dataCore
+}
+
 func CoreToData(data user.Core) User {
 	return User{
 		Model:    gorm.Model{ID: data.ID},
@@ -36,5 +182,150 @@ func CoreToData(data user.Core) User {
 		Alamat:   data.Alamat,
 		HP:       data.HP,
 		Password: data.Password,
+		Role:     data.Role,
+
+		OIDCSubject: stringPtr(data.OIDCSubject),
+		ShowAlamat:  data.ShowAlamat,
+		ShowHP:      data.ShowHP,
+		Avatar:      data.Avatar,
+	}
+}
+
+// stringPtr mengubah string kosong jadi NULL supaya tidak bentrok di unique index.
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func RefreshToCore(data RefreshToken) user.RefreshTokenCore {
+	return user.RefreshTokenCore{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		TokenHash: data.TokenHash,
+		FamilyID:  data.FamilyID,
+		ExpiresAt: data.ExpiresAt,
+		Used:      data.UsedAt != nil,
+		Revoked:   data.RevokedAt != nil,
+	}
+}
+
+func CoreToRefresh(data user.RefreshTokenCore) RefreshToken {
+	return RefreshToken{
+		Model:     gorm.Model{ID: data.ID},
+		UserID:    data.UserID,
+		TokenHash: data.TokenHash,
+		FamilyID:  data.FamilyID,
+		ExpiresAt: data.ExpiresAt,
+	}
+}
+
+func ResetToCore(data PasswordReset) user.PasswordResetCore {
+	return user.PasswordResetCore{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		TokenHash: data.TokenHash,
+		ExpiresAt: data.ExpiresAt,
+		Used:      data.UsedAt != nil,
+	}
+}
+
+func CoreToReset(data user.PasswordResetCore) PasswordReset {
+	return PasswordReset{
+		Model:     gorm.Model{ID: data.ID},
+		UserID:    data.UserID,
+		TokenHash: data.TokenHash,
+		ExpiresAt: data.ExpiresAt,
+	}
+}
+
+func CoreToLoginAttempt(data user.LoginAttemptCore) LoginAttempt {
+	return LoginAttempt{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		Email:     data.Email,
+		IP:        data.IP,
+		UserAgent: data.UserAgent,
+		Success:   data.Success,
+		Reason:    data.Reason,
+		CreatedAt: data.CreatedAt,
+	}
+}
+
+func SessionToCore(data Session) user.SessionCore {
+	return user.SessionCore{
+		ID:         data.ID,
+		UserID:     data.UserID,
+		FamilyID:   data.FamilyID,
+		UserAgent:  data.UserAgent,
+		IP:         data.IP,
+		CreatedAt:  data.CreatedAt,
+		LastSeenAt: data.LastSeenAt,
+		Revoked:    data.RevokedAt != nil,
+	}
+}
+
+func CoreToSession(data user.SessionCore) Session {
+	return Session{
+		ID:         data.ID,
+		UserID:     data.UserID,
+		FamilyID:   data.FamilyID,
+		UserAgent:  data.UserAgent,
+		IP:         data.IP,
+		CreatedAt:  data.CreatedAt,
+		LastSeenAt: data.LastSeenAt,
+	}
+}
+
+func EmailChangeToCore(data EmailChange) user.EmailChangeCore {
+	return user.EmailChangeCore{
+		ID:              data.ID,
+		UserID:          data.UserID,
+		OldEmail:        data.OldEmail,
+		NewEmail:        data.NewEmail,
+		TokenHash:       data.TokenHash,
+		RevertHash:      data.RevertHash,
+		ExpiresAt:       data.ExpiresAt,
+		RevertExpiresAt: data.RevertExpiresAt,
+		Confirmed:       data.ConfirmedAt != nil,
+		Reverted:        data.RevertedAt != nil,
+	}
+}
+
+func CoreToEmailChange(data user.EmailChangeCore) EmailChange {
+	return EmailChange{
+		ID:              data.ID,
+		UserID:          data.UserID,
+		OldEmail:        data.OldEmail,
+		NewEmail:        data.NewEmail,
+		TokenHash:       data.TokenHash,
+		RevertHash:      data.RevertHash,
+		ExpiresAt:       data.ExpiresAt,
+		RevertExpiresAt: data.RevertExpiresAt,
+	}
+}
+
+func SecurityEventToCore(data SecurityEvent) user.SecurityEventCore {
+	return user.SecurityEventCore{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		Type:      data.Type,
+		IP:        data.IP,
+		UserAgent: data.UserAgent,
+		Detail:    data.Detail,
+		CreatedAt: data.CreatedAt,
+	}
+}
+
+func CoreToSecurityEvent(data user.SecurityEventCore) SecurityEvent {
+	return SecurityEvent{
+		ID:        data.ID,
+		UserID:    data.UserID,
+		Type:      data.Type,
+		IP:        data.IP,
+		UserAgent: data.UserAgent,
+		Detail:    data.Detail,
+		CreatedAt: data.CreatedAt,
 	}
 }
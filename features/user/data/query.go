@@ -1,13 +1,30 @@
 package data
 
 import (
+	"api/dbutil"
+	ad "api/features/apikey/data"
+	bd "api/features/book/data"
 	"api/features/user"
 	"errors"
 	"log"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
+// loginAttemptRetention lama audit login disimpan sebelum dibersihkan CleanupExpiredTokens.
+const loginAttemptRetention = 90 * 24 * time.Hour
+
+// securityEventRetention lama riwayat keamanan disimpan sebelum dibersihkan CleanupExpiredTokens.
+const securityEventRetention = 365 * 24 * time.Hour
+
+// sessionRetention lama sesi yang sudah dicabut atau tidak aktif disimpan sebelum dibersihkan.
+const sessionRetention = 30 * 24 * time.Hour
+
+// passwordHistoryKeep jumlah maksimal hash lama yang disimpan per user.
+const passwordHistoryKeep = 24
+
 type userQuery struct {
 	db *gorm.DB
 }
@@ -32,6 +49,9 @@ func (uq *userQuery) Register(newUser user.Core) (user.Core, error) {
 	cnv := CoreToData(newUser)
 	err := uq.db.Create(&cnv).Error
 	if err != nil {
+		if isDuplicate(err) {
+			return user.Core{}, errors.New("email duplicated")
+		}
 		return user.Core{}, err
 	}
 
@@ -48,14 +68,29 @@ func (uq *userQuery) Profile(id uint) (user.Core, error) {
 
 	return ToCore(res), nil
 }
-func (uq *userQuery) Update(id uint, updateData user.Core) (user.Core, error) {
+func (uq *userQuery) Update(id uint, updateData user.Core, fields []string) (user.Core, error) {
+	if len(fields) == 0 {
+		return uq.Profile(id)
+	}
 	userModel := CoreToData(updateData)
 	userModel.ID = id
 
-	Input := uq.db.Where("id = ?", id).Updates(&userModel)
-	if Input.Error != nil {
-		log.Println("Get By ID query error", Input.Error.Error())
-		return user.Core{}, Input.Error
+	var Input *gorm.DB
+	err := uq.db.Transaction(func(tx *gorm.DB) error {
+		if hasField(fields, "Password") {
+			if err := pushPasswordHistory(tx, id); err != nil {
+				return err
+			}
+		}
+		Input = tx.Model(&User{}).Where("id = ?", id).Select(append([]string{"UpdatedAt"}, fields...)).Updates(&userModel)
+		return Input.Error
+	})
+	if err != nil {
+		log.Println("Get By ID query error", err.Error())
+		if isDuplicate(err) {
+			return user.Core{}, errors.New("email duplicated")
+		}
+		return user.Core{}, err
 	}
 	if Input.RowsAffected == 0 {
 		log.Println("Rows  update error")
@@ -65,6 +100,40 @@ func (uq *userQuery) Update(id uint, updateData user.Core) (user.Core, error) {
 	return ToCore(userModel), nil
 }
 
+func hasField(fields []string, name string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pushPasswordHistory menyimpan hash password saat ini sebelum diganti
+// dan membuang riwayat yang lebih lama dari passwordHistoryKeep.
+func pushPasswordHistory(tx *gorm.DB, id uint) error {
+	current := User{}
+	if err := tx.Select("id", "password").Where("id = ?", id).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if current.Password == "" {
+		return nil
+	}
+
+	if err := tx.Create(&PasswordHistory{UserID: id, Hash: current.Password}).Error; err != nil {
+		return err
+	}
+
+	var keep []uint
+	if err := tx.Model(&PasswordHistory{}).Where("user_id = ?", id).Order("id desc").Limit(passwordHistoryKeep).Pluck("id", &keep).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ? AND id NOT IN ?", id, keep).Delete(&PasswordHistory{}).Error
+}
+
 // Deactive implements user.UserData
 func (uq *userQuery) Deactive(id uint) (user.Core, error) {
 	users := User{}
@@ -76,10 +145,948 @@ func (uq *userQuery) Deactive(id uint) (user.Core, error) {
 		return user.Core{}, delete.Error
 	}
 
-	if delete.RowsAffected < 0 {
+	if delete.RowsAffected <= 0 {
 		log.Println("Rows affected delete error")
 		return user.Core{}, errors.New("user not found")
 	}
 
 	return ToCore(users), nil
 }
+
+func (uq *userQuery) SaveRefreshToken(newToken user.RefreshTokenCore) error {
+	cnv := CoreToRefresh(newToken)
+	if err := uq.db.Create(&cnv).Error; err != nil {
+		log.Println("save refresh token query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) GetRefreshToken(tokenHash string) (user.RefreshTokenCore, error) {
+	res := RefreshToken{}
+	if err := uq.db.Where("token_hash = ?", tokenHash).First(&res).Error; err != nil {
+		log.Println("get refresh token query error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user.RefreshTokenCore{}, errors.New("data not found")
+		}
+		return user.RefreshTokenCore{}, err
+	}
+
+	return RefreshToCore(res), nil
+}
+
+// UseRefreshToken menandai token sudah dipakai. Update bersyarat used_at IS NULL
+// supaya dua request refresh yang bersamaan tidak sama-sama lolos.
+func (uq *userQuery) UseRefreshToken(id uint) error {
+	tx := uq.db.Model(&RefreshToken{}).Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).Update("used_at", time.Now())
+	if tx.Error != nil {
+		log.Println("use refresh token query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("refresh token not found")
+	}
+
+	return nil
+}
+
+func (uq *userQuery) RevokeTokenFamily(familyID string) error {
+	now := time.Now()
+	err := uq.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		// sesi ikut berakhir bersama family-nya
+		return tx.Model(&Session{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error
+	})
+	if err != nil {
+		log.Println("revoke token family query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens mencabut semua refresh token dan sesi user, access token yang masih
+// berlaku ikut ditolak CheckToken karena sesinya sudah dicabut.
+func (uq *userQuery) RevokeUserRefreshTokens(userID uint) error {
+	if err := uq.db.Transaction(func(tx *gorm.DB) error {
+		return revokeUserTokens(tx, userID)
+	}); err != nil {
+		log.Println("revoke user refresh token query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// revokeUserTokens mencabut semua refresh token dan sesi user di dalam transaksi tx.
+func revokeUserTokens(tx *gorm.DB, userID uint) error {
+	now := time.Now()
+	if err := tx.Model(&RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error
+}
+
+func (uq *userQuery) RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	revoked := RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	if err := uq.db.Create(&revoked).Error; err != nil {
+		log.Println("revoke token query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) IsTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := uq.db.Model(&RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		log.Println("check revoked token query error", err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CleanupExpiredTokens membuang denylist dan refresh token yang sudah lewat masa berlakunya.
+func (uq *userQuery) CleanupExpiredTokens() error {
+	now := time.Now()
+	if err := uq.db.Where("expires_at < ?", now).Delete(&RevokedToken{}).Error; err != nil {
+		log.Println("cleanup revoked token query error", err.Error())
+		return err
+	}
+	if err := uq.db.Unscoped().Where("expires_at < ?", now).Delete(&RefreshToken{}).Error; err != nil {
+		log.Println("cleanup refresh token query error", err.Error())
+		return err
+	}
+	if err := uq.db.Unscoped().Where("expires_at < ?", now).Delete(&PasswordReset{}).Error; err != nil {
+		log.Println("cleanup password reset query error", err.Error())
+		return err
+	}
+	if err := uq.db.Where("last_seen_at < ?", now.Add(-sessionRetention)).Delete(&Session{}).Error; err != nil {
+		log.Println("cleanup session query error", err.Error())
+		return err
+	}
+	if err := uq.db.Where("created_at < ?", now.Add(-loginAttemptRetention)).Delete(&LoginAttempt{}).Error; err != nil {
+		log.Println("cleanup login attempt query error", err.Error())
+		return err
+	}
+	if err := uq.db.Where("created_at < ?", now.Add(-securityEventRetention)).Delete(&SecurityEvent{}).Error; err != nil {
+		log.Println("cleanup security event query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) ListUsers(filter user.UserFilter) ([]user.Core, int64, error) {
+	qry := uq.db.Model(&User{})
+	switch filter.Status {
+	case "deleted":
+		qry = qry.Unscoped().Where("deleted_at IS NOT NULL")
+	case "suspended":
+		qry = qry.Where("suspended_at IS NOT NULL")
+	case "active":
+		qry = qry.Where("suspended_at IS NULL")
+	case "all":
+		qry = qry.Unscoped()
+	}
+	if filter.Search != "" {
+		like := "%" + dbutil.EscapeLike(filter.Search) + "%"
+		qry = qry.Where("name LIKE ? OR email LIKE ?", like, like)
+	}
+	if filter.Role != "" {
+		qry = qry.Where("role = ?", filter.Role)
+	}
+	qry = qry.Session(&gorm.Session{})
+
+	var total int64
+	if err := qry.Count(&total).Error; err != nil {
+		log.Println("count users query error", err.Error())
+		return nil, 0, err
+	}
+
+	res := []User{}
+	err := qry.Order("id").Limit(filter.Limit).Offset((filter.Page - 1) * filter.Limit).Find(&res).Error
+	if err != nil {
+		log.Println("list users query error", err.Error())
+		return nil, 0, err
+	}
+
+	return ListToCore(res), total, nil
+}
+
+// GetByID sama dengan Profile tetapi ikut membaca user yang sudah di-soft delete.
+func (uq *userQuery) GetByID(id uint) (user.Core, error) {
+	res := User{}
+	if err := uq.db.Unscoped().Where("id = ?", id).First(&res).Error; err != nil {
+		log.Println("get user by id query error", err.Error())
+		return user.Core{}, err
+	}
+
+	return ToCore(res), nil
+}
+
+func (uq *userQuery) SetSuspended(id uint, suspended bool) error {
+	var suspendedAt interface{}
+	if suspended {
+		suspendedAt = time.Now()
+	}
+
+	tx := uq.db.Model(&User{}).Where("id = ?", id).Update("suspended_at", suspendedAt)
+	if tx.Error != nil {
+		log.Println("suspend user query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return uq.mustExist(id)
+	}
+
+	return nil
+}
+
+func (uq *userQuery) SetPasswordResetRequired(id uint, required bool) error {
+	tx := uq.db.Model(&User{}).Where("id = ?", id).Update("password_reset_required", required)
+	if tx.Error != nil {
+		log.Println("force reset password query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return uq.mustExist(id)
+	}
+
+	return nil
+}
+
+// mustExist membedakan update tanpa perubahan (nilai sudah sama, MySQL mengembalikan 0 rows affected)
+// dari user yang memang tidak ada.
+func (uq *userQuery) mustExist(id uint) error {
+	var count int64
+	if err := uq.db.Model(&User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		log.Println("user exists query error", err.Error())
+		return err
+	}
+	if count == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// Restore mengembalikan user yang dihapus lewat Deactive.
+func (uq *userQuery) Restore(id uint) error {
+	tx := uq.db.Unscoped().Model(&User{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if tx.Error != nil {
+		log.Println("restore user query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("deleted user not found")
+	}
+
+	return nil
+}
+
+// Purge menghapus permanen user beserta token miliknya. Buku yang masih aktif dipindah ke
+// bookOwner jika diisi, sisanya (termasuk buku yang sudah dihapus) ikut dihapus permanen.
+// Riwayat keamanan tidak dihapus, IP, user agent, dan alamat email di dalamnya dikosongkan.
+func (uq *userQuery) Purge(id, bookOwner uint) error {
+	return uq.db.Transaction(func(tx *gorm.DB) error {
+		if bookOwner > 0 && bookOwner != id {
+			var owner int64
+			if err := tx.Model(&User{}).Where("id = ?", bookOwner).Count(&owner).Error; err != nil {
+				log.Println("purge book owner query error", err.Error())
+				return err
+			}
+			if owner == 0 {
+				return errors.New("book owner not found")
+			}
+			// ISBN yang sudah dimiliki penerima dikosongkan supaya tidak bentrok di unique index (user_id, isbn)
+			var taken []string
+			if err := tx.Model(&bd.Books{}).Where("user_id = ? AND isbn IS NOT NULL", bookOwner).Pluck("isbn", &taken).Error; err != nil {
+				log.Println("purge book owner isbn query error", err.Error())
+				return err
+			}
+			if len(taken) > 0 {
+				if err := tx.Unscoped().Model(&bd.Books{}).Where("user_id = ? AND isbn IN ?", id, taken).Update("isbn", nil).Error; err != nil {
+					log.Println("purge clear isbn query error", err.Error())
+					return err
+				}
+			}
+			if err := tx.Model(&bd.Books{}).Where("user_id = ?", id).Update("user_id", bookOwner).Error; err != nil {
+				log.Println("purge reassign books query error", err.Error())
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&bd.Books{}).Error; err != nil {
+			log.Println("purge user books query error", err.Error())
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&RefreshToken{}).Error; err != nil {
+			log.Println("purge user refresh token query error", err.Error())
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&PasswordReset{}).Error; err != nil {
+			log.Println("purge user password reset query error", err.Error())
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&ad.APIKey{}).Error; err != nil {
+			log.Println("purge user api key query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&RecoveryCode{}).Error; err != nil {
+			log.Println("purge user recovery code query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&Session{}).Error; err != nil {
+			log.Println("purge user session query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&PasswordHistory{}).Error; err != nil {
+			log.Println("purge user password history query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&EmailChange{}).Error; err != nil {
+			log.Println("purge user email change query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&LoginAttempt{}).Error; err != nil {
+			log.Println("purge user login attempt query error", err.Error())
+			return err
+		}
+		// riwayat keamanan (termasuk aksi admin) tetap disimpan untuk audit, hanya data pribadinya dikosongkan
+		if err := tx.Model(&SecurityEvent{}).Where("user_id = ?", id).Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			log.Println("purge user security event query error", err.Error())
+			return err
+		}
+		emailEvents := []string{user.EventEmailChangeRequested, user.EventEmailChanged, user.EventEmailChangeReverted}
+		if err := tx.Model(&SecurityEvent{}).Where("user_id = ? AND type IN ?", id, emailEvents).Update("detail", "").Error; err != nil {
+			log.Println("purge user security event query error", err.Error())
+			return err
+		}
+		del := tx.Unscoped().Delete(&User{}, id)
+		if del.Error != nil {
+			log.Println("purge user query error", del.Error.Error())
+			return del.Error
+		}
+		if del.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		return nil
+	})
+}
+
+func (uq *userQuery) SavePasswordReset(newReset user.PasswordResetCore) error {
+	cnv := CoreToReset(newReset)
+	if err := uq.db.Create(&cnv).Error; err != nil {
+		log.Println("save password reset query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) GetPasswordReset(tokenHash string) (user.PasswordResetCore, error) {
+	res := PasswordReset{}
+	if err := uq.db.Where("token_hash = ?", tokenHash).First(&res).Error; err != nil {
+		log.Println("get password reset query error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user.PasswordResetCore{}, errors.New("data not found")
+		}
+		return user.PasswordResetCore{}, err
+	}
+
+	return ResetToCore(res), nil
+}
+
+// UsePasswordReset memakai link reset, mengganti password dan mencabut semua sesi user dalam satu
+// transaksi, sehingga link tidak hangus jika password gagal diganti dan access token lama ikut ditolak.
+func (uq *userQuery) UsePasswordReset(id, userID uint, hashed string) error {
+	return uq.db.Transaction(func(tx *gorm.DB) error {
+		use := tx.Model(&PasswordReset{}).Where("id = ? AND user_id = ? AND used_at IS NULL", id, userID).Update("used_at", time.Now())
+		if use.Error != nil {
+			log.Println("use password reset query error", use.Error.Error())
+			return use.Error
+		}
+		if use.RowsAffected == 0 {
+			return errors.New("password reset not found")
+		}
+		if err := updatePassword(tx, userID, hashed); err != nil {
+			return err
+		}
+		if err := revokeUserTokens(tx, userID); err != nil {
+			log.Println("reset password revoke token query error", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+// UpdatePassword sekaligus menghapus kewajiban reset password dari admin.
+func (uq *userQuery) UpdatePassword(id uint, hashed string) error {
+	return uq.db.Transaction(func(tx *gorm.DB) error {
+		return updatePassword(tx, id, hashed)
+	})
+}
+
+func updatePassword(tx *gorm.DB, id uint, hashed string) error {
+	if err := pushPasswordHistory(tx, id); err != nil {
+		log.Println("update password history query error", err.Error())
+		return err
+	}
+
+	upd := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":                hashed,
+		"password_reset_required": false,
+	})
+	if upd.Error != nil {
+		log.Println("update password query error", upd.Error.Error())
+		return upd.Error
+	}
+	if upd.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// SetEmailVerified hanya berhasil jika email user masih sama dengan email di link verifikasi.
+func (uq *userQuery) SetEmailVerified(id uint, email string) error {
+	res := User{}
+	if err := uq.db.Where("id = ? AND email = ?", id, email).First(&res).Error; err != nil {
+		log.Println("verify email query error", err.Error())
+		return err
+	}
+	if res.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	if err := uq.db.Model(&res).Update("email_verified_at", time.Now()).Error; err != nil {
+		log.Println("verify email query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) SetVerificationSent(id uint, sentAt time.Time) error {
+	if err := uq.db.Model(&User{}).Where("id = ?", id).Update("verification_sent_at", sentAt).Error; err != nil {
+		log.Println("verification sent query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SetMFASecret menyimpan secret yang belum aktif sampai dikonfirmasi lewat EnableMFA.
+func (uq *userQuery) SetMFASecret(id uint, secret string) error {
+	tx := uq.db.Model(&User{}).Where("id = ? AND mfa_enabled = ?", id, false).Update("mfa_secret", secret)
+	if tx.Error != nil {
+		log.Println("set mfa secret query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+func (uq *userQuery) EnableMFA(id uint, step int64, recoveryHashes []string) error {
+	return uq.db.Transaction(func(tx *gorm.DB) error {
+		upd := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"mfa_enabled":   true,
+			"mfa_last_step": step,
+		})
+		if upd.Error != nil {
+			log.Println("enable mfa query error", upd.Error.Error())
+			return upd.Error
+		}
+		if upd.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&ad.APIKey{}).Error; err != nil {
+			log.Println("purge user api key query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&RecoveryCode{}).Error; err != nil {
+			log.Println("delete recovery code query error", err.Error())
+			return err
+		}
+		codes := []RecoveryCode{}
+		for _, hash := range recoveryHashes {
+			codes = append(codes, RecoveryCode{UserID: id, CodeHash: hash})
+		}
+		if err := tx.Create(&codes).Error; err != nil {
+			log.Println("save recovery code query error", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (uq *userQuery) DisableMFA(id uint) error {
+	return uq.db.Transaction(func(tx *gorm.DB) error {
+		upd := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"mfa_enabled":   false,
+			"mfa_secret":    "",
+			"mfa_last_step": 0,
+		})
+		if upd.Error != nil {
+			log.Println("disable mfa query error", upd.Error.Error())
+			return upd.Error
+		}
+		if upd.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&ad.APIKey{}).Error; err != nil {
+			log.Println("purge user api key query error", err.Error())
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&RecoveryCode{}).Error; err != nil {
+			log.Println("delete recovery code query error", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+// SetMFALastStep gagal jika step sudah pernah dipakai untuk mencegah replay kode TOTP, dan ikut berlaku
+// untuk akun dalam masa tenggang penghapusan karena login 2FA mengaktifkannya kembali.
+func (uq *userQuery) SetMFALastStep(id uint, step int64) error {
+	tx := uq.db.Unscoped().Model(&User{}).Where("id = ? AND mfa_last_step < ?", id, step).Update("mfa_last_step", step)
+	if tx.Error != nil {
+		log.Println("set mfa step query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("mfa step not found")
+	}
+
+	return nil
+}
+
+func (uq *userQuery) UseRecoveryCode(id uint, codeHash string) error {
+	tx := uq.db.Model(&RecoveryCode{}).Where("user_id = ? AND code_hash = ? AND used_at IS NULL", id, codeHash).Update("used_at", time.Now())
+	if tx.Error != nil {
+		log.Println("use recovery code query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("recovery code not found")
+	}
+
+	return nil
+}
+
+func (uq *userQuery) GetByOIDCSubject(subject string) (user.Core, error) {
+	res := User{}
+	if err := uq.db.Where("oidc_subject = ?", subject).First(&res).Error; err != nil {
+		log.Println("get by oidc subject query error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user.Core{}, errors.New("data not found")
+		}
+		return user.Core{}, err
+	}
+
+	return ToCore(res), nil
+}
+
+// LinkOIDC hanya menautkan akun yang belum punya identitas OIDC.
+func (uq *userQuery) LinkOIDC(id uint, subject string) error {
+	tx := uq.db.Model(&User{}).Where("id = ? AND oidc_subject IS NULL", id).Update("oidc_subject", subject)
+	if tx.Error != nil {
+		log.Println("link oidc query error", tx.Error.Error())
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+func (uq *userQuery) RecordLoginAttempt(attempt user.LoginAttemptCore) error {
+	cnv := CoreToLoginAttempt(attempt)
+	if err := uq.db.Create(&cnv).Error; err != nil {
+		log.Println("record login attempt query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// AccountLoginFailures menghitung kegagalan untuk email sejak since,
+// login sukses terakhir mereset hitungan.
+func (uq *userQuery) AccountLoginFailures(email string, since time.Time) (user.LoginFailureCore, error) {
+	last := LoginAttempt{}
+	err := uq.db.Where("email = ? AND success = ? AND created_at > ?", email, true, since).Order("created_at desc").Limit(1).Find(&last).Error
+	if err != nil {
+		log.Println("account login failures query error", err.Error())
+		return user.LoginFailureCore{}, err
+	}
+	if last.ID != 0 {
+		since = last.CreatedAt
+	}
+
+	return uq.loginFailures(uq.db.Where("email = ?", email), since)
+}
+
+// IPLoginFailures tidak direset login sukses, supaya satu akun valid tidak bisa
+// dipakai membuka jeda untuk menebak password akun lain dari IP yang sama.
+func (uq *userQuery) IPLoginFailures(ip string, since time.Time) (user.LoginFailureCore, error) {
+	return uq.loginFailures(uq.db.Where("ip = ?", ip), since)
+}
+
+func (uq *userQuery) loginFailures(scope *gorm.DB, since time.Time) (user.LoginFailureCore, error) {
+	var row struct {
+		Count int64
+		Last  *time.Time
+	}
+	err := scope.Model(&LoginAttempt{}).Select("COUNT(*) AS count, MAX(created_at) AS last").
+		Where("success = ? AND reason IN ? AND created_at > ?", false, user.LoginFailureReasons, since).
+		Scan(&row).Error
+	if err != nil {
+		log.Println("login failures query error", err.Error())
+		return user.LoginFailureCore{}, err
+	}
+
+	return user.LoginFailureCore{Count: row.Count, Last: timeValue(row.Last)}, nil
+}
+
+// RecentPasswords mengembalikan hash password saat ini dan n-1 hash sebelumnya.
+func (uq *userQuery) RecentPasswords(id uint, n int) ([]string, error) {
+	current := User{}
+	if err := uq.db.Select("id", "password").Where("id = ?", id).First(&current).Error; err != nil {
+		log.Println("recent passwords query error", err.Error())
+		return nil, err
+	}
+	hashes := []string{current.Password}
+	if n <= 1 {
+		return hashes, nil
+	}
+
+	var old []string
+	if err := uq.db.Model(&PasswordHistory{}).Where("user_id = ?", id).Order("id desc").Limit(n-1).Pluck("hash", &old).Error; err != nil {
+		log.Println("recent passwords query error", err.Error())
+		return nil, err
+	}
+
+	return append(hashes, old...), nil
+}
+
+func (uq *userQuery) SaveSession(newSession user.SessionCore) (user.SessionCore, error) {
+	cnv := CoreToSession(newSession)
+	cnv.UserAgent = truncate(cnv.UserAgent, 255)
+	if err := uq.db.Create(&cnv).Error; err != nil {
+		log.Println("save session query error", err.Error())
+		return user.SessionCore{}, err
+	}
+
+	return SessionToCore(cnv), nil
+}
+
+func (uq *userQuery) GetSession(id uint) (user.SessionCore, error) {
+	res := Session{}
+	if err := uq.db.Where("id = ?", id).First(&res).Error; err != nil {
+		log.Println("get session query error", err.Error())
+		return user.SessionCore{}, errors.New("session not found")
+	}
+
+	return SessionToCore(res), nil
+}
+
+func (uq *userQuery) GetSessionByFamily(familyID string) (user.SessionCore, error) {
+	res := Session{}
+	if err := uq.db.Where("family_id = ?", familyID).First(&res).Error; err != nil {
+		log.Println("get session query error", err.Error())
+		return user.SessionCore{}, errors.New("session not found")
+	}
+
+	return SessionToCore(res), nil
+}
+
+// ListSessions sesi yang belum dicabut dan masih aktif sejak since, terbaru dulu.
+func (uq *userQuery) ListSessions(userID uint, since time.Time) ([]user.SessionCore, error) {
+	res := []Session{}
+	err := uq.db.Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, since).Order("last_seen_at desc").Find(&res).Error
+	if err != nil {
+		log.Println("list session query error", err.Error())
+		return nil, err
+	}
+
+	sessions := []user.SessionCore{}
+	for _, val := range res {
+		sessions = append(sessions, SessionToCore(val))
+	}
+	return sessions, nil
+}
+
+func (uq *userQuery) TouchSession(id uint, client user.ClientInfo, at time.Time) error {
+	upd := map[string]interface{}{"last_seen_at": at}
+	if client.IP != "" {
+		upd["ip"] = client.IP
+	}
+	if client.UserAgent != "" {
+		upd["user_agent"] = truncate(client.UserAgent, 255)
+	}
+	if err := uq.db.Model(&Session{}).Where("id = ?", id).Updates(upd).Error; err != nil {
+		log.Println("touch session query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// RevokeSession mencabut sesi milik userID beserta refresh token family-nya.
+func (uq *userQuery) RevokeSession(userID, id uint) error {
+	return uq.db.Transaction(func(tx *gorm.DB) error {
+		res := Session{}
+		if err := tx.Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).First(&res).Error; err != nil {
+			log.Println("revoke session query error", err.Error())
+			return errors.New("session not found")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&res).Update("revoked_at", now).Error; err != nil {
+			log.Println("revoke session query error", err.Error())
+			return err
+		}
+		if err := tx.Model(&RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", res.FamilyID).Update("revoked_at", now).Error; err != nil {
+			log.Println("revoke session refresh token query error", err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// GetDeactivated mencari akun yang sudah dihapus lewat Deactive tetapi belum dihapus permanen.
+func (uq *userQuery) GetDeactivated(email string) (user.Core, error) {
+	res := User{}
+	if err := uq.db.Unscoped().Where("email = ? AND deleted_at IS NOT NULL", email).First(&res).Error; err != nil {
+		log.Println("get deactivated user query error", err.Error())
+		return user.Core{}, errors.New("data not found")
+	}
+
+	return ToCore(res), nil
+}
+
+// DeactivatedBefore id akun yang dihapus sebelum waktu tertentu, siap dihapus permanen.
+func (uq *userQuery) DeactivatedBefore(before time.Time) ([]uint, error) {
+	var ids []uint
+	err := uq.db.Unscoped().Model(&User{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Order("id").Pluck("id", &ids).Error
+	if err != nil {
+		log.Println("deactivated users query error", err.Error())
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (uq *userQuery) OwnedBooks(userID uint) ([]user.OwnedBookCore, error) {
+	books := []bd.Books{}
+	if err := uq.db.Where("user_id = ?", userID).Order("id").Find(&books).Error; err != nil {
+		log.Println("owned books query error", err.Error())
+		return nil, err
+	}
+
+	res := []user.OwnedBookCore{}
+	for _, v := range books {
+		res = append(res, user.OwnedBookCore{
+			ID:          v.ID,
+			Judul:       v.Judul,
+			TahunTerbit: v.TahunTerbit,
+			Penulis:     v.Penulis,
+			ISBN:        stringValue(v.ISBN),
+			Penerbit:    v.Penerbit,
+			Halaman:     v.Halaman,
+			CoverURL:    v.CoverURL,
+			CreatedAt:   v.CreatedAt,
+			UpdatedAt:   v.UpdatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// isDuplicate true untuk pelanggaran unique index MySQL (error 1062).
+func isDuplicate(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1062
+}
+
+// SaveEmailChange menyimpan permintaan ganti email baru dan membatalkan permintaan lain yang belum dikonfirmasi.
+func (uq *userQuery) SaveEmailChange(change user.EmailChangeCore) error {
+	cnv := CoreToEmailChange(change)
+	err := uq.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND confirmed_at IS NULL AND reverted_at IS NULL", change.UserID).Delete(&EmailChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&cnv).Error
+	})
+	if err != nil {
+		log.Println("save email change query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) GetEmailChange(tokenHash string) (user.EmailChangeCore, error) {
+	res := EmailChange{}
+	if err := uq.db.Where("token_hash = ?", tokenHash).First(&res).Error; err != nil {
+		log.Println("get email change query error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user.EmailChangeCore{}, errors.New("data not found")
+		}
+		return user.EmailChangeCore{}, err
+	}
+
+	return EmailChangeToCore(res), nil
+}
+
+func (uq *userQuery) GetEmailChangeByRevert(revertHash string) (user.EmailChangeCore, error) {
+	res := EmailChange{}
+	if err := uq.db.Where("revert_hash = ?", revertHash).First(&res).Error; err != nil {
+		log.Println("get email change query error", err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user.EmailChangeCore{}, errors.New("data not found")
+		}
+		return user.EmailChangeCore{}, err
+	}
+
+	return EmailChangeToCore(res), nil
+}
+
+// ConfirmEmailChange memindahkan email user ke alamat baru, hanya jika email user masih sama
+// dengan saat permintaan dibuat. Alamat baru langsung dianggap terverifikasi.
+func (uq *userQuery) ConfirmEmailChange(id uint) error {
+	err := uq.db.Transaction(func(tx *gorm.DB) error {
+		change := EmailChange{}
+		if err := tx.Where("id = ? AND confirmed_at IS NULL AND reverted_at IS NULL", id).First(&change).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("email change not found")
+			}
+			return err
+		}
+
+		now := time.Now()
+		upd := tx.Model(&User{}).Where("id = ? AND email = ?", change.UserID, change.OldEmail).Updates(map[string]interface{}{
+			"email":             change.NewEmail,
+			"email_verified_at": now,
+		})
+		if upd.Error != nil {
+			return upd.Error
+		}
+		if upd.RowsAffected == 0 {
+			return errors.New("email change not found")
+		}
+
+		return tx.Model(&change).Update("confirmed_at", now).Error
+	})
+	if err != nil {
+		log.Println("confirm email change query error", err.Error())
+		if isDuplicate(err) {
+			return errors.New("email duplicated")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RevertEmailChange membatalkan permintaan yang belum dikonfirmasi, atau mengembalikan
+// email lama jika sudah terlanjur dikonfirmasi.
+func (uq *userQuery) RevertEmailChange(id uint) error {
+	err := uq.db.Transaction(func(tx *gorm.DB) error {
+		change := EmailChange{}
+		if err := tx.Where("id = ? AND reverted_at IS NULL", id).First(&change).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("email change not found")
+			}
+			return err
+		}
+
+		if change.ConfirmedAt != nil {
+			upd := tx.Unscoped().Model(&User{}).Where("id = ? AND email = ?", change.UserID, change.NewEmail).Update("email", change.OldEmail)
+			if upd.Error != nil {
+				return upd.Error
+			}
+			if upd.RowsAffected == 0 {
+				return errors.New("email change not found")
+			}
+		}
+
+		return tx.Model(&change).Update("reverted_at", time.Now()).Error
+	})
+	if err != nil {
+		log.Println("revert email change query error", err.Error())
+		if isDuplicate(err) {
+			return errors.New("email duplicated")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) SaveSecurityEvent(event user.SecurityEventCore) error {
+	cnv := CoreToSecurityEvent(event)
+	if err := uq.db.Create(&cnv).Error; err != nil {
+		log.Println("save security event query error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (uq *userQuery) ListSecurityEvents(filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error) {
+	qry := uq.db.Model(&SecurityEvent{})
+	if filter.UserID > 0 {
+		qry = qry.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Type != "" {
+		qry = qry.Where("type = ?", filter.Type)
+	}
+	if !filter.From.IsZero() {
+		qry = qry.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		qry = qry.Where("created_at < ?", filter.To)
+	}
+	qry = qry.Session(&gorm.Session{})
+
+	var total int64
+	if err := qry.Count(&total).Error; err != nil {
+		log.Println("count security events query error", err.Error())
+		return nil, 0, err
+	}
+
+	res := []SecurityEvent{}
+	err := qry.Order("created_at desc, id desc").Limit(filter.Limit).Offset((filter.Page - 1) * filter.Limit).Find(&res).Error
+	if err != nil {
+		log.Println("list security events query error", err.Error())
+		return nil, 0, err
+	}
+
+	events := make([]user.SecurityEventCore, 0, len(res))
+	for _, v := range res {
+		events = append(events, SecurityEventToCore(v))
+	}
+	return events, total, nil
+}
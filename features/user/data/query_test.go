@@ -0,0 +1,107 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gormysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// fakeDB database palsu yang mencatat query exec dan mengembalikan rows yang disiapkan untuk query select.
+type fakeDB struct {
+	execs   []string
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.execs = append(c.db.execs, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{columns: c.db.columns, rows: c.db.rows}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openFake(t *testing.T, db *fakeDB) *userQuery {
+	conn, err := gorm.Open(gormysql.New(gormysql.Config{Conn: sql.OpenDB(db), SkipInitializeWithVersion: true}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &userQuery{db: conn}
+}
+
+func TestPurge(t *testing.T) {
+	db := &fakeDB{}
+	qry := openFake(t, db)
+
+	assert.Nil(t, qry.Purge(5, 0))
+
+	executed := strings.Join(db.execs, "\n")
+	assert.Contains(t, executed, "DELETE FROM `api_keys` WHERE user_id = ?")
+	assert.Contains(t, executed, "DELETE FROM `users` WHERE `users`.`id` = ?")
+	// riwayat keamanan hanya dianonimkan, tidak dihapus
+	assert.NotContains(t, executed, "DELETE FROM `security_events`")
+	assert.Contains(t, executed, "UPDATE `security_events`")
+}
+
+func TestOwnedBooks(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	db := &fakeDB{
+		columns: []string{"id", "created_at", "updated_at", "deleted_at", "judul", "tahun_terbit", "penulis", "isbn", "penerbit", "halaman", "cover_url", "user_id"},
+		rows: [][]driver.Value{
+			{int64(1), created, updated, nil, "Naruto", int64(1999), "Masashi Kishimoto", "9780306406157", "Shueisha", int64(192), "https://example.com/naruto.jpg", int64(5)},
+			{int64(2), created, created, nil, "Bleach", int64(2001), "Tite Kubo", nil, "", int64(0), "", int64(5)},
+		},
+	}
+	qry := openFake(t, db)
+
+	res, err := qry.OwnedBooks(5)
+	assert.Nil(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "9780306406157", res[0].ISBN)
+	assert.Equal(t, "Shueisha", res[0].Penerbit)
+	assert.Equal(t, 192, res[0].Halaman)
+	assert.Equal(t, "https://example.com/naruto.jpg", res[0].CoverURL)
+	assert.Equal(t, updated, res[0].UpdatedAt)
+	assert.Empty(t, res[1].ISBN)
+}
@@ -1,6 +1,13 @@
 package user
 
-import "github.com/labstack/echo/v4"
+import (
+	"api/auth"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
 
 type Core struct {
 	ID       uint
@@ -9,6 +16,261 @@ type Core struct {
 	Alamat   string
 	HP       string
 	Password string
+	Role     string
+
+	Suspended             bool
+	PasswordResetRequired bool
+	Deleted               bool
+	DeletedAt             time.Time
+	CreatedAt             time.Time
+	Verified              bool
+	VerificationSentAt    time.Time
+	MFASecret             string
+	MFAEnabled            bool
+	MFALastStep           int64
+	OIDCSubject           string
+	// ShowAlamat dan ShowHP menentukan apakah alamat dan hp tampil di profil publik.
+	ShowAlamat bool
+	ShowHP     bool
+	// Avatar prefix key blob avatar, file setiap ukuran ada di <Avatar>/<ukuran>.jpg. Kosong jika belum upload.
+	Avatar string
+}
+
+// CheckStatus menolak akun yang ditangguhkan admin, wajib reset password, atau emailnya belum diverifikasi.
+// Dipakai semua jalur autentikasi (login, JWT, API key) supaya aturannya sama.
+func (c Core) CheckStatus() error {
+	if c.Suspended {
+		return errors.New("akses ditolak, akun sedang ditangguhkan")
+	}
+	if c.PasswordResetRequired {
+		return errors.New("akses ditolak, password harus direset melalui lupa password")
+	}
+	if !c.Verified {
+		return errors.New("akses ditolak, email belum diverifikasi")
+	}
+	return nil
+}
+
+// AvatarSizes ukuran thumbnail avatar (persegi, pixel) yang dibuat saat upload.
+var AvatarSizes = []int{64, 128, 256}
+
+// AvatarKey key blob avatar dengan ukuran size untuk prefix Core.Avatar.
+func AvatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", prefix, size)
+}
+
+// EmailChangeCore permintaan ganti email. Email baru baru dipakai setelah link di TokenHash
+// dikonfirmasi, sedangkan link di RevertHash dikirim ke email lama untuk membatalkan atau mengembalikan.
+type EmailChangeCore struct {
+	ID              uint
+	UserID          uint
+	OldEmail        string
+	NewEmail        string
+	TokenHash       string
+	RevertHash      string
+	ExpiresAt       time.Time
+	RevertExpiresAt time.Time
+	Confirmed       bool
+	Reverted        bool
+}
+
+// OwnedBookCore buku milik user, dipakai untuk export data akun.
+type OwnedBookCore struct {
+	ID          uint
+	Judul       string
+	TahunTerbit int
+	Penulis     string
+	ISBN        string
+	Penerbit    string
+	Halaman     int
+	CoverURL    string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ExportCore seluruh data akun yang bisa diunduh user sebelum menghapus akunnya.
+type ExportCore struct {
+	User       Core
+	Books      []OwnedBookCore
+	ExportedAt time.Time
+}
+
+// UserFilter dipakai admin untuk mencari user, Status: active, suspended, deleted atau all.
+type UserFilter struct {
+	Search string
+	Role   string
+	Status string
+	Page   int
+	Limit  int
+}
+
+// Normalize mengisi page/limit default dan membatasi limit maksimal 100.
+func (f *UserFilter) Normalize() {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	} else if f.Limit > 100 {
+		f.Limit = 100
+	}
+}
+
+// TokenCore hasil login. Untuk akun dengan 2FA hanya MFAToken yang terisi,
+// access token baru didapat dari LoginMFA.
+type TokenCore struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	MFAToken     string
+}
+
+type MFAEnrollCore struct {
+	Secret string
+	URI    string
+}
+
+type RefreshTokenCore struct {
+	ID        uint
+	UserID    uint
+	TokenHash string
+	FamilyID  string
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+type PasswordResetCore struct {
+	ID        uint
+	UserID    uint
+	TokenHash string
+	ExpiresAt time.Time
+	Used      bool
+}
+
+// ClientInfo perangkat yang melakukan request, disimpan di audit login dan sesi.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionCore satu perangkat yang sedang login, sama dengan satu refresh token family.
+type SessionCore struct {
+	ID         uint
+	UserID     uint
+	FamilyID   string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	Revoked    bool
+	Current    bool
+}
+
+// Alasan pada audit login. Hanya LoginFailureReasons yang dihitung untuk jeda dan lockout.
+const (
+	LoginSuccess       = "success"
+	LoginUnknownEmail  = "unknown_email"
+	LoginWrongPassword = "wrong_password"
+	LoginMFAFailed     = "mfa_failed"
+	LoginMFAPending    = "mfa_pending"
+	LoginStatusDenied  = "status_denied"
+	LoginThrottled     = "throttled"
+)
+
+var LoginFailureReasons = []string{LoginUnknownEmail, LoginWrongPassword, LoginMFAFailed}
+
+type LoginAttemptCore struct {
+	ID        uint
+	UserID    uint
+	Email     string
+	IP        string
+	UserAgent string
+	Success   bool
+	Reason    string
+	// Method cara login selain password (mis. "oidc"), hanya dicatat sebagai detail security event.
+	Method    string
+	CreatedAt time.Time
+}
+
+// Jenis security event yang bisa dilihat pemilik akun dan admin.
+const (
+	EventLoginSuccess         = "login_success"
+	EventLoginFailed          = "login_failed"
+	EventLogout               = "logout"
+	EventSessionRevoked       = "session_revoked"
+	EventRefreshTokenReuse    = "refresh_token_reuse"
+	EventPasswordChanged      = "password_changed"
+	EventPasswordReset        = "password_reset"
+	EventEmailChangeRequested = "email_change_requested"
+	EventEmailChanged         = "email_changed"
+	EventEmailChangeReverted  = "email_change_reverted"
+	EventMFAEnabled           = "mfa_enabled"
+	EventMFADisabled          = "mfa_disabled"
+	EventDeactivated          = "deactivated"
+	EventReactivated          = "reactivated"
+	// aksi admin, Detail berisi id admin yang melakukannya
+	EventSuspended           = "suspended"
+	EventUnsuspended         = "unsuspended"
+	EventPasswordResetForced = "password_reset_forced"
+	EventRestored            = "restored"
+	EventPurged              = "purged"
+)
+
+var SecurityEventTypes = []string{
+	EventLoginSuccess, EventLoginFailed, EventLogout, EventSessionRevoked, EventRefreshTokenReuse,
+	EventPasswordChanged, EventPasswordReset, EventEmailChangeRequested, EventEmailChanged,
+	EventEmailChangeReverted, EventMFAEnabled, EventMFADisabled, EventDeactivated, EventReactivated,
+	EventSuspended, EventUnsuspended, EventPasswordResetForced, EventRestored, EventPurged,
+}
+
+func ValidEventType(eventType string) bool {
+	for _, val := range SecurityEventTypes {
+		if val == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// SecurityEventCore satu baris riwayat keamanan akun, Detail berisi keterangan tambahan
+// seperti alasan login gagal atau email baru.
+type SecurityEventCore struct {
+	ID        uint
+	UserID    uint
+	Type      string
+	IP        string
+	UserAgent string
+	Detail    string
+	CreatedAt time.Time
+}
+
+// SecurityEventFilter filter riwayat keamanan, UserID 0 berarti semua user (khusus admin).
+type SecurityEventFilter struct {
+	UserID uint
+	Type   string
+	From   time.Time
+	To     time.Time
+	Page   int
+	Limit  int
+}
+
+// Normalize mengisi page/limit default dan membatasi limit maksimal 100.
+func (f *SecurityEventFilter) Normalize() {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = 20
+	} else if f.Limit > 100 {
+		f.Limit = 100
+	}
+}
+
+// LoginFailureCore jumlah percobaan login gagal dalam satu window dan waktu gagal terakhir.
+type LoginFailureCore struct {
+	Count int64
+	Last  time.Time
 }
 
 type UserHandler interface {
@@ -17,20 +279,119 @@ type UserHandler interface {
 	Profile() echo.HandlerFunc
 	Deactive() echo.HandlerFunc
 	Update() echo.HandlerFunc
+	Patch() echo.HandlerFunc
+	Refresh() echo.HandlerFunc
+	Logout() echo.HandlerFunc
+	ForgotPassword() echo.HandlerFunc
+	ResetPassword() echo.HandlerFunc
+	VerifyEmail() echo.HandlerFunc
+	ResendVerification() echo.HandlerFunc
+	LoginMFA() echo.HandlerFunc
+	EnrollMFA() echo.HandlerFunc
+	ConfirmMFA() echo.HandlerFunc
+	DisableMFA() echo.HandlerFunc
+	OIDCLogin() echo.HandlerFunc
+	OIDCCallback() echo.HandlerFunc
+	Sessions() echo.HandlerFunc
+	RevokeSession() echo.HandlerFunc
+	Export() echo.HandlerFunc
+	ChangeEmail() echo.HandlerFunc
+	ConfirmEmailChangePage() echo.HandlerFunc
+	ConfirmEmailChange() echo.HandlerFunc
+	RevertEmailChangePage() echo.HandlerFunc
+	RevertEmailChange() echo.HandlerFunc
+	UploadAvatar() echo.HandlerFunc
+	Avatar() echo.HandlerFunc
+	DeleteAvatar() echo.HandlerFunc
+	SecurityEvents() echo.HandlerFunc
 }
 
 type UserService interface {
-	Login(email, password string) (string, Core, error)
+	Login(email, password string, client ClientInfo) (TokenCore, Core, error)
 	Register(newUser Core) (Core, error)
-	Profile(token interface{}) (Core, error)
-	Update(token interface{}, updateData Core) (Core, error)
-	Deactive(token interface{}) (Core, error)
+	Profile(p auth.Principal) (Core, error)
+	Update(p auth.Principal, updateData Core) (Core, error)
+	Patch(p auth.Principal, patch []byte) (Core, error)
+	Deactive(p auth.Principal) (Core, error)
+	Refresh(refreshToken string, client ClientInfo) (TokenCore, error)
+	Logout(p auth.Principal, refreshToken string) error
+	CheckToken(p auth.Principal) error
+	ForgotPassword(email string) error
+	ResetPassword(resetToken, newPassword string, client ClientInfo) error
+	VerifyEmail(verifyToken string) error
+	ResendVerification(email string) error
+	LoginMFA(mfaToken, code string, client ClientInfo) (TokenCore, Core, error)
+	EnrollMFA(p auth.Principal) (MFAEnrollCore, error)
+	ConfirmMFA(p auth.Principal, code string) ([]string, error)
+	DisableMFA(p auth.Principal, code string) error
+	OIDCStart() (authURL string, stateToken string, err error)
+	OIDCCallback(stateToken, state, code string, client ClientInfo) (TokenCore, Core, error)
+	Sessions(p auth.Principal) ([]SessionCore, error)
+	RevokeSession(p auth.Principal, sessionID uint) error
+	Export(p auth.Principal) (ExportCore, error)
+	PurgeDeactivated() (int, error)
+	RequestEmailChange(p auth.Principal, newEmail, password string) error
+	ConfirmEmailChange(confirmToken string, client ClientInfo) error
+	RevertEmailChange(revertToken string, client ClientInfo) error
+	UploadAvatar(p auth.Principal, data []byte) (Core, error)
+	Avatar(userID uint, size int) ([]byte, string, error)
+	DeleteAvatar(p auth.Principal) error
+	SecurityEvents(p auth.Principal, filter SecurityEventFilter) ([]SecurityEventCore, int64, error)
 }
 
 type UserData interface {
 	Login(email string) (Core, error)
 	Register(newUser Core) (Core, error)
 	Profile(id uint) (Core, error)
-	Update(id uint, updateData Core) (Core, error)
+	// Update hanya menulis field Core yang disebut di fields, nilai kosong ikut ditulis.
+	Update(id uint, updateData Core, fields []string) (Core, error)
 	Deactive(id uint) (Core, error)
+	SaveRefreshToken(newToken RefreshTokenCore) error
+	GetRefreshToken(tokenHash string) (RefreshTokenCore, error)
+	UseRefreshToken(id uint) error
+	RevokeTokenFamily(familyID string) error
+	RevokeUserRefreshTokens(userID uint) error
+	RevokeToken(jti string, userID uint, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+	CleanupExpiredTokens() error
+	ListUsers(filter UserFilter) ([]Core, int64, error)
+	GetByID(id uint) (Core, error)
+	SetSuspended(id uint, suspended bool) error
+	SetPasswordResetRequired(id uint, required bool) error
+	Restore(id uint) error
+	Purge(id, bookOwner uint) error
+	GetDeactivated(email string) (Core, error)
+	DeactivatedBefore(before time.Time) ([]uint, error)
+	OwnedBooks(userID uint) ([]OwnedBookCore, error)
+	SaveEmailChange(change EmailChangeCore) error
+	GetEmailChange(tokenHash string) (EmailChangeCore, error)
+	GetEmailChangeByRevert(revertHash string) (EmailChangeCore, error)
+	ConfirmEmailChange(id uint) error
+	RevertEmailChange(id uint) error
+	SavePasswordReset(newReset PasswordResetCore) error
+	GetPasswordReset(tokenHash string) (PasswordResetCore, error)
+	// UsePasswordReset memakai link reset, mengganti password dan mencabut semua sesi dalam satu transaksi.
+	UsePasswordReset(id, userID uint, hashed string) error
+	UpdatePassword(id uint, hashed string) error
+	SetEmailVerified(id uint, email string) error
+	SetVerificationSent(id uint, sentAt time.Time) error
+	SetMFASecret(id uint, secret string) error
+	EnableMFA(id uint, step int64, recoveryHashes []string) error
+	DisableMFA(id uint) error
+	SetMFALastStep(id uint, step int64) error
+	UseRecoveryCode(id uint, codeHash string) error
+	GetByOIDCSubject(subject string) (Core, error)
+	LinkOIDC(id uint, subject string) error
+	RecordLoginAttempt(attempt LoginAttemptCore) error
+	AccountLoginFailures(email string, since time.Time) (LoginFailureCore, error)
+	IPLoginFailures(ip string, since time.Time) (LoginFailureCore, error)
+	RecentPasswords(id uint, n int) ([]string, error)
+	SaveSession(newSession SessionCore) (SessionCore, error)
+	GetSession(id uint) (SessionCore, error)
+	GetSessionByFamily(familyID string) (SessionCore, error)
+	ListSessions(userID uint, since time.Time) ([]SessionCore, error)
+	TouchSession(id uint, client ClientInfo, at time.Time) error
+	RevokeSession(userID, id uint) error
+	SaveSecurityEvent(event SecurityEventCore) error
+	ListSecurityEvents(filter SecurityEventFilter) ([]SecurityEventCore, int64, error)
 }
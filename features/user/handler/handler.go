@@ -1,8 +1,15 @@
 package handler
 
 import (
+	"api/config"
+	adminhdl "api/features/admin/handler"
 	"api/features/user"
+	"api/helper"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -17,6 +24,11 @@ func New(srv user.UserService) user.UserHandler {
 	}
 }
 
+// clientInfo perangkat yang melakukan request, dicatat di sesi login.
+func clientInfo(c echo.Context) user.ClientInfo {
+	return user.ClientInfo{IP: c.RealIP(), UserAgent: c.Request().UserAgent()}
+}
+
 func (uc *userControll) Login() echo.HandlerFunc {
 	return func(c echo.Context) error {
 		input := LoginRequest{}
@@ -24,12 +36,31 @@ func (uc *userControll) Login() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, "format inputan salah")
 		}
 
-		token, res, err := uc.srv.Login(input.Email, input.Password)
+		token, res, err := uc.srv.Login(input.Email, input.Password, clientInfo(c))
 		if err != nil {
-			return c.JSON(PrintErrorResponse(err.Error()))
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+		if token.MFAToken != "" {
+			return c.JSON(PrintSuccessReponse(http.StatusOK, "masukkan kode 2FA", MFAPendingResponse{MFAToken: token.MFAToken}))
 		}
 
-		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil login", res, token))
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil login", ToResponse(res), ToTokenResponse(token)))
+	}
+}
+
+func (uc *userControll) Refresh() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := RefreshRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		token, err := uc.srv.Refresh(input.RefreshToken, clientInfo(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil refresh token", ToTokenResponse(token)))
 	}
 }
 func (uc *userControll) Register() echo.HandlerFunc {
@@ -41,22 +72,20 @@ func (uc *userControll) Register() echo.HandlerFunc {
 
 		res, err := uc.srv.Register(*ToCore(input))
 		if err != nil {
-			return c.JSON(PrintErrorResponse(err.Error()))
+			return c.JSON(printError(err))
 		}
 
-		return c.JSON(PrintSuccessReponse(http.StatusCreated, "berhasil mendaftar", res))
+		return c.JSON(PrintSuccessReponse(http.StatusCreated, "berhasil mendaftar, cek email untuk verifikasi akun", ToResponse(res)))
 	}
 }
 func (uc *userControll) Profile() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		token := c.Get("user")
-
-		res, err := uc.srv.Profile(token)
+		res, err := uc.srv.Profile(helper.Principal(c))
 		if err != nil {
-			return c.JSON(PrintErrorResponse(err.Error()))
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
 		}
 
-		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil lihat profil", res))
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil lihat profil", ToResponse(res)))
 	}
 }
 
@@ -69,13 +98,29 @@ func (uc *userControll) Update() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, "format inputan salah")
 		}
 		dataCore := *ToCore(input)
-		res, err := uc.srv.Update(c.Get("user"), dataCore)
+		res, err := uc.srv.Update(helper.Principal(c), dataCore)
 
 		if err != nil {
-			return c.JSON(PrintErrorResponse(err.Error()))
+			return c.JSON(printError(err))
 		}
 
-		return c.JSON(PrintSuccessReponse(http.StatusCreated, "berhasil updates", res))
+		return c.JSON(PrintSuccessReponse(http.StatusCreated, "berhasil updates", ToResponse(res)))
+	}
+}
+
+func (uc *userControll) Patch() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		patch, err := helper.ReadMergePatch(c.Request())
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, err := uc.srv.Patch(helper.Principal(c), patch)
+		if err != nil {
+			return c.JSON(printError(err))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil update", ToResponse(res)))
 	}
 }
 
@@ -83,12 +128,376 @@ func (uc *userControll) Update() echo.HandlerFunc {
 func (uc *userControll) Deactive() echo.HandlerFunc {
 	return func(c echo.Context) error {
 
-		res, err := uc.srv.Deactive(c.Get("user"))
+		res, err := uc.srv.Deactive(helper.Principal(c))
 
 		if err != nil {
-			return c.JSON(PrintErrorResponse(err.Error()))
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
 		}
 		result := ToResponse(res)
-		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil hapus", result))
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil hapus, login kembali sebelum masa tenggang berakhir untuk membatalkan", result))
+	}
+}
+
+func (uc *userControll) Logout() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := RefreshRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		if err := uc.srv.Logout(helper.Principal(c), input.RefreshToken); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil logout"))
+	}
+}
+
+func (uc *userControll) ForgotPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := ForgotPasswordRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		if err := uc.srv.ForgotPassword(input.Email); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "jika email terdaftar, link reset password sudah dikirim"))
+	}
+}
+
+func (uc *userControll) ResetPassword() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := ResetPasswordRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		if err := uc.srv.ResetPassword(input.Token, input.Password, clientInfo(c)); err != nil {
+			return c.JSON(printError(err))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil reset password, silakan login"))
+	}
+}
+
+func (uc *userControll) VerifyEmail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := uc.srv.VerifyEmail(c.QueryParam("token")); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "email berhasil diverifikasi, silakan login"))
+	}
+}
+
+func (uc *userControll) ResendVerification() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := ForgotPasswordRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		if err := uc.srv.ResendVerification(input.Email); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "jika email belum diverifikasi, link verifikasi sudah dikirim ulang"))
+	}
+}
+
+func (uc *userControll) LoginMFA() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := LoginMFARequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		token, res, err := uc.srv.LoginMFA(input.MFAToken, input.Code, clientInfo(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil login", ToResponse(res), ToTokenResponse(token)))
+	}
+}
+
+func (uc *userControll) EnrollMFA() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res, err := uc.srv.EnrollMFA(helper.Principal(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "scan QR code lalu konfirmasi dengan kode 2FA", MFAEnrollResponse{Secret: res.Secret, URI: res.URI}))
+	}
+}
+
+func (uc *userControll) ConfirmMFA() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := MFACodeRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		codes, err := uc.srv.ConfirmMFA(helper.Principal(c), input.Code)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "2FA aktif, simpan recovery code di tempat aman", RecoveryCodeResponse{RecoveryCodes: codes}))
+	}
+}
+
+func (uc *userControll) DisableMFA() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := MFACodeRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		if err := uc.srv.DisableMFA(helper.Principal(c), input.Code); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "2FA dinonaktifkan"))
+	}
+}
+
+// oidcStateCookie menyimpan state login OIDC di browser yang memulai login,
+// callback dari browser lain (login CSRF) tidak akan membawa cookie ini.
+const oidcStateCookie = "oidc_state"
+
+func (uc *userControll) OIDCLogin() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		authURL, stateToken, err := uc.srv.OIDCStart()
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		c.SetCookie(&http.Cookie{
+			Name:     oidcStateCookie,
+			Value:    stateToken,
+			Path:     "/login/oidc",
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(config.APP_URL, "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		return c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+func (uc *userControll) OIDCCallback() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if idpErr := c.QueryParam("error"); idpErr != "" {
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"message": "login oidc dibatalkan: " + idpErr,
+			})
+		}
+
+		stateToken := ""
+		if cookie, err := c.Cookie(oidcStateCookie); err == nil {
+			stateToken = cookie.Value
+		}
+		// state hanya berlaku sekali
+		c.SetCookie(&http.Cookie{Name: oidcStateCookie, Path: "/login/oidc", MaxAge: -1, HttpOnly: true})
+
+		token, res, err := uc.srv.OIDCCallback(stateToken, c.QueryParam("state"), c.QueryParam("code"), clientInfo(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+		if token.MFAToken != "" {
+			return c.JSON(PrintSuccessReponse(http.StatusOK, "masukkan kode 2FA", MFAPendingResponse{MFAToken: token.MFAToken}))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil login", ToResponse(res), ToTokenResponse(token)))
+	}
+}
+
+func (uc *userControll) Sessions() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res, err := uc.srv.Sessions(helper.Principal(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil lihat sesi aktif", ToSessionResponse(res)))
+	}
+}
+
+func (uc *userControll) SecurityEvents() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// parser sama dengan endpoint admin, user_id diabaikan service karena user hanya melihat riwayatnya sendiri
+		filter, err := adminhdl.ToEventFilter(c)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		res, total, err := uc.srv.SecurityEvents(helper.Principal(c), filter)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		paging := helper.Pagination{Page: filter.Page, Limit: filter.Limit, Total: total}
+		return c.JSON(helper.PrintPagingResponse(http.StatusOK, "berhasil lihat riwayat keamanan", ToSecurityEventResponse(res), paging))
+	}
+}
+
+func (uc *userControll) RevokeSession() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			return c.JSON(helper.PrintErrorResponse("format id sesi salah"))
+		}
+
+		if err := uc.srv.RevokeSession(helper.Principal(c), uint(id)); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "sesi berhasil diakhiri"))
+	}
+}
+
+func (uc *userControll) Export() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res, err := uc.srv.Export(helper.Principal(c))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"akun-%d.json\"", res.User.ID))
+		return c.JSON(http.StatusOK, ToExportResponse(res))
+	}
+}
+
+func (uc *userControll) ChangeEmail() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := ChangeEmailRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		if err := uc.srv.RequestEmailChange(helper.Principal(c), input.Email, input.Password); err != nil {
+			return c.JSON(printError(err))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusAccepted, "cek email baru untuk konfirmasi, email lama tetap dipakai sampai dikonfirmasi"))
+	}
+}
+
+// ConfirmEmailChangePage halaman dari link di email baru, belum mengganti email.
+func (uc *userControll) ConfirmEmailChangePage() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderEmailPage(c, http.StatusOK, emailPageData{
+			Title:   "Konfirmasi email baru",
+			Message: "Tekan tombol di bawah untuk memakai email ini di akun kamu.",
+			Action:  "/users/email/confirm",
+			Button:  "Konfirmasi email",
+			Token:   c.QueryParam("token"),
+		})
+	}
+}
+
+func (uc *userControll) ConfirmEmailChange() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := TokenRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		err := uc.srv.ConfirmEmailChange(input.Token, clientInfo(c))
+		return emailResult(c, "Konfirmasi email baru", err, "email berhasil diganti")
+	}
+}
+
+// RevertEmailChangePage halaman dari link di email lama, belum membatalkan apa pun.
+func (uc *userControll) RevertEmailChangePage() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderEmailPage(c, http.StatusOK, emailPageData{
+			Title:   "Batalkan ganti email",
+			Message: "Tekan tombol di bawah jika bukan kamu yang meminta ganti email. Semua sesi akan diputus dan password harus direset.",
+			Action:  "/users/email/revert",
+			Button:  "Batalkan ganti email",
+			Token:   c.QueryParam("token"),
+		})
+	}
+}
+
+func (uc *userControll) RevertEmailChange() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		input := TokenRequest{}
+		if err := c.Bind(&input); err != nil {
+			return c.JSON(http.StatusBadRequest, "format inputan salah")
+		}
+
+		err := uc.srv.RevertEmailChange(input.Token, clientInfo(c))
+		return emailResult(c, "Batalkan ganti email", err, "ganti email dibatalkan, semua sesi diputus, silakan reset password")
+	}
+}
+
+func (uc *userControll) UploadAvatar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		file, err := c.FormFile("avatar")
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse("format inputan salah, kirim gambar di field avatar"))
+		}
+		if file.Size > config.AVATAR_MAX_SIZE {
+			return c.JSON(helper.PrintErrorResponse(fmt.Sprintf("ukuran avatar terlalu besar, maksimal %d KB", config.AVATAR_MAX_SIZE>>10)))
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse("terdapat masalah pada server"))
+		}
+		defer src.Close()
+		data, err := io.ReadAll(io.LimitReader(src, config.AVATAR_MAX_SIZE+1))
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse("terdapat masalah pada server"))
+		}
+
+		res, err := uc.srv.UploadAvatar(helper.Principal(c), data)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "berhasil upload avatar", ToResponse(res)))
+	}
+}
+
+func (uc *userControll) Avatar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id <= 0 {
+			return c.JSON(helper.PrintErrorResponse("format id user salah"))
+		}
+		size := 0
+		if val := c.QueryParam("size"); val != "" {
+			if size, err = strconv.Atoi(val); err != nil {
+				return c.JSON(helper.PrintErrorResponse("format ukuran avatar salah"))
+			}
+		}
+
+		data, contentType, err := uc.srv.Avatar(uint(id), size)
+		if err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+		return c.Blob(http.StatusOK, contentType, data)
+	}
+}
+
+func (uc *userControll) DeleteAvatar() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if err := uc.srv.DeleteAvatar(helper.Principal(c)); err != nil {
+			return c.JSON(helper.PrintErrorResponse(err.Error()))
+		}
+
+		return c.JSON(PrintSuccessReponse(http.StatusOK, "avatar dihapus"))
 	}
 }
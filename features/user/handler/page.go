@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"api/helper"
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// emailPage halaman dari link email. GET hanya menampilkan tombol, aksi baru dijalankan saat
+// form dikirim (POST) supaya link yang dibuka pemindai atau prefetch email tidak mengubah apa pun.
+var emailPage = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Token}}<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>{{end}}
+</body>
+</html>`))
+
+type emailPageData struct {
+	Title   string
+	Message string
+	Action  string
+	Button  string
+	Token   string
+}
+
+func renderEmailPage(c echo.Context, code int, data emailPageData) error {
+	buf := bytes.Buffer{}
+	if err := emailPage.Execute(&buf, data); err != nil {
+		log.Println("render email page error", err.Error())
+		return c.JSON(helper.PrintErrorResponse("terdapat masalah pada server"))
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
+
+// fromForm true jika request dikirim dari form halaman email, hasilnya dibalas HTML bukan JSON.
+func fromForm(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationForm)
+}
+
+// emailResult membalas hasil konfirmasi atau pembatalan ganti email sesuai asal request.
+func emailResult(c echo.Context, title string, err error, okMsg string) error {
+	if err != nil {
+		code, res := helper.PrintErrorResponse(err.Error())
+		if fromForm(c) {
+			return renderEmailPage(c, code, emailPageData{Title: title, Message: err.Error()})
+		}
+		return c.JSON(code, res)
+	}
+	if fromForm(c) {
+		return renderEmailPage(c, http.StatusOK, emailPageData{Title: title, Message: okMsg})
+	}
+	return c.JSON(PrintSuccessReponse(http.StatusOK, okMsg))
+}
@@ -7,6 +7,39 @@ type LoginRequest struct {
 	Password string `json:"password" form:"password"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+// ForgotPasswordRequest juga dipakai untuk kirim ulang email verifikasi.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" form:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" form:"token"`
+	Password string `json:"password" form:"password"`
+}
+
+// TokenRequest token dari link email (konfirmasi atau pembatalan ganti email).
+type TokenRequest struct {
+	Token string `json:"token" form:"token"`
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" form:"mfa_token"`
+	Code     string `json:"code" form:"code"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" form:"email"`
+	Password string `json:"password" form:"password"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" form:"code"`
+}
+
 type RegisterRequest struct {
 	Name     string `json:"nama" form:"nama"`
 	Email    string `json:"email" form:"email"`
@@ -2,26 +2,182 @@ package handler
 
 import (
 	"api/features/user"
-	"net/http"
-	"strings"
+	"api/helper"
+	"errors"
+	"time"
 )
 
 type UserReponse struct {
-	ID     uint   `json:"id"`
-	Name   string `json:"nama"`
-	Email  string `json:"email"`
-	Alamat string `json:"alamat"`
-	HP     string `json:"hp"`
+	ID         uint   `json:"id"`
+	Name       string `json:"nama"`
+	Email      string `json:"email"`
+	Alamat     string `json:"alamat"`
+	HP         string `json:"hp"`
+	ShowAlamat bool   `json:"show_alamat"`
+	ShowHP     bool   `json:"show_hp"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+func ToTokenResponse(data user.TokenCore) TokenResponse {
+	return TokenResponse{
+		AccessToken:  data.AccessToken,
+		RefreshToken: data.RefreshToken,
+		ExpiresIn:    data.ExpiresIn,
+	}
+}
+
+type MFAPendingResponse struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFAEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type RecoveryCodeResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+func ToSessionResponse(data []user.SessionCore) []SessionResponse {
+	res := []SessionResponse{}
+	for _, v := range data {
+		res = append(res, SessionResponse{
+			ID:         v.ID,
+			UserAgent:  v.UserAgent,
+			IP:         v.IP,
+			CreatedAt:  v.CreatedAt,
+			LastSeenAt: v.LastSeenAt,
+			Current:    v.Current,
+		})
+	}
+	return res
+}
+
+type SecurityEventResponse struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToSecurityEventResponse(data []user.SecurityEventCore) []SecurityEventResponse {
+	res := []SecurityEventResponse{}
+	for _, v := range data {
+		res = append(res, SecurityEventResponse{
+			ID:        v.ID,
+			Type:      v.Type,
+			IP:        v.IP,
+			UserAgent: v.UserAgent,
+			Detail:    v.Detail,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+	return res
+}
+
+type ExportResponse struct {
+	Profile    ExportProfile `json:"profil"`
+	Books      []ExportBook  `json:"buku"`
+	ExportedAt time.Time     `json:"exported_at"`
+}
+
+type ExportProfile struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"nama"`
+	Email      string    `json:"email"`
+	Alamat     string    `json:"alamat"`
+	HP         string    `json:"hp"`
+	Role       string    `json:"role"`
+	Verified   bool      `json:"email_verified"`
+	MFAEnabled bool      `json:"mfa_enabled"`
+	OIDCLinked bool      `json:"oidc_linked"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ExportBook struct {
+	ID          uint      `json:"id"`
+	Judul       string    `json:"judul"`
+	TahunTerbit int       `json:"tahun_terbit"`
+	Penulis     string    `json:"penulis"`
+	ISBN        string    `json:"isbn"`
+	Penerbit    string    `json:"penerbit"`
+	Halaman     int       `json:"halaman"`
+	CoverURL    string    `json:"cover_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func ToExportResponse(data user.ExportCore) ExportResponse {
+	res := ExportResponse{
+		Profile: ExportProfile{
+			ID:         data.User.ID,
+			Name:       data.User.Name,
+			Email:      data.User.Email,
+			Alamat:     data.User.Alamat,
+			HP:         data.User.HP,
+			Role:       data.User.Role,
+			Verified:   data.User.Verified,
+			MFAEnabled: data.User.MFAEnabled,
+			OIDCLinked: data.User.OIDCSubject != "",
+			CreatedAt:  data.User.CreatedAt,
+		},
+		Books:      []ExportBook{},
+		ExportedAt: data.ExportedAt,
+	}
+	for _, v := range data.Books {
+		res.Books = append(res.Books, ExportBook{
+			ID:          v.ID,
+			Judul:       v.Judul,
+			TahunTerbit: v.TahunTerbit,
+			Penulis:     v.Penulis,
+			ISBN:        v.ISBN,
+			Penerbit:    v.Penerbit,
+			Halaman:     v.Halaman,
+			CoverURL:    v.CoverURL,
+			CreatedAt:   v.CreatedAt,
+			UpdatedAt:   v.UpdatedAt,
+		})
+	}
+	return res
 }
 
 func ToResponse(data user.Core) UserReponse {
 	return UserReponse{
-		ID:     data.ID,
-		Name:   data.Name,
-		Email:  data.Email,
-		Alamat: data.Alamat,
-		HP:     data.HP,
+		ID:         data.ID,
+		Name:       data.Name,
+		Email:      data.Email,
+		Alamat:     data.Alamat,
+		HP:         data.HP,
+		ShowAlamat: data.ShowAlamat,
+		ShowHP:     data.ShowHP,
+		AvatarURL:  avatarURL(data),
+	}
+}
+
+// avatarURL kosong jika user belum upload avatar.
+func avatarURL(data user.Core) string {
+	if data.Avatar == "" {
+		return ""
 	}
+	return helper.AvatarURL(data.ID)
 }
 
 func PrintSuccessReponse(code int, message string, data ...interface{}) (int, interface{}) {
@@ -36,7 +192,14 @@ func PrintSuccessReponse(code int, message string, data ...interface{}) (int, in
 	case 1:
 		resp["data"] = data[0]
 	case 2:
-		resp["token"] = data[1].(string)
+		switch token := data[1].(type) {
+		case TokenResponse:
+			resp["token"] = token.AccessToken
+			resp["refresh_token"] = token.RefreshToken
+			resp["expires_in"] = token.ExpiresIn
+		default:
+			resp["token"] = token.(string)
+		}
 		resp["data"] = data[0]
 	}
 	if message != "" {
@@ -46,20 +209,12 @@ func PrintSuccessReponse(code int, message string, data ...interface{}) (int, in
 	return code, resp
 }
 
-func PrintErrorResponse(msg string) (int, interface{}) {
-	resp := map[string]interface{}{}
-	code := -1
-	if msg != "" {
-		resp["message"] = msg
-	}
-
-	if strings.Contains(msg, "server") {
-		code = http.StatusInternalServerError
-	} else if strings.Contains(msg, "format") {
-		code = http.StatusBadRequest
-	} else if strings.Contains(msg, "not found") {
-		code = http.StatusNotFound
+// printError seperti helper.PrintErrorResponse, tapi ValidationError dikirim lengkap dengan detail per field.
+func printError(err error) (int, interface{}) {
+	var ve *helper.ValidationError
+	if errors.As(err, &ve) {
+		return helper.PrintValidationResponse(ve)
 	}
 
-	return code, resp
+	return helper.PrintErrorResponse(err.Error())
 }
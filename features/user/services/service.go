@@ -1,57 +1,376 @@
 package services
 
 import (
+	"api/auth"
+	"api/blobstore"
+	"api/config"
 	"api/features/user"
 	"api/helper"
+	"api/imaging"
+	"api/mailer"
+	"api/oidc"
+	"api/pwpolicy"
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
+const (
+	resetTokenTTL        = time.Hour
+	verifyTokenTTL       = 24 * time.Hour
+	verifyResendInterval = time.Minute
+	verifyEmailPurpose   = "verify_email"
+	mfaPendingPurpose    = "mfa_pending"
+	mfaPendingTTL        = 5 * time.Minute
+	mfaIssuer            = "Buku API"
+	recoveryCodeCount    = 10
+	oidcStatePurpose     = "oidc_state"
+	oidcStateTTL         = 10 * time.Minute
+	emailChangeTTL       = 24 * time.Hour
+	emailRevertTTL       = 7 * 24 * time.Hour
+	emailChangeMsg       = "email tidak bisa diubah langsung, gunakan POST /users/email"
+
+	loginFailedMsg       = "email atau password salah"
+	loginFailureWindow   = 15 * time.Minute
+	loginLockoutDuration = 15 * time.Minute
+	accountDelayAfter    = 3
+	accountLockoutAfter  = 10
+	ipDelayAfter         = 10
+	ipLockoutAfter       = 50
+
+	// sessionTouchInterval jarak minimal antar update last seen sesi
+	sessionTouchInterval = time.Minute
+
+	// reauthMaxAge umur maksimal sesi login agar akun OIDC boleh ganti email tanpa password
+	reauthMaxAge = 10 * time.Minute
+
+	avatarDefaultSize = 128
+)
+
+// dummyHash dipakai membandingkan password saat email tidak terdaftar.
+var dummyHash, _ = helper.GeneratePassword("dummy-password-untuk-timing")
+
 type userUseCase struct {
 	qry user.UserData
 	vld *validator.Validate
+	ml  mailer.Mailer
+	idp oidc.IdentityProvider
+	pwd *pwpolicy.Policy
+	bs  blobstore.BlobStore
 }
 
-func New(ud user.UserData) user.UserService {
+// New membuat user service, idp boleh nil jika login OIDC tidak dipakai
+// dan pwd nil berarti memakai pwpolicy.Default.
+func New(ud user.UserData, ml mailer.Mailer, idp oidc.IdentityProvider, pwd *pwpolicy.Policy, bs blobstore.BlobStore) user.UserService {
+	if pwd == nil {
+		pwd = pwpolicy.Default()
+	}
 	return &userUseCase{
 		qry: ud,
 		vld: validator.New(),
+		ml:  ml,
+		idp: idp,
+		pwd: pwd,
+		bs:  bs,
 	}
 }
 
-func (uuc *userUseCase) Login(email, password string) (string, user.Core, error) {
+func (uuc *userUseCase) Login(email, password string, client user.ClientInfo) (user.TokenCore, user.Core, error) {
+	attempt := user.LoginAttemptCore{Email: normalizeEmail(email), IP: client.IP, UserAgent: client.UserAgent}
+	if err := uuc.throttleLogin(attempt); err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+
 	res, err := uuc.qry.Login(email)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		// akun yang dihapus masih bisa login selama masa tenggang, login membatalkan penghapusan
+		if deleted, derr := uuc.qry.GetDeactivated(email); derr == nil && inGrace(deleted) {
+			res, err = deleted, nil
+		}
+	}
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return user.TokenCore{}, user.Core{}, errors.New("terdapat masalah pada server")
+		}
+		// tetap hitung bcrypt supaya waktu respon email tidak terdaftar sama dengan password salah
+		helper.CheckPassword(dummyHash, password)
+		attempt.Reason = user.LoginUnknownEmail
+		uuc.recordLogin(attempt)
+		return user.TokenCore{}, user.Core{}, errors.New(loginFailedMsg)
+	}
+	attempt.UserID = res.ID
+
+	if err := helper.CheckPassword(res.Password, password); err != nil {
+		attempt.Reason = user.LoginWrongPassword
+		uuc.recordLogin(attempt)
+		return user.TokenCore{}, user.Core{}, errors.New(loginFailedMsg)
+	}
+
+	if err := res.CheckStatus(); err != nil {
+		attempt.Reason = user.LoginStatusDenied
+		uuc.recordLogin(attempt)
+		return user.TokenCore{}, user.Core{}, err
+	}
+
+	if res.MFAEnabled {
+		// belum dihitung sukses, hitungan gagal baru direset setelah kode 2FA benar
+		attempt.Reason = user.LoginMFAPending
+		uuc.recordLogin(attempt)
+		mfaToken := helper.GenerateSignedToken(mfaPendingPurpose, res.ID, res.Email, mfaPendingTTL)
+		return user.TokenCore{MFAToken: mfaToken}, res, nil
+	}
+
+	if err := uuc.reactivate(&res, client); err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+	token, err := uuc.newLogin(res, client)
+	if err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+	attempt.Success = true
+	attempt.Reason = user.LoginSuccess
+	uuc.recordLogin(attempt)
+
+	return token, res, nil
+
+}
+
+// throttleLogin menolak login saat akun atau IP masih dalam jeda. Jeda naik dua kali lipat
+// tiap kegagalan setelah batas tertentu, lalu dikunci sementara saat mencapai batas lockout.
+func (uuc *userUseCase) throttleLogin(attempt user.LoginAttemptCore) error {
+	since := time.Now().Add(-loginFailureWindow)
+
+	var wait time.Duration
+	if attempt.Email != "" {
+		account, err := uuc.qry.AccountLoginFailures(attempt.Email, since)
+		if err != nil {
+			return errors.New("terdapat masalah pada server")
+		}
+		wait = loginWait(account, accountDelayAfter, accountLockoutAfter)
+	}
+
+	if attempt.IP != "" {
+		byIP, err := uuc.qry.IPLoginFailures(attempt.IP, since)
+		if err != nil {
+			return errors.New("terdapat masalah pada server")
+		}
+		if ipWait := loginWait(byIP, ipDelayAfter, ipLockoutAfter); ipWait > wait {
+			wait = ipWait
+		}
+	}
+
+	if wait > 0 {
+		attempt.Reason = user.LoginThrottled
+		uuc.recordLogin(attempt)
+		return fmt.Errorf("terlalu banyak percobaan login, coba lagi dalam %d detik", int(wait.Seconds())+1)
+	}
+
+	return nil
+}
+
+func loginWait(failures user.LoginFailureCore, delayAfter, lockoutAfter int64) time.Duration {
+	if failures.Count < delayAfter {
+		return 0
+	}
+
+	delay := loginLockoutDuration
+	if failures.Count < lockoutAfter {
+		delay = time.Second << uint(failures.Count-delayAfter)
+		if delay <= 0 || delay > loginLockoutDuration {
+			delay = loginLockoutDuration
+		}
+	}
+
+	return time.Until(failures.Last.Add(delay))
+}
+
+// recordLogin menulis audit login, gagal menulis audit tidak menggagalkan login.
+func (uuc *userUseCase) recordLogin(attempt user.LoginAttemptCore) {
+	attempt.CreatedAt = time.Now()
+	if err := uuc.qry.RecordLoginAttempt(attempt); err != nil {
+		log.Println("record login attempt error", err.Error())
+	}
+
+	// email tidak terdaftar tidak punya pemilik, langkah 2FA yang belum selesai dicatat saat LoginMFA
+	if attempt.UserID == 0 || attempt.Reason == user.LoginMFAPending {
+		return
+	}
+	event := user.SecurityEventCore{UserID: attempt.UserID, Type: user.EventLoginFailed, IP: attempt.IP, UserAgent: attempt.UserAgent, Detail: attempt.Reason}
+	if attempt.Success {
+		event.Type, event.Detail = user.EventLoginSuccess, attempt.Method
+	}
+	uuc.recordEvent(event)
+}
+
+// recordEvent menulis riwayat keamanan akun, gagal menulis tidak menggagalkan aksi user.
+func (uuc *userUseCase) recordEvent(event user.SecurityEventCore) {
+	event.CreatedAt = time.Now()
+	if err := uuc.qry.SaveSecurityEvent(event); err != nil {
+		log.Println("save security event error", err.Error())
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// newLogin membuka sesi dan refresh token family baru untuk login yang sudah lolos semua pengecekan.
+func (uuc *userUseCase) newLogin(usr user.Core, client user.ClientInfo) (user.TokenCore, error) {
+	familyID, err := helper.GenerateRandomToken(16)
+	if err != nil {
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	now := time.Now()
+	session, err := uuc.qry.SaveSession(user.SessionCore{
+		UserID:     usr.ID,
+		FamilyID:   familyID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+	if err != nil {
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	return uuc.issueToken(usr, familyID, session.ID)
+}
+
+// roleOf role yang ditulis di access token, user lama tanpa role dianggap user biasa.
+func roleOf(usr user.Core) string {
+	if usr.Role == "" {
+		return helper.RoleUser
+	}
+	return usr.Role
+}
+
+// issueToken membuat access token baru beserta refresh token yang masuk ke family yang sama.
+func (uuc *userUseCase) issueToken(usr user.Core, familyID string, sessionID uint) (user.TokenCore, error) {
+	accessToken, err := helper.GenerateSessionJWT(int(usr.ID), roleOf(usr), sessionID)
+	if err != nil {
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	refreshToken, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	err = uuc.qry.SaveRefreshToken(user.RefreshTokenCore{
+		UserID:    usr.ID,
+		TokenHash: helper.HashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(config.REFRESH_TOKEN_TTL),
+	})
+	if err != nil {
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	return user.TokenCore{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.ACCESS_TOKEN_TTL.Seconds()),
+	}, nil
+}
+
+// Refresh menukar refresh token dengan pasangan token baru (rotation).
+// Refresh token yang sudah pernah dipakai dianggap bocor, seluruh family-nya dicabut.
+func (uuc *userUseCase) Refresh(refreshToken string, client user.ClientInfo) (user.TokenCore, error) {
+	if refreshToken == "" {
+		return user.TokenCore{}, errors.New("refresh token tidak valid")
+	}
+
+	stored, err := uuc.qry.GetRefreshToken(helper.HashToken(refreshToken))
 	if err != nil {
 		msg := ""
 		if strings.Contains(err.Error(), "not found") {
-			msg = "data tidak ditemukan"
+			msg = "refresh token tidak valid"
 		} else {
 			msg = "terdapat masalah pada server"
 		}
-		return "", user.Core{}, errors.New(msg)
+		return user.TokenCore{}, errors.New(msg)
 	}
 
-	if err := helper.CheckPassword(res.Password, password); err != nil {
-		log.Println("login compare", err.Error())
-		return "", user.Core{}, errors.New("password tidak sesuai " + res.Password)
+	if stored.Used || stored.Revoked {
+		return user.TokenCore{}, uuc.revokeFamily(stored, client)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return user.TokenCore{}, errors.New("refresh token sudah kedaluwarsa, silakan login ulang")
 	}
 
-	//Token expires after 1 hour
-	token, _ := helper.GenerateJWT(int(res.ID))
+	if err := uuc.qry.UseRefreshToken(stored.ID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			// kalah balapan dengan request lain yang memakai token yang sama
+			return user.TokenCore{}, uuc.revokeFamily(stored, client)
+		}
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
 
-	return token, res, nil
+	// role diambil ulang dari database supaya perubahan role ikut ke token baru
+	usr, err := uuc.qry.Profile(stored.UserID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.TokenCore{}, errors.New("token tidak valid, akun sudah tidak aktif")
+		}
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+	if err := usr.CheckStatus(); err != nil {
+		return user.TokenCore{}, err
+	}
+
+	// family dari sebelum ada sesi tetap bisa refresh, hanya tanpa sid
+	var sessionID uint
+	session, err := uuc.qry.GetSessionByFamily(stored.FamilyID)
+	if err == nil {
+		if session.Revoked {
+			return user.TokenCore{}, errors.New("refresh token sudah dicabut, silakan login ulang")
+		}
+		sessionID = session.ID
+		if err := uuc.qry.TouchSession(session.ID, client, time.Now()); err != nil {
+			log.Println("touch session error", err.Error())
+		}
+	} else if !strings.Contains(err.Error(), "not found") {
+		return user.TokenCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	return uuc.issueToken(usr, stored.FamilyID, sessionID)
+}
 
+func (uuc *userUseCase) revokeFamily(stored user.RefreshTokenCore, client user.ClientInfo) error {
+	log.Println("refresh token reuse detected, user:", stored.UserID, "family:", stored.FamilyID)
+	uuc.recordEvent(user.SecurityEventCore{UserID: stored.UserID, Type: user.EventRefreshTokenReuse, IP: client.IP, UserAgent: client.UserAgent})
+	if err := uuc.qry.RevokeTokenFamily(stored.FamilyID); err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+
+	return errors.New("refresh token sudah dipakai, silakan login ulang")
 }
 func (uuc *userUseCase) Register(newUser user.Core) (user.Core, error) {
+	ve := &helper.ValidationError{}
+	if err := uuc.vld.Var(newUser.Email, "required,email"); err != nil {
+		ve.Add("email", "format email salah")
+	}
+	if err := uuc.validatePassword(ve, 0, newUser.Password); err != nil {
+		return user.Core{}, err
+	}
+	if ve.HasErrors() {
+		return user.Core{}, ve
+	}
+
 	hashed, err := helper.GeneratePassword(newUser.Password)
 	if err != nil {
 		log.Println("bcrypt error ", err.Error())
 		return user.Core{}, errors.New("password process error")
 	}
 	newUser.Password = string(hashed)
+	newUser.Role = helper.RoleUser
 	res, err := uuc.qry.Register(newUser)
 	if err != nil {
 		msg := ""
@@ -63,10 +382,90 @@ func (uuc *userUseCase) Register(newUser user.Core) (user.Core, error) {
 		return user.Core{}, errors.New(msg)
 	}
 
+	// gagal kirim email tidak menggagalkan pendaftaran, user bisa minta kirim ulang
+	if err := uuc.sendVerification(res); err != nil {
+		log.Println("send verification mail error", err.Error())
+	}
+
 	return res, nil
 }
-func (uuc *userUseCase) Profile(token interface{}) (user.Core, error) {
-	id := helper.ExtractToken(token)
+
+// validatePassword menambahkan pelanggaran password policy ke ve. Untuk user yang sudah ada
+// (userID > 0) password juga dicek terhadap riwayat password terakhirnya.
+func (uuc *userUseCase) validatePassword(ve *helper.ValidationError, userID uint, password string) error {
+	violations := uuc.pwd.Validate(password)
+	for _, msg := range violations {
+		ve.Add("password", msg)
+	}
+	if len(violations) > 0 || userID == 0 || uuc.pwd.History <= 0 {
+		return nil
+	}
+
+	hashes, err := uuc.qry.RecentPasswords(userID, uuc.pwd.History)
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+	if uuc.pwd.Reused(password, hashes) {
+		ve.Add("password", fmt.Sprintf("password tidak boleh sama dengan %d password terakhir", uuc.pwd.History))
+	}
+
+	return nil
+}
+
+func (uuc *userUseCase) sendVerification(usr user.Core) error {
+	verifyToken := helper.GenerateSignedToken(verifyEmailPurpose, usr.ID, usr.Email, verifyTokenTTL)
+	body := fmt.Sprintf("Halo %s,\n\nKlik link berikut untuk memverifikasi email kamu (berlaku %d jam):\n%s/verify-email?token=%s",
+		usr.Name, int(verifyTokenTTL.Hours()), config.APP_URL, verifyToken)
+	if err := uuc.ml.Send(usr.Email, "Verifikasi email", body); err != nil {
+		return err
+	}
+
+	return uuc.qry.SetVerificationSent(usr.ID, time.Now())
+}
+
+func (uuc *userUseCase) VerifyEmail(verifyToken string) error {
+	id, email, err := helper.ParseSignedToken(verifyEmailPurpose, verifyToken)
+	if err != nil {
+		return errors.New("link verifikasi tidak valid atau sudah kedaluwarsa")
+	}
+
+	if err := uuc.qry.SetEmailVerified(id, email); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("link verifikasi tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+
+	return nil
+}
+
+// ResendVerification tidak membedakan email yang tidak terdaftar atau sudah terverifikasi,
+// hanya dibatasi satu kali kirim per verifyResendInterval.
+func (uuc *userUseCase) ResendVerification(email string) error {
+	res, err := uuc.qry.Login(email)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if res.Verified {
+		return nil
+	}
+
+	if wait := time.Until(res.VerificationSentAt.Add(verifyResendInterval)); wait > 0 {
+		return fmt.Errorf("terlalu banyak permintaan, coba lagi dalam %d detik", int(wait.Seconds())+1)
+	}
+
+	if err := uuc.sendVerification(res); err != nil {
+		log.Println("send verification mail error", err.Error())
+		return errors.New("terdapat masalah pada server")
+	}
+
+	return nil
+}
+func (uuc *userUseCase) Profile(p auth.Principal) (user.Core, error) {
+	id := int(p.UserID)
 	if id <= 0 {
 		return user.Core{}, errors.New("data tidak ditemukan")
 	}
@@ -83,14 +482,45 @@ func (uuc *userUseCase) Profile(token interface{}) (user.Core, error) {
 	return res, nil
 }
 
-func (uuc *userUseCase) Update(token interface{}, updateData user.Core) (user.Core, error) {
-	id := helper.ExtractToken(token)
+func (uuc *userUseCase) Update(p auth.Principal, updateData user.Core) (user.Core, error) {
+	id := int(p.UserID)
 	if id <= 0 {
 		return user.Core{}, errors.New("invalid user id")
 	}
-	hashed, err := helper.GeneratePassword(updateData.Password)
-	updateData.Password = hashed
-	res, err := uuc.qry.Update(uint(id), updateData)
+	// email yang sama boleh ikut dikirim, penggantian email harus lewat konfirmasi
+	if updateData.Email != "" {
+		cur, err := uuc.qry.Profile(uint(id))
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return user.Core{}, errors.New("data user not found")
+			}
+			return user.Core{}, errors.New("internal server error")
+		}
+		if updateData.Email != cur.Email {
+			ve := &helper.ValidationError{}
+			ve.Add("email", emailChangeMsg)
+			return user.Core{}, ve
+		}
+		updateData.Email = ""
+	}
+	// password kosong berarti tidak diganti
+	if updateData.Password != "" {
+		ve := &helper.ValidationError{}
+		if err := uuc.validatePassword(ve, uint(id), updateData.Password); err != nil {
+			return user.Core{}, err
+		}
+		if ve.HasErrors() {
+			return user.Core{}, ve
+		}
+
+		hashed, err := helper.GeneratePassword(updateData.Password)
+		if err != nil {
+			log.Println("bcrypt error ", err.Error())
+			return user.Core{}, errors.New("password process error")
+		}
+		updateData.Password = hashed
+	}
+	res, err := uuc.qry.Update(uint(id), updateData, filledFields(updateData))
 	if err != nil {
 		msg := ""
 		if strings.Contains(err.Error(), "not found") {
@@ -100,12 +530,129 @@ func (uuc *userUseCase) Update(token interface{}, updateData user.Core) (user.Co
 		}
 		return user.Core{}, errors.New(msg)
 	}
+	if updateData.Password != "" {
+		uuc.recordEvent(user.SecurityEventCore{UserID: uint(id), Type: user.EventPasswordChanged, IP: p.IP, UserAgent: p.UserAgent})
+	}
 	return res, nil
 }
 
+// filledFields field yang diisi pada PUT /users, field kosong berarti tidak diubah.
+func filledFields(data user.Core) []string {
+	fields := []string{}
+	if data.Name != "" {
+		fields = append(fields, "Name")
+	}
+	if data.Email != "" {
+		fields = append(fields, "Email")
+	}
+	if data.Alamat != "" {
+		fields = append(fields, "Alamat")
+	}
+	if data.HP != "" {
+		fields = append(fields, "HP")
+	}
+	if data.Password != "" {
+		fields = append(fields, "Password")
+	}
+	return fields
+}
+
+// userPatch dokumen user yang bisa diubah lewat PATCH /users, sama dengan response profil.
+// Password hanya bisa ditulis, nilainya selalu kosong di dokumen awal.
+type userPatch struct {
+	Name       string `json:"nama"`
+	Email      string `json:"email"`
+	Alamat     string `json:"alamat"`
+	HP         string `json:"hp"`
+	ShowAlamat bool   `json:"show_alamat"`
+	ShowHP     bool   `json:"show_hp"`
+	Password   string `json:"password"`
+}
+
+// Patch menerapkan JSON Merge Patch ke profil user. Field bernilai null dikosongkan,
+// hanya kolom yang benar-benar berubah yang ditulis ke database.
+func (uuc *userUseCase) Patch(p auth.Principal, patch []byte) (user.Core, error) {
+	id := int(p.UserID)
+	if id <= 0 {
+		return user.Core{}, errors.New("invalid user id")
+	}
+
+	cur, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.Core{}, errors.New("data user not found")
+		}
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	doc := userPatch{Name: cur.Name, Email: cur.Email, Alamat: cur.Alamat, HP: cur.HP, ShowAlamat: cur.ShowAlamat, ShowHP: cur.ShowHP}
+	if err := helper.ApplyMergePatch(&doc, patch); err != nil {
+		return user.Core{}, err
+	}
+
+	ve := &helper.ValidationError{}
+	fields := []string{}
+	if doc.Name != cur.Name {
+		if strings.TrimSpace(doc.Name) == "" {
+			ve.Add("nama", "nama wajib diisi")
+		}
+		fields = append(fields, "Name")
+	}
+	if doc.Email != cur.Email {
+		ve.Add("email", emailChangeMsg)
+	}
+	if doc.Alamat != cur.Alamat {
+		fields = append(fields, "Alamat")
+	}
+	if doc.HP != cur.HP {
+		fields = append(fields, "HP")
+	}
+	if doc.ShowAlamat != cur.ShowAlamat {
+		fields = append(fields, "ShowAlamat")
+	}
+	if doc.ShowHP != cur.ShowHP {
+		fields = append(fields, "ShowHP")
+	}
+	if doc.Password != "" {
+		if err := uuc.validatePassword(ve, uint(id), doc.Password); err != nil {
+			return user.Core{}, err
+		}
+		fields = append(fields, "Password")
+	}
+	if ve.HasErrors() {
+		return user.Core{}, ve
+	}
+	if len(fields) == 0 {
+		return cur, nil
+	}
+
+	updateData := user.Core{Name: doc.Name, Alamat: doc.Alamat, HP: doc.HP, ShowAlamat: doc.ShowAlamat, ShowHP: doc.ShowHP}
+	if doc.Password != "" {
+		hashed, err := helper.GeneratePassword(doc.Password)
+		if err != nil {
+			log.Println("bcrypt error ", err.Error())
+			return user.Core{}, errors.New("password process error")
+		}
+		updateData.Password = hashed
+	}
+	if _, err := uuc.qry.Update(uint(id), updateData, fields); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.Core{}, errors.New("data user not found")
+		}
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	if doc.Password != "" {
+		uuc.recordEvent(user.SecurityEventCore{UserID: uint(id), Type: user.EventPasswordChanged, IP: p.IP, UserAgent: p.UserAgent})
+	}
+
+	cur.Name, cur.Alamat, cur.HP = doc.Name, doc.Alamat, doc.HP
+	cur.ShowAlamat, cur.ShowHP = doc.ShowAlamat, doc.ShowHP
+	return cur, nil
+}
+
 // Deactive implements user.UserService
-func (uuc *userUseCase) Deactive(token interface{}) (user.Core, error) {
-	id := helper.ExtractToken(token)
+func (uuc *userUseCase) Deactive(p auth.Principal) (user.Core, error) {
+	id := int(p.UserID)
 	if id <= 0 {
 		return user.Core{}, errors.New("id user not found")
 	}
@@ -119,6 +666,926 @@ func (uuc *userUseCase) Deactive(token interface{}) (user.Core, error) {
 		}
 		return user.Core{}, errors.New(msg)
 	}
+
+	// access token yang sedang dipakai otomatis ditolak CheckToken karena user sudah terhapus,
+	// refresh token perlu dicabut agar tidak bisa ditukar token baru
+	if err := uuc.qry.RevokeUserRefreshTokens(uint(id)); err != nil {
+		log.Println("revoke refresh token error", err.Error())
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: uint(id), Type: user.EventDeactivated, IP: p.IP, UserAgent: p.UserAgent})
 	return data, nil
 
 }
+
+func (uuc *userUseCase) Logout(p auth.Principal, refreshToken string) error {
+	id := int(p.UserID)
+	jti, exp := p.TokenID, p.ExpiresAt
+	if id <= 0 || jti == "" {
+		return errors.New("token tidak valid")
+	}
+
+	if err := uuc.qry.RevokeToken(jti, uint(id), exp); err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+
+	if refreshToken != "" {
+		stored, err := uuc.qry.GetRefreshToken(helper.HashToken(refreshToken))
+		if err == nil && stored.UserID == uint(id) {
+			if err := uuc.qry.RevokeTokenFamily(stored.FamilyID); err != nil {
+				return errors.New("terdapat masalah pada server")
+			}
+		}
+	}
+
+	if sid := p.SessionID; sid > 0 {
+		if err := uuc.qry.RevokeSession(uint(id), sid); err != nil && !strings.Contains(err.Error(), "not found") {
+			return errors.New("terdapat masalah pada server")
+		}
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: uint(id), Type: user.EventLogout, IP: p.IP, UserAgent: p.UserAgent})
+
+	return nil
+}
+
+// CheckToken dipanggil middleware setelah signature JWT valid, menolak token yang
+// sudah logout, token milik akun yang sudah dinonaktifkan, dan token dengan role yang sudah berubah.
+func (uuc *userUseCase) CheckToken(p auth.Principal) error {
+	id := int(p.UserID)
+	jti := p.TokenID
+	if id <= 0 || jti == "" {
+		return errors.New("token tidak valid")
+	}
+
+	revoked, err := uuc.qry.IsTokenRevoked(jti)
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+	if revoked {
+		return errors.New("token sudah dicabut, silakan login ulang")
+	}
+
+	if sid := p.SessionID; sid > 0 {
+		session, err := uuc.qry.GetSession(sid)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return errors.New("token sudah dicabut, silakan login ulang")
+			}
+			return errors.New("terdapat masalah pada server")
+		}
+		if session.Revoked || session.UserID != uint(id) {
+			return errors.New("token sudah dicabut, silakan login ulang")
+		}
+		if time.Since(session.LastSeenAt) > sessionTouchInterval {
+			if err := uuc.qry.TouchSession(sid, user.ClientInfo{}, time.Now()); err != nil {
+				log.Println("touch session error", err.Error())
+			}
+		}
+	}
+
+	usr, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("token tidak valid, akun sudah tidak aktif")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if err := usr.CheckStatus(); err != nil {
+		return err
+	}
+	// role di claim bisa basi (misalnya admin yang sudah diturunkan), yang berlaku role di database
+	if len(p.Roles) != 1 || p.Roles[0] != roleOf(usr) {
+		return errors.New("token tidak valid, role akun sudah berubah, silakan login ulang")
+	}
+
+	return nil
+}
+
+// ForgotPassword selalu sukses untuk email yang tidak terdaftar supaya
+// endpoint ini tidak bisa dipakai mengecek email mana yang punya akun.
+func (uuc *userUseCase) ForgotPassword(email string) error {
+	res, err := uuc.qry.Login(email)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+
+	resetToken, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+
+	err = uuc.qry.SavePasswordReset(user.PasswordResetCore{
+		UserID:    res.ID,
+		TokenHash: helper.HashToken(resetToken),
+		ExpiresAt: time.Now().Add(resetTokenTTL),
+	})
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+
+	body := fmt.Sprintf("Halo %s,\n\nGunakan link berikut untuk membuat password baru (berlaku %d menit):\n%s/password/reset?token=%s\n\nAbaikan email ini jika kamu tidak meminta reset password.",
+		res.Name, int(resetTokenTTL.Minutes()), config.APP_URL, resetToken)
+	if err := uuc.ml.Send(res.Email, "Reset password", body); err != nil {
+		log.Println("send reset password mail error", err.Error())
+		return errors.New("terdapat masalah pada server")
+	}
+
+	return nil
+}
+
+func (uuc *userUseCase) ResetPassword(resetToken, newPassword string, client user.ClientInfo) error {
+	stored, err := uuc.qry.GetPasswordReset(helper.HashToken(resetToken))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("link reset password tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if stored.Used || time.Now().After(stored.ExpiresAt) {
+		return errors.New("link reset password tidak valid atau sudah kedaluwarsa")
+	}
+
+	// divalidasi sebelum link dipakai supaya user bisa mencoba password lain dengan link yang sama
+	ve := &helper.ValidationError{}
+	if err := uuc.validatePassword(ve, stored.UserID, newPassword); err != nil {
+		return err
+	}
+	if ve.HasErrors() {
+		return ve
+	}
+
+	hashed, err := helper.GeneratePassword(newPassword)
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+	// sesi lama di perangkat lain ikut diputus bersama pergantian password
+	if err := uuc.qry.UsePasswordReset(stored.ID, stored.UserID, hashed); err != nil {
+		if strings.Contains(err.Error(), "password reset not found") {
+			return errors.New("link reset password tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: stored.UserID, Type: user.EventPasswordReset, IP: client.IP, UserAgent: client.UserAgent})
+
+	return nil
+}
+
+// LoginMFA menukar mfa token dari Login dan kode TOTP (atau recovery code) dengan access token.
+func (uuc *userUseCase) LoginMFA(mfaToken, code string, client user.ClientInfo) (user.TokenCore, user.Core, error) {
+	id, email, err := helper.ParseSignedToken(mfaPendingPurpose, mfaToken)
+	if err != nil {
+		return user.TokenCore{}, user.Core{}, errors.New("mfa token tidak valid atau sudah kedaluwarsa")
+	}
+
+	attempt := user.LoginAttemptCore{UserID: id, Email: normalizeEmail(email), IP: client.IP, UserAgent: client.UserAgent}
+	if err := uuc.throttleLogin(attempt); err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+
+	res, err := uuc.qry.GetByID(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.TokenCore{}, user.Core{}, errors.New("mfa token tidak valid atau sudah kedaluwarsa")
+		}
+		return user.TokenCore{}, user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	if (res.Deleted && !inGrace(res)) || res.Email != email || !res.MFAEnabled {
+		return user.TokenCore{}, user.Core{}, errors.New("mfa token tidak valid atau sudah kedaluwarsa")
+	}
+	if err := res.CheckStatus(); err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+
+	if err := uuc.verifyMFA(res, code); err != nil {
+		// kode 2FA hanya 6 digit, kegagalan ikut dihitung supaya tidak bisa ditebak
+		if !strings.Contains(err.Error(), "server") {
+			attempt.Reason = user.LoginMFAFailed
+			uuc.recordLogin(attempt)
+		}
+		return user.TokenCore{}, user.Core{}, err
+	}
+
+	if err := uuc.reactivate(&res, client); err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+	token, err := uuc.newLogin(res, client)
+	if err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+	attempt.Success = true
+	attempt.Reason = user.LoginSuccess
+	uuc.recordLogin(attempt)
+
+	return token, res, nil
+}
+
+// verifyMFA menerima kode TOTP yang belum pernah dipakai, atau recovery code yang masih berlaku.
+func (uuc *userUseCase) verifyMFA(usr user.Core, code string) error {
+	if step, ok := helper.ValidateTOTP(usr.MFASecret, code, time.Now()); ok {
+		if err := uuc.qry.SetMFALastStep(usr.ID, step); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return errors.New("kode 2FA tidak valid")
+			}
+			return errors.New("terdapat masalah pada server")
+		}
+		return nil
+	}
+
+	if err := uuc.qry.UseRecoveryCode(usr.ID, helper.HashToken(helper.NormalizeRecoveryCode(code))); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("kode 2FA tidak valid")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+
+	return nil
+}
+
+func (uuc *userUseCase) mfaUser(p auth.Principal) (user.Core, error) {
+	id := int(p.UserID)
+	if id <= 0 {
+		return user.Core{}, errors.New("id user not found")
+	}
+
+	res, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.Core{}, errors.New("data user not found")
+		}
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	return res, nil
+}
+
+func (uuc *userUseCase) EnrollMFA(p auth.Principal) (user.MFAEnrollCore, error) {
+	res, err := uuc.mfaUser(p)
+	if err != nil {
+		return user.MFAEnrollCore{}, err
+	}
+	if res.MFAEnabled {
+		return user.MFAEnrollCore{}, errors.New("2FA sudah aktif")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return user.MFAEnrollCore{}, errors.New("terdapat masalah pada server")
+	}
+	if err := uuc.qry.SetMFASecret(res.ID, secret); err != nil {
+		return user.MFAEnrollCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	return user.MFAEnrollCore{
+		Secret: secret,
+		URI:    helper.TOTPURI(mfaIssuer, res.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA mengaktifkan 2FA setelah user membuktikan authenticator-nya menghasilkan kode yang benar.
+// Recovery code hanya dikembalikan sekali di sini, yang disimpan hanya hash-nya.
+func (uuc *userUseCase) ConfirmMFA(p auth.Principal, code string) ([]string, error) {
+	res, err := uuc.mfaUser(p)
+	if err != nil {
+		return nil, err
+	}
+	if res.MFAEnabled {
+		return nil, errors.New("2FA sudah aktif")
+	}
+	if res.MFASecret == "" {
+		return nil, errors.New("2FA belum didaftarkan")
+	}
+
+	step, ok := helper.ValidateTOTP(res.MFASecret, code, time.Now())
+	if !ok {
+		return nil, errors.New("kode 2FA tidak valid")
+	}
+
+	codes, err := helper.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, errors.New("terdapat masalah pada server")
+	}
+	hashes := []string{}
+	for _, val := range codes {
+		hashes = append(hashes, helper.HashToken(helper.NormalizeRecoveryCode(val)))
+	}
+
+	if err := uuc.qry.EnableMFA(res.ID, step, hashes); err != nil {
+		return nil, errors.New("terdapat masalah pada server")
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: res.ID, Type: user.EventMFAEnabled, IP: p.IP, UserAgent: p.UserAgent})
+
+	return codes, nil
+}
+
+func (uuc *userUseCase) DisableMFA(p auth.Principal, code string) error {
+	res, err := uuc.mfaUser(p)
+	if err != nil {
+		return err
+	}
+	if !res.MFAEnabled {
+		return errors.New("2FA belum aktif")
+	}
+
+	if err := uuc.verifyMFA(res, code); err != nil {
+		return err
+	}
+
+	if err := uuc.qry.DisableMFA(res.ID); err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: res.ID, Type: user.EventMFADisabled, IP: p.IP, UserAgent: p.UserAgent})
+
+	return nil
+}
+
+// OIDCStart menyiapkan redirect ke identity provider. State, nonce dan code verifier PKCE
+// dikembalikan sebagai stateToken bertanda tangan yang disimpan handler di cookie browser.
+func (uuc *userUseCase) OIDCStart() (string, string, error) {
+	if uuc.idp == nil {
+		return "", "", errors.New("login oidc belum dikonfigurasi")
+	}
+
+	state, errState := helper.GenerateRandomToken(32)
+	nonce, errNonce := helper.GenerateRandomToken(32)
+	verifier, errVerifier := helper.GenerateRandomToken(32)
+	if errState != nil || errNonce != nil || errVerifier != nil {
+		return "", "", errors.New("terdapat masalah pada server")
+	}
+
+	authURL, err := uuc.idp.AuthCodeURL(state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		log.Println("oidc auth url error", err.Error())
+		return "", "", errors.New("terdapat masalah pada server")
+	}
+
+	stateToken := helper.GenerateSignedClaims(oidcStatePurpose, map[string]string{
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	}, oidcStateTTL)
+
+	return authURL, stateToken, nil
+}
+
+func (uuc *userUseCase) OIDCCallback(stateToken, state, code string, client user.ClientInfo) (user.TokenCore, user.Core, error) {
+	if uuc.idp == nil {
+		return user.TokenCore{}, user.Core{}, errors.New("login oidc belum dikonfigurasi")
+	}
+
+	pending, err := helper.ParseSignedClaims(oidcStatePurpose, stateToken)
+	if err != nil || state == "" || code == "" ||
+		subtle.ConstantTimeCompare([]byte(pending["state"]), []byte(state)) != 1 {
+		return user.TokenCore{}, user.Core{}, errors.New("state login oidc tidak valid, silakan ulangi login")
+	}
+
+	claims, err := uuc.idp.Exchange(code, pending["verifier"], pending["nonce"])
+	if err != nil {
+		log.Println("oidc exchange error", err.Error())
+		return user.TokenCore{}, user.Core{}, errors.New("token identity provider tidak valid")
+	}
+
+	// throttle dan audit sama dengan login password supaya jeda akun dan IP tidak bisa dilewati lewat OIDC
+	attempt := user.LoginAttemptCore{Email: normalizeEmail(claims.Email), IP: client.IP, UserAgent: client.UserAgent, Method: "oidc"}
+	if err := uuc.throttleLogin(attempt); err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+
+	res, err := uuc.oidcUser(claims)
+	if err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+	attempt.UserID = res.ID
+
+	if err := res.CheckStatus(); err != nil {
+		attempt.Reason = user.LoginStatusDenied
+		uuc.recordLogin(attempt)
+		return user.TokenCore{}, user.Core{}, err
+	}
+
+	if res.MFAEnabled {
+		attempt.Reason = user.LoginMFAPending
+		uuc.recordLogin(attempt)
+		mfaToken := helper.GenerateSignedToken(mfaPendingPurpose, res.ID, res.Email, mfaPendingTTL)
+		return user.TokenCore{MFAToken: mfaToken}, res, nil
+	}
+
+	token, err := uuc.newLogin(res, client)
+	if err != nil {
+		return user.TokenCore{}, user.Core{}, err
+	}
+	attempt.Success = true
+	attempt.Reason = user.LoginSuccess
+	uuc.recordLogin(attempt)
+
+	return token, res, nil
+}
+
+// oidcUser mencari user berdasarkan subject OIDC. Jika belum ada, akun lokal dengan email
+// yang sama ditautkan, atau dibuatkan akun baru tanpa password yang bisa dipakai.
+func (uuc *userUseCase) oidcUser(claims oidc.Claims) (user.Core, error) {
+	res, err := uuc.qry.GetByOIDCSubject(claims.Subject)
+	if err == nil {
+		return res, nil
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return user.Core{}, errors.New("akses ditolak, email dari identity provider belum diverifikasi")
+	}
+
+	res, err = uuc.qry.Login(claims.Email)
+	if err == nil {
+		// akun yang emailnya belum diverifikasi bisa saja didaftarkan orang lain,
+		// jadi tidak ditautkan supaya tidak bisa diambil alih lewat OIDC
+		if !res.Verified {
+			return user.Core{}, errors.New("akses ditolak, verifikasi email akun terlebih dahulu")
+		}
+		if err := uuc.qry.LinkOIDC(res.ID, claims.Subject); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return user.Core{}, errors.New("akses ditolak, akun sudah tertaut dengan identitas oidc lain")
+			}
+			return user.Core{}, errors.New("terdapat masalah pada server")
+		}
+		res.OIDCSubject = claims.Subject
+		return res, nil
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	password, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	hashed, err := helper.GeneratePassword(password)
+	if err != nil {
+		log.Println("bcrypt error ", err.Error())
+		return user.Core{}, errors.New("password process error")
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+	res, err = uuc.qry.Register(user.Core{
+		Name:        name,
+		Email:       claims.Email,
+		Password:    hashed,
+		Role:        helper.RoleUser,
+		OIDCSubject: claims.Subject,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicated") {
+			return user.Core{}, errors.New("data sudah terdaftar")
+		}
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	if err := uuc.qry.SetEmailVerified(res.ID, res.Email); err != nil {
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	res.Verified = true
+
+	return res, nil
+}
+
+// Sessions daftar perangkat yang masih login, sesi dari token yang dipakai ditandai Current.
+func (uuc *userUseCase) Sessions(p auth.Principal) ([]user.SessionCore, error) {
+	id := int(p.UserID)
+	if id <= 0 {
+		return nil, errors.New("data tidak ditemukan")
+	}
+
+	res, err := uuc.qry.ListSessions(uint(id), time.Now().Add(-config.REFRESH_TOKEN_TTL))
+	if err != nil {
+		return nil, errors.New("terdapat masalah pada server")
+	}
+
+	current := p.SessionID
+	for i := range res {
+		res[i].Current = res[i].ID == current
+	}
+
+	return res, nil
+}
+
+func (uuc *userUseCase) RevokeSession(p auth.Principal, sessionID uint) error {
+	id := int(p.UserID)
+	if id <= 0 {
+		return errors.New("data tidak ditemukan")
+	}
+
+	if err := uuc.qry.RevokeSession(uint(id), sessionID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data sesi not found")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: uint(id), Type: user.EventSessionRevoked, Detail: fmt.Sprintf("sesi %d", sessionID), IP: p.IP, UserAgent: p.UserAgent})
+
+	return nil
+}
+
+// inGrace true untuk akun yang dihapus lewat Deactive dan masa tenggangnya belum habis.
+func inGrace(usr user.Core) bool {
+	return usr.Deleted && time.Since(usr.DeletedAt) < config.DELETION_GRACE
+}
+
+// reactivate membatalkan penghapusan akun yang login kembali dalam masa tenggang.
+func (uuc *userUseCase) reactivate(usr *user.Core, client user.ClientInfo) error {
+	if !usr.Deleted {
+		return nil
+	}
+	if err := uuc.qry.Restore(usr.ID); err != nil && !strings.Contains(err.Error(), "not found") {
+		return errors.New("terdapat masalah pada server")
+	}
+	usr.Deleted = false
+	usr.DeletedAt = time.Time{}
+	uuc.recordEvent(user.SecurityEventCore{UserID: usr.ID, Type: user.EventReactivated, IP: client.IP, UserAgent: client.UserAgent})
+
+	return nil
+}
+
+// Export seluruh data akun beserta buku milik user.
+func (uuc *userUseCase) Export(p auth.Principal) (user.ExportCore, error) {
+	id := int(p.UserID)
+	if id <= 0 {
+		return user.ExportCore{}, errors.New("data tidak ditemukan")
+	}
+
+	res, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.ExportCore{}, errors.New("data user not found")
+		}
+		return user.ExportCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	books, err := uuc.qry.OwnedBooks(uint(id))
+	if err != nil {
+		return user.ExportCore{}, errors.New("terdapat masalah pada server")
+	}
+
+	return user.ExportCore{User: res, Books: books, ExportedAt: time.Now()}, nil
+}
+
+// PurgeDeactivated menghapus permanen akun yang masa tenggangnya sudah habis, dijalankan berkala.
+func (uuc *userUseCase) PurgeDeactivated() (int, error) {
+	ids, err := uuc.qry.DeactivatedBefore(time.Now().Add(-config.DELETION_GRACE))
+	if err != nil {
+		return 0, errors.New("terdapat masalah pada server")
+	}
+
+	purged := 0
+	for _, id := range ids {
+		usr, err := uuc.qry.GetByID(id)
+		if err != nil {
+			log.Println("purge user error", id, err.Error())
+			continue
+		}
+		if err := uuc.qry.Purge(id, config.PURGE_BOOKS_TO); err != nil {
+			log.Println("purge user error", id, err.Error())
+			continue
+		}
+		uuc.recordEvent(user.SecurityEventCore{UserID: id, Type: user.EventPurged, Detail: "masa tenggang habis"})
+		uuc.removeAvatar(usr.Avatar)
+		purged++
+	}
+
+	return purged, nil
+}
+
+// RequestEmailChange memulai ganti email. Email baru belum dipakai sampai link konfirmasi
+// yang dikirim ke alamat baru dibuka, alamat lama mendapat pemberitahuan beserta link pembatalan.
+func (uuc *userUseCase) RequestEmailChange(p auth.Principal, newEmail, password string) error {
+	id := int(p.UserID)
+	if id <= 0 {
+		return errors.New("data tidak ditemukan")
+	}
+
+	cur, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data user not found")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	ve := &helper.ValidationError{}
+	if err := uuc.vld.Var(newEmail, "required,email"); err != nil {
+		ve.Add("email", "format email salah")
+	} else if strings.EqualFold(newEmail, cur.Email) {
+		ve.Add("email", "email baru sama dengan email sekarang")
+	}
+	if ve.HasErrors() {
+		return ve
+	}
+
+	// token yang dicuri saja tidak cukup untuk mengganti email. Akun OIDC tidak tahu passwordnya
+	// (dibuat acak), jadi cukup login ulang lewat identity provider sebelum reauthMaxAge lewat.
+	if password == "" && cur.OIDCSubject != "" {
+		if err := uuc.recentLogin(p); err != nil {
+			return err
+		}
+	} else if err := helper.CheckPassword(cur.Password, password); err != nil {
+		return errors.New("password salah")
+	}
+
+	if err := uuc.emailAvailable(newEmail); err != nil {
+		return err
+	}
+
+	confirmToken, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+	revertToken, err := helper.GenerateRandomToken(32)
+	if err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+
+	now := time.Now()
+	change := user.EmailChangeCore{
+		UserID:          cur.ID,
+		OldEmail:        cur.Email,
+		NewEmail:        newEmail,
+		TokenHash:       helper.HashToken(confirmToken),
+		RevertHash:      helper.HashToken(revertToken),
+		ExpiresAt:       now.Add(emailChangeTTL),
+		RevertExpiresAt: now.Add(emailRevertTTL),
+	}
+	if err := uuc.qry.SaveEmailChange(change); err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+
+	confirmBody := fmt.Sprintf("Halo %s,\n\nKlik link berikut untuk memakai email ini di akun kamu (berlaku %d jam):\n%s/users/email/confirm?token=%s",
+		cur.Name, int(emailChangeTTL.Hours()), config.APP_URL, confirmToken)
+	if err := uuc.ml.Send(newEmail, "Konfirmasi email baru", confirmBody); err != nil {
+		log.Println("send email change mail error", err.Error())
+		return errors.New("terdapat masalah pada server")
+	}
+
+	noticeBody := fmt.Sprintf("Halo %s,\n\nAda permintaan mengganti email akun kamu menjadi %s.\n"+
+		"Jika bukan kamu yang meminta, klik link berikut untuk membatalkan atau mengembalikan email (berlaku %d hari). "+
+		"Semua sesi akan diputus dan password harus direset lewat lupa password:\n%s/users/email/revert?token=%s",
+		cur.Name, newEmail, int(emailRevertTTL.Hours()/24), config.APP_URL, revertToken)
+	if err := uuc.ml.Send(cur.Email, "Permintaan ganti email", noticeBody); err != nil {
+		log.Println("send email change notice error", err.Error())
+		return errors.New("terdapat masalah pada server")
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: cur.ID, Type: user.EventEmailChangeRequested, Detail: newEmail, IP: p.IP, UserAgent: p.UserAgent})
+
+	return nil
+}
+
+// recentLogin memastikan sesi token yang dipakai baru saja dibuka lewat login, bukan hasil refresh token lama.
+func (uuc *userUseCase) recentLogin(p auth.Principal) error {
+	const msg = "unauthorized, login ulang lewat oidc terlebih dahulu"
+	if p.SessionID == 0 {
+		return errors.New(msg)
+	}
+
+	session, err := uuc.qry.GetSession(p.SessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New(msg)
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if session.UserID != p.UserID || session.Revoked || time.Since(session.CreatedAt) > reauthMaxAge {
+		return errors.New(msg)
+	}
+
+	return nil
+}
+
+// emailAvailable menolak email yang sudah dipakai akun lain, termasuk akun dalam masa tenggang penghapusan.
+func (uuc *userUseCase) emailAvailable(email string) error {
+	if _, err := uuc.qry.Login(email); err == nil {
+		return errors.New("email sudah terdaftar")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return errors.New("terdapat masalah pada server")
+	}
+	if _, err := uuc.qry.GetDeactivated(email); err == nil {
+		return errors.New("email sudah terdaftar")
+	}
+
+	return nil
+}
+
+func (uuc *userUseCase) ConfirmEmailChange(confirmToken string, client user.ClientInfo) error {
+	change, err := uuc.qry.GetEmailChange(helper.HashToken(confirmToken))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("link konfirmasi email tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if change.Confirmed || change.Reverted || time.Now().After(change.ExpiresAt) {
+		return errors.New("link konfirmasi email tidak valid atau sudah kedaluwarsa")
+	}
+
+	if err := uuc.qry.ConfirmEmailChange(change.ID); err != nil {
+		if strings.Contains(err.Error(), "duplicated") {
+			return errors.New("email sudah terdaftar")
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("link konfirmasi email tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: change.UserID, Type: user.EventEmailChanged, Detail: change.OldEmail + " -> " + change.NewEmail, IP: client.IP, UserAgent: client.UserAgent})
+
+	return nil
+}
+
+// RevertEmailChange dipakai pemilik email lama untuk membatalkan permintaan yang bukan dari dirinya.
+// Karena peminta tahu password, semua sesi diputus dan password wajib direset.
+func (uuc *userUseCase) RevertEmailChange(revertToken string, client user.ClientInfo) error {
+	change, err := uuc.qry.GetEmailChangeByRevert(helper.HashToken(revertToken))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("link pembatalan ganti email tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if change.Reverted || time.Now().After(change.RevertExpiresAt) {
+		return errors.New("link pembatalan ganti email tidak valid atau sudah kedaluwarsa")
+	}
+
+	if err := uuc.qry.RevertEmailChange(change.ID); err != nil {
+		if strings.Contains(err.Error(), "duplicated") {
+			return errors.New("email sudah terdaftar")
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("link pembatalan ganti email tidak valid atau sudah kedaluwarsa")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+
+	if err := uuc.qry.RevokeUserRefreshTokens(change.UserID); err != nil {
+		log.Println("revoke refresh token error", err.Error())
+	}
+	if err := uuc.qry.SetPasswordResetRequired(change.UserID, true); err != nil {
+		log.Println("force password reset error", err.Error())
+	}
+	uuc.recordEvent(user.SecurityEventCore{UserID: change.UserID, Type: user.EventEmailChangeReverted, Detail: change.OldEmail, IP: client.IP, UserAgent: client.UserAgent})
+
+	return nil
+}
+
+// UploadAvatar membuat thumbnail dari gambar yang diupload lalu menyimpannya di blob store.
+// Setiap upload memakai prefix key baru sehingga URL lama di cache tidak menampilkan gambar yang salah.
+func (uuc *userUseCase) UploadAvatar(p auth.Principal, data []byte) (user.Core, error) {
+	id := int(p.UserID)
+	if id <= 0 {
+		return user.Core{}, errors.New("data tidak ditemukan")
+	}
+	if int64(len(data)) > config.AVATAR_MAX_SIZE {
+		return user.Core{}, fmt.Errorf("ukuran avatar terlalu besar, maksimal %d KB", config.AVATAR_MAX_SIZE>>10)
+	}
+	if len(data) == 0 {
+		return user.Core{}, errors.New("format avatar salah, file kosong")
+	}
+
+	thumbs, err := imaging.Thumbnails(data, user.AvatarSizes)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupported) || errors.Is(err, imaging.ErrTooLarge) {
+			return user.Core{}, err
+		}
+		log.Println("avatar process error", err.Error())
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	cur, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return user.Core{}, errors.New("data user not found")
+		}
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+
+	suffix, err := helper.GenerateRandomToken(8)
+	if err != nil {
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	prefix := fmt.Sprintf("avatars/%d/%s", id, suffix)
+	for _, size := range user.AvatarSizes {
+		if err := uuc.bs.Put(user.AvatarKey(prefix, size), thumbs[size], "image/jpeg"); err != nil {
+			log.Println("avatar store error", err.Error())
+			uuc.removeAvatar(prefix)
+			return user.Core{}, errors.New("terdapat masalah pada server")
+		}
+	}
+
+	if _, err := uuc.qry.Update(uint(id), user.Core{Avatar: prefix}, []string{"Avatar"}); err != nil {
+		uuc.removeAvatar(prefix)
+		return user.Core{}, errors.New("terdapat masalah pada server")
+	}
+	uuc.removeAvatar(cur.Avatar)
+
+	cur.Avatar = prefix
+	return cur, nil
+}
+
+// Avatar mengembalikan thumbnail avatar user, size 0 berarti ukuran default.
+func (uuc *userUseCase) Avatar(userID uint, size int) ([]byte, string, error) {
+	if size == 0 {
+		size = avatarDefaultSize
+	}
+	valid := false
+	for _, s := range user.AvatarSizes {
+		if s == size {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, "", fmt.Errorf("ukuran avatar tidak valid, pilih salah satu dari %v", user.AvatarSizes)
+	}
+
+	usr, err := uuc.qry.Profile(userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, "", errors.New("data user not found")
+		}
+		return nil, "", errors.New("terdapat masalah pada server")
+	}
+	if usr.Suspended || usr.Avatar == "" {
+		return nil, "", errors.New("avatar not found")
+	}
+
+	data, contentType, err := uuc.bs.Get(user.AvatarKey(usr.Avatar, size))
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, "", errors.New("avatar not found")
+		}
+		log.Println("avatar get error", err.Error())
+		return nil, "", errors.New("terdapat masalah pada server")
+	}
+
+	return data, contentType, nil
+}
+
+func (uuc *userUseCase) DeleteAvatar(p auth.Principal) error {
+	id := int(p.UserID)
+	if id <= 0 {
+		return errors.New("data tidak ditemukan")
+	}
+
+	cur, err := uuc.qry.Profile(uint(id))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("data user not found")
+		}
+		return errors.New("terdapat masalah pada server")
+	}
+	if cur.Avatar == "" {
+		return errors.New("avatar not found")
+	}
+
+	if _, err := uuc.qry.Update(uint(id), user.Core{}, []string{"Avatar"}); err != nil {
+		return errors.New("terdapat masalah pada server")
+	}
+	uuc.removeAvatar(cur.Avatar)
+
+	return nil
+}
+
+// removeAvatar menghapus semua ukuran avatar, kegagalan hanya dicatat karena data user sudah tidak menunjuk ke sana.
+func (uuc *userUseCase) removeAvatar(prefix string) {
+	if prefix == "" {
+		return
+	}
+	for _, size := range user.AvatarSizes {
+		if err := uuc.bs.Delete(user.AvatarKey(prefix, size)); err != nil {
+			log.Println("avatar delete error", err.Error())
+		}
+	}
+}
+
+// SecurityEvents riwayat keamanan milik user yang sedang login, terbaru lebih dulu.
+func (uuc *userUseCase) SecurityEvents(p auth.Principal, filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error) {
+	if p.UserID == 0 {
+		return nil, 0, errors.New("data tidak ditemukan")
+	}
+	if filter.Type != "" && !user.ValidEventType(filter.Type) {
+		return nil, 0, errors.New("format type event salah")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, 0, errors.New("format rentang waktu salah")
+	}
+	filter.UserID = p.UserID
+	filter.Normalize()
+
+	res, total, err := uuc.qry.ListSecurityEvents(filter)
+	if err != nil {
+		return nil, 0, errors.New("terdapat masalah pada server")
+	}
+
+	return res, total, nil
+}
@@ -1,11 +1,22 @@
 package services
 
 import (
+	"api/auth"
+	"api/blobstore"
+	"api/config"
 	"api/features/user"
 	"api/helper"
 	"api/mocks"
+	"api/oidc"
+	"bytes"
 	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
@@ -13,6 +24,36 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// testClient perangkat yang dipakai semua test login.
+var testClient = user.ClientInfo{IP: "127.0.0.1", UserAgent: "go-test"}
+
+// expectSession memastikan login membuka sesi baru untuk perangkat testClient.
+func expectSession(repo *mocks.UserData, userID uint) {
+	repo.On("SaveSession", mock.MatchedBy(func(session user.SessionCore) bool {
+		return session.UserID == userID && session.FamilyID != "" && session.UserAgent == testClient.UserAgent && session.IP == testClient.IP
+	})).Return(user.SessionCore{ID: 7, UserID: userID}, nil).Once()
+}
+
+// allowLogin menyiapkan mock throttling login untuk akun dan IP tanpa riwayat gagal.
+func allowLogin(repo *mocks.UserData) {
+	repo.On("AccountLoginFailures", mock.Anything, mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+	repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+}
+
+// expectAttempt memastikan audit login ditulis dengan alasan tertentu.
+func expectAttempt(repo *mocks.UserData, reason string) {
+	repo.On("RecordLoginAttempt", mock.MatchedBy(func(attempt user.LoginAttemptCore) bool {
+		return attempt.Reason == reason && attempt.IP == "127.0.0.1"
+	})).Return(nil).Once()
+}
+
+// expectEvent memastikan riwayat keamanan ditulis untuk user dan jenis event tertentu.
+func expectEvent(repo *mocks.UserData, userID uint, eventType string) {
+	repo.On("SaveSecurityEvent", mock.MatchedBy(func(event user.SecurityEventCore) bool {
+		return event.UserID == userID && event.Type == eventType
+	})).Return(nil).Once()
+}
+
 func TestLogin(t *testing.T) {
 	repo := mocks.NewUserData(t) // mock data
 
@@ -21,36 +62,106 @@ func TestLogin(t *testing.T) {
 		inputEmail := "jerry@alterra.id"
 		// res dari data akan mengembalik password yang sudah di hash
 		hashed, _ := helper.GeneratePassword("be1422")
-		resData := user.Core{ID: uint(1), Name: "jerry", Email: "jerry@alterra.id", HP: "08123456", Password: hashed}
+		resData := user.Core{ID: uint(1), Name: "jerry", Email: "jerry@alterra.id", HP: "08123456", Password: hashed, Verified: true}
 
+		allowLogin(repo)
 		repo.On("Login", inputEmail).Return(resData, nil).Once() // simulasi method login pada layer data
-
-		srv := New(repo)
-		token, res, err := srv.Login(inputEmail, "be1422")
+		expectSession(repo, 1)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		repo.On("RecordLoginAttempt", mock.MatchedBy(func(attempt user.LoginAttemptCore) bool {
+			return attempt.Success && attempt.UserID == 1 && attempt.Email == inputEmail && attempt.UserAgent == testClient.UserAgent
+		})).Return(nil).Once()
+		repo.On("SaveSecurityEvent", mock.MatchedBy(func(event user.SecurityEventCore) bool {
+			return event.UserID == 1 && event.Type == user.EventLoginSuccess && event.IP == testClient.IP && event.UserAgent == testClient.UserAgent
+		})).Return(nil).Once()
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		token, res, err := srv.Login(inputEmail, "be1422", testClient)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, token)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.NotEmpty(t, token.RefreshToken)
 		assert.Equal(t, resData.ID, res.ID)
 		repo.AssertExpectations(t)
 	})
 
+	t.Run("akun ditangguhkan", func(t *testing.T) {
+		inputEmail := "jerry@alterra.id"
+		hashed, _ := helper.GeneratePassword("be1422")
+		resData := user.Core{ID: uint(1), Email: inputEmail, Password: hashed, Suspended: true}
+		allowLogin(repo)
+		repo.On("Login", inputEmail).Return(resData, nil).Once()
+		expectAttempt(repo, user.LoginStatusDenied)
+		expectEvent(repo, 1, user.EventLoginFailed)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		token, _, err := srv.Login(inputEmail, "be1422", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditangguhkan")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
 	t.Run("Tidak ditemukan", func(t *testing.T) {
 		inputEmail := "putra@alterra.id"
+		allowLogin(repo)
 		repo.On("Login", inputEmail).Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("GetDeactivated", inputEmail).Return(user.Core{}, errors.New("data not found")).Once()
+		expectAttempt(repo, user.LoginUnknownEmail)
 
-		srv := New(repo)
-		token, res, err := srv.Login(inputEmail, "be1422")
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		token, res, err := srv.Login(inputEmail, "be1422", testClient)
 		assert.NotNil(t, err)
-		assert.ErrorContains(t, err, "tidak ditemukan")
+		assert.EqualError(t, err, "email atau password salah")
 		assert.Empty(t, token)
 		assert.Equal(t, uint(0), res.ID)
 		repo.AssertExpectations(t)
 	})
+	t.Run("login dalam masa tenggang membatalkan penghapusan", func(t *testing.T) {
+		inputEmail := "jerry@alterra.id"
+		hashed, _ := helper.GeneratePassword("be1422")
+		deleted := user.Core{ID: 1, Email: inputEmail, Password: hashed, Verified: true, Deleted: true, DeletedAt: time.Now().Add(-24 * time.Hour)}
+
+		allowLogin(repo)
+		repo.On("Login", inputEmail).Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("GetDeactivated", inputEmail).Return(deleted, nil).Once()
+		repo.On("Restore", uint(1)).Return(nil).Once()
+		expectSession(repo, 1)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		expectAttempt(repo, user.LoginSuccess)
+		expectEvent(repo, 1, user.EventReactivated)
+		expectEvent(repo, 1, user.EventLoginSuccess)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		token, res, err := srv.Login(inputEmail, "be1422", testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.False(t, res.Deleted)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("masa tenggang sudah habis", func(t *testing.T) {
+		inputEmail := "jerry@alterra.id"
+		hashed, _ := helper.GeneratePassword("be1422")
+		deleted := user.Core{ID: 1, Email: inputEmail, Password: hashed, Verified: true, Deleted: true, DeletedAt: time.Now().Add(-config.DELETION_GRACE - time.Hour)}
+
+		allowLogin(repo)
+		repo.On("Login", inputEmail).Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("GetDeactivated", inputEmail).Return(deleted, nil).Once()
+		expectAttempt(repo, user.LoginUnknownEmail)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		_, _, err := srv.Login(inputEmail, "be1422", testClient)
+		assert.EqualError(t, err, "email atau password salah")
+		repo.AssertExpectations(t)
+	})
+
 	t.Run("server error", func(t *testing.T) {
 		inputEmail := "jerry@alterra.id"
 
+		allowLogin(repo)
 		repo.On("Login", inputEmail).Return(user.Core{}, errors.New("terdapat masalah pada server")).Once()
-		srv := New(repo)
-		token, res, err := srv.Login(inputEmail, "be1422")
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		token, res, err := srv.Login(inputEmail, "be1422", testClient)
 
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "terdapat masalah pada server")
@@ -63,17 +174,189 @@ func TestLogin(t *testing.T) {
 		inputEmail := "jerry@alterra.id"
 		hashed, _ := helper.GeneratePassword("be1422")
 		resData := user.Core{ID: uint(1), Name: "helmi", Email: "jerry@alterra.id", HP: "08123456", Password: hashed}
+		allowLogin(repo)
 		repo.On("Login", inputEmail).Return(resData, nil).Once()
-		srv := New(repo)
-		token, res, err := srv.Login(inputEmail, "asal")
+		expectAttempt(repo, user.LoginWrongPassword)
+		expectEvent(repo, 1, user.EventLoginFailed)
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		token, res, err := srv.Login(inputEmail, "asal", testClient)
 
 		assert.NotNil(t, err)
-		assert.ErrorContains(t, err, "password tidak sesuai")
+		// pesan sama dengan email tidak terdaftar dan tidak membocorkan hash password
+		assert.EqualError(t, err, "email atau password salah")
+		assert.NotContains(t, err.Error(), hashed)
 		assert.Empty(t, token)
 		assert.Equal(t, uint(0), res.ID)
 		repo.AssertExpectations(t)
 	})
 
+	t.Run("jeda setelah beberapa kali gagal", func(t *testing.T) {
+		repo.On("AccountLoginFailures", "jerry@alterra.id", mock.Anything).Return(user.LoginFailureCore{Count: 5, Last: time.Now()}, nil).Once()
+		repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+		expectAttempt(repo, user.LoginThrottled)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		_, _, err := srv.Login(" Jerry@alterra.id", "be1422", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "terlalu banyak percobaan login, coba lagi dalam 4 detik")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("jeda sudah lewat", func(t *testing.T) {
+		inputEmail := "jerry@alterra.id"
+		hashed, _ := helper.GeneratePassword("be1422")
+		repo.On("AccountLoginFailures", inputEmail, mock.Anything).Return(user.LoginFailureCore{Count: 3, Last: time.Now().Add(-2 * time.Second)}, nil).Once()
+		repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+		repo.On("Login", inputEmail).Return(user.Core{ID: 1, Password: hashed}, nil).Once()
+		expectAttempt(repo, user.LoginWrongPassword)
+		expectEvent(repo, 1, user.EventLoginFailed)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		_, _, err := srv.Login(inputEmail, "asal", testClient)
+		assert.EqualError(t, err, "email atau password salah")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun terkunci", func(t *testing.T) {
+		repo.On("AccountLoginFailures", "jerry@alterra.id", mock.Anything).Return(user.LoginFailureCore{Count: 10, Last: time.Now().Add(-time.Minute)}, nil).Once()
+		repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+		expectAttempt(repo, user.LoginThrottled)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		_, _, err := srv.Login("jerry@alterra.id", "be1422", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "terlalu banyak percobaan login")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("IP terkunci", func(t *testing.T) {
+		repo.On("AccountLoginFailures", "jerry@alterra.id", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+		repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{Count: 50, Last: time.Now()}, nil).Once()
+		expectAttempt(repo, user.LoginThrottled)
+
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		_, _, err := srv.Login("jerry@alterra.id", "be1422", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "terlalu banyak percobaan login")
+		repo.AssertExpectations(t)
+	})
+
+}
+
+func TestRefresh(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	t.Run("Berhasil refresh token", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		repo.On("GetRefreshToken", helper.HashToken("refresh-lama")).Return(stored, nil).Once()
+		repo.On("UseRefreshToken", uint(1)).Return(nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Role: helper.RoleAdmin, Verified: true}, nil).Once()
+		repo.On("GetSessionByFamily", "family").Return(user.SessionCore{ID: 7, UserID: 1, FamilyID: "family"}, nil).Once()
+		repo.On("TouchSession", uint(7), testClient, mock.Anything).Return(nil).Once()
+		repo.On("SaveRefreshToken", mock.MatchedBy(func(newToken user.RefreshTokenCore) bool {
+			return newToken.FamilyID == "family" && newToken.UserID == 1
+		})).Return(nil).Once()
+
+		token, err := srv.Refresh("refresh-lama", testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		parsed, _ := jwt.Parse(token.AccessToken, func(*jwt.Token) (interface{}, error) { return []byte(config.JWT_KEY), nil })
+		claims := parsed.Claims.(jwt.MapClaims)
+		assert.Equal(t, helper.RoleAdmin, claims["role"])
+		assert.Equal(t, float64(7), claims["sid"])
+		assert.NotEqual(t, "refresh-lama", token.RefreshToken)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token tidak ditemukan", func(t *testing.T) {
+		repo.On("GetRefreshToken", mock.Anything).Return(user.RefreshTokenCore{}, errors.New("data not found")).Once()
+
+		token, err := srv.Refresh("asal", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "refresh token tidak valid")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token dipakai ulang", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour), Used: true}
+		repo.On("GetRefreshToken", mock.Anything).Return(stored, nil).Once()
+		repo.On("RevokeTokenFamily", "family").Return(nil).Once()
+		expectEvent(repo, 1, user.EventRefreshTokenReuse)
+
+		token, err := srv.Refresh("refresh-lama", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah dipakai")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun sudah dinonaktifkan", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		repo.On("GetRefreshToken", mock.Anything).Return(stored, nil).Once()
+		repo.On("UseRefreshToken", uint(1)).Return(nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{}, errors.New("record not found")).Once()
+
+		token, err := srv.Refresh("refresh-lama", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak aktif")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token kedaluwarsa", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(-time.Minute)}
+		repo.On("GetRefreshToken", mock.Anything).Return(stored, nil).Once()
+
+		token, err := srv.Refresh("refresh-lama", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "kedaluwarsa")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token dari sebelum ada sesi", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "lama", ExpiresAt: time.Now().Add(time.Hour)}
+		repo.On("GetRefreshToken", mock.Anything).Return(stored, nil).Once()
+		repo.On("UseRefreshToken", uint(1)).Return(nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Verified: true}, nil).Once()
+		repo.On("GetSessionByFamily", "lama").Return(user.SessionCore{}, errors.New("session not found")).Once()
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+
+		token, err := srv.Refresh("refresh-lama", testClient)
+		assert.Nil(t, err)
+		parsed, _ := jwt.Parse(token.AccessToken, func(*jwt.Token) (interface{}, error) { return []byte(config.JWT_KEY), nil })
+		assert.NotContains(t, parsed.Claims.(jwt.MapClaims), "sid")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("sesi sudah dicabut", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		repo.On("GetRefreshToken", mock.Anything).Return(stored, nil).Once()
+		repo.On("UseRefreshToken", uint(1)).Return(nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Verified: true}, nil).Once()
+		repo.On("GetSessionByFamily", "family").Return(user.SessionCore{ID: 7, UserID: 1, Revoked: true}, nil).Once()
+
+		token, err := srv.Refresh("refresh-lama", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "dicabut")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("balapan refresh bersamaan", func(t *testing.T) {
+		stored := user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		repo.On("GetRefreshToken", mock.Anything).Return(stored, nil).Once()
+		repo.On("UseRefreshToken", uint(1)).Return(errors.New("refresh token not found")).Once()
+		repo.On("RevokeTokenFamily", "family").Return(nil).Once()
+		expectEvent(repo, 1, user.EventRefreshTokenReuse)
+
+		_, err := srv.Refresh("refresh-lama", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah dipakai")
+		repo.AssertExpectations(t)
+	})
 }
 
 func TestProfile(t *testing.T) {
@@ -84,12 +367,9 @@ func TestProfile(t *testing.T) {
 
 		repo.On("Profile", uint(1)).Return(resData, nil).Once()
 
-		srv := New(repo)
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
 
-		_, token := helper.GenerateJWT(1)
-
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
 
 		res, err := srv.Profile(pToken)
 		assert.Nil(t, err)
@@ -98,9 +378,9 @@ func TestProfile(t *testing.T) {
 	})
 
 	t.Run("jwt tidak valid", func(t *testing.T) {
-		srv := New(repo)
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
 
-		_, token := helper.GenerateJWT(1)
+		token := auth.Principal{}
 
 		res, err := srv.Profile(token)
 		assert.NotNil(t, err)
@@ -111,11 +391,9 @@ func TestProfile(t *testing.T) {
 	t.Run("data tidak ditemukan", func(t *testing.T) {
 		repo.On("Profile", uint(4)).Return(user.Core{}, errors.New("data not found")).Once()
 
-		srv := New(repo)
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
 
-		_, token := helper.GenerateJWT(4)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		pToken := auth.Principal{UserID: 4, Roles: []string{helper.RoleUser}}
 		res, err := srv.Profile(pToken)
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "tidak ditemukan")
@@ -125,11 +403,9 @@ func TestProfile(t *testing.T) {
 
 	t.Run("masalah di server", func(t *testing.T) {
 		repo.On("Profile", mock.Anything).Return(user.Core{}, errors.New("terdapat masalah pada server")).Once()
-		srv := New(repo)
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
 		res, err := srv.Profile(pToken)
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "server")
@@ -140,7 +416,7 @@ func TestProfile(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	repo := mocks.NewUserData(t)
-	service := New(repo)
+	service := New(repo, mocks.NewMailer(t), nil, nil, nil)
 
 	// Case: user mengganti nama
 	t.Run("Update successfully", func(t *testing.T) {
@@ -148,22 +424,61 @@ func TestUpdate(t *testing.T) {
 		hashed, _ := helper.GeneratePassword("be1422")
 
 		resData := user.Core{ID: uint(1), Name: "dendy", Email: "fajar@gmail.com", Alamat: "jakart", HP: "081222222", Password: hashed}
-		repo.On("Update", uint(1), input).Return(resData, nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Email: "fajar@gmail.com"}, nil).Once()
+		repo.On("Update", uint(1), user.Core{Name: "dendy", Alamat: "jakart", HP: "081222222"}, []string{"Name", "Alamat", "HP"}).Return(resData, nil).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		res, err := service.Update(token, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		res, err := service.Update(pToken, input)
 
 		assert.NoError(t, err)
 		assert.Equal(t, resData.ID, res.ID)
 		repo.AssertExpectations(t)
 	})
 
+	// Case: email tidak bisa diganti langsung
+	t.Run("Update error ganti email", func(t *testing.T) {
+		input := user.Core{Name: "dendy", Email: "lain@gmail.com"}
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Email: "fajar@gmail.com"}, nil).Once()
+
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		_, err := service.Update(pToken, input)
+
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "email")
+		repo.AssertExpectations(t)
+	})
+
+	// Case: user mengganti password
+	t.Run("Update password", func(t *testing.T) {
+		input := user.Core{Password: "PasswordBaru77"}
+		repo.On("RecentPasswords", uint(1), 5).Return([]string{}, nil).Once()
+		repo.On("Update", uint(1), mock.MatchedBy(func(updateData user.Core) bool {
+			return helper.CheckPassword(updateData.Password, "PasswordBaru77") == nil
+		}), []string{"Password"}).Return(user.Core{ID: 1}, nil).Once()
+		expectEvent(repo, 1, user.EventPasswordChanged)
+
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		res, err := service.Update(pToken, input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), res.ID)
+		repo.AssertExpectations(t)
+	})
+
+	// Case: password baru tidak memenuhi policy
+	t.Run("Update error password policy", func(t *testing.T) {
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		_, err := service.Update(pToken, user.Core{Password: "pendek"})
+
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "password minimal 8 karakter")
+	})
+
 	// Case: id user tidak valid atau tidak ditemukan
 	t.Run("Update error invalid id", func(t *testing.T) {
 		input := user.Core{Name: "dendy", Email: "fajar@gmail.com", Alamat: "jakart", HP: "081222222"}
-		token := jwt.New(jwt.SigningMethodHS256)
+		token := auth.Principal{}
 		res, err := service.Update(token, input)
 
 		assert.NotNil(t, err)
@@ -178,12 +493,10 @@ func TestUpdate(t *testing.T) {
 		input := user.Core{Name: "dendy"}
 
 		resData := user.Core{}
-		repo.On("Update", uint(1), input).Return(resData, errors.New("not found")).Once()
+		repo.On("Update", uint(1), input, []string{"Name"}).Return(resData, errors.New("not found")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		res, err := service.Update(token, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		res, err := service.Update(pToken, input)
 
 		assert.NotNil(t, err)
 		assert.EqualError(t, err, "data user not found")
@@ -197,12 +510,10 @@ func TestUpdate(t *testing.T) {
 		input := user.Core{Name: "dendy"}
 
 		resData := user.Core{}
-		repo.On("Update", uint(1), input).Return(resData, errors.New("internal server error")).Once()
+		repo.On("Update", uint(1), input, []string{"Name"}).Return(resData, errors.New("internal server error")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		res, err := service.Update(token, input)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		res, err := service.Update(pToken, input)
 
 		assert.NotNil(t, err)
 		assert.EqualError(t, err, "internal server error")
@@ -235,10 +546,10 @@ func TestDeactive(t *testing.T) {
 		}
 
 		repo.On("Deactive", uint(sample.ID)).Return(Respon, nil).Once()
-		srv := New(repo)
-		_, token := helper.GenerateJWT(sample.ID)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
+		repo.On("RevokeUserRefreshTokens", uint(sample.ID)).Return(nil).Once()
+		expectEvent(repo, uint(sample.ID), user.EventDeactivated)
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		pToken := auth.Principal{UserID: uint(sample.ID), Roles: []string{helper.RoleUser}}
 		res, err := srv.Deactive(pToken)
 
 		assert.NoError(t, err)
@@ -247,18 +558,9 @@ func TestDeactive(t *testing.T) {
 	})
 
 	t.Run("jwt tidak valid", func(t *testing.T) {
-		type SampleUsers struct {
-			ID   int
-			Name string
-		}
-		sample := SampleUsers{
-			ID:   4,
-			Name: "fajar1411",
-		}
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
 
-		srv := New(repo)
-
-		_, token := helper.GenerateJWT(sample.ID)
+		token := auth.Principal{}
 
 		res, err := srv.Deactive(token)
 		assert.NotNil(t, err)
@@ -268,11 +570,9 @@ func TestDeactive(t *testing.T) {
 	t.Run("Deactive error user not found", func(t *testing.T) {
 		repo.On("Deactive", uint(1)).Return(user.Core{}, errors.New("not found")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		srv := New(repo)
-		res, err := srv.Deactive(token)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		res, err := srv.Deactive(pToken)
 
 		assert.NotNil(t, err)
 		assert.EqualError(t, err, "data tidak ditemukan")
@@ -284,11 +584,9 @@ func TestDeactive(t *testing.T) {
 	t.Run("Deactive  server error", func(t *testing.T) {
 		repo.On("Deactive", uint(1)).Return(user.Core{}, errors.New("internal server error")).Once()
 
-		_, token := helper.GenerateJWT(1)
-		pToken := token.(*jwt.Token)
-		pToken.Valid = true
-		srv := New(repo)
-		res, err := srv.Deactive(token)
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		res, err := srv.Deactive(pToken)
 
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "server")
@@ -296,10 +594,208 @@ func TestDeactive(t *testing.T) {
 		repo.AssertExpectations(t)
 	})
 }
+func TestLogout(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	t.Run("Berhasil logout", func(t *testing.T) {
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, TokenID: "jti-1", ExpiresAt: time.Now().Add(time.Minute), IP: testClient.IP, UserAgent: testClient.UserAgent}
+
+		repo.On("RevokeToken", "jti-1", uint(1), pToken.ExpiresAt).Return(nil).Once()
+		repo.On("GetRefreshToken", helper.HashToken("refresh")).Return(user.RefreshTokenCore{ID: 1, UserID: 1, FamilyID: "family"}, nil).Once()
+		repo.On("RevokeTokenFamily", "family").Return(nil).Once()
+		// perangkat diambil dari principal supaya riwayat keamanan menunjukkan asal logout
+		repo.On("SaveSecurityEvent", mock.MatchedBy(func(event user.SecurityEventCore) bool {
+			return event.Type == user.EventLogout && event.IP == testClient.IP && event.UserAgent == testClient.UserAgent
+		})).Return(nil).Once()
+
+		err := srv.Logout(pToken, "refresh")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("refresh token milik user lain tidak dicabut", func(t *testing.T) {
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, TokenID: "jti-1"}
+
+		repo.On("RevokeToken", mock.Anything, uint(1), mock.Anything).Return(nil).Once()
+		repo.On("GetRefreshToken", mock.Anything).Return(user.RefreshTokenCore{ID: 2, UserID: 2, FamilyID: "lain"}, nil).Once()
+		expectEvent(repo, 1, user.EventLogout)
+
+		err := srv.Logout(pToken, "refresh")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("jwt tidak valid", func(t *testing.T) {
+		token := auth.Principal{}
+
+		err := srv.Logout(token, "")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "token tidak valid")
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, TokenID: "jti-1"}
+
+		repo.On("RevokeToken", mock.Anything, uint(1), mock.Anything).Return(errors.New("database error")).Once()
+
+		err := srv.Logout(pToken, "")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestCheckToken(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, TokenID: "jti-1"}
+	jti := pToken.TokenID
+
+	t.Run("token masih berlaku", func(t *testing.T) {
+		repo.On("IsTokenRevoked", jti).Return(false, nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Verified: true}, nil).Once()
+
+		err := srv.CheckToken(pToken)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("token sudah logout", func(t *testing.T) {
+		repo.On("IsTokenRevoked", jti).Return(true, nil).Once()
+
+		err := srv.CheckToken(pToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "dicabut")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun sudah dinonaktifkan", func(t *testing.T) {
+		repo.On("IsTokenRevoked", jti).Return(false, nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{}, errors.New("record not found")).Once()
+
+		err := srv.CheckToken(pToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak aktif")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("admin yang sudah diturunkan jadi user", func(t *testing.T) {
+		adminToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleAdmin}, TokenID: jti}
+		repo.On("IsTokenRevoked", jti).Return(false, nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Role: helper.RoleUser, Verified: true}, nil).Once()
+
+		err := srv.CheckToken(adminToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "role akun sudah berubah")
+		code, _ := helper.PrintErrorResponse(err.Error())
+		assert.Equal(t, http.StatusUnauthorized, code)
+		repo.AssertExpectations(t)
+	})
+
+	sessToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, SessionID: 7, TokenID: "jti-2"}
+	sessJTI := sessToken.TokenID
+
+	t.Run("sesi masih aktif", func(t *testing.T) {
+		repo.On("IsTokenRevoked", sessJTI).Return(false, nil).Once()
+		repo.On("GetSession", uint(7)).Return(user.SessionCore{ID: 7, UserID: 1, LastSeenAt: time.Now()}, nil).Once()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Verified: true}, nil).Once()
+
+		err := srv.CheckToken(sessToken)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("sesi sudah dicabut dari perangkat lain", func(t *testing.T) {
+		repo.On("IsTokenRevoked", sessJTI).Return(false, nil).Once()
+		repo.On("GetSession", uint(7)).Return(user.SessionCore{ID: 7, UserID: 1, Revoked: true}, nil).Once()
+
+		err := srv.CheckToken(sessToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "dicabut")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("sesi milik user lain", func(t *testing.T) {
+		repo.On("IsTokenRevoked", sessJTI).Return(false, nil).Once()
+		repo.On("GetSession", uint(7)).Return(user.SessionCore{ID: 7, UserID: 2, LastSeenAt: time.Now()}, nil).Once()
+
+		err := srv.CheckToken(sessToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "dicabut")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSessions(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, SessionID: 7}
+
+	t.Run("Berhasil lihat sesi", func(t *testing.T) {
+		resData := []user.SessionCore{{ID: 7, UserID: 1, UserAgent: "go-test"}, {ID: 8, UserID: 1, UserAgent: "curl"}}
+		repo.On("ListSessions", uint(1), mock.Anything).Return(resData, nil).Once()
+
+		res, err := srv.Sessions(pToken)
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+		assert.True(t, res[0].Current)
+		assert.False(t, res[1].Current)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("ListSessions", uint(1), mock.Anything).Return(nil, errors.New("database error")).Once()
+
+		res, err := srv.Sessions(pToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		assert.Nil(t, res)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, SessionID: 7}
+
+	t.Run("Berhasil akhiri sesi", func(t *testing.T) {
+		repo.On("RevokeSession", uint(1), uint(8)).Return(nil).Once()
+		expectEvent(repo, 1, user.EventSessionRevoked)
+
+		err := srv.RevokeSession(pToken, 8)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("sesi tidak ditemukan", func(t *testing.T) {
+		repo.On("RevokeSession", uint(1), uint(99)).Return(errors.New("session not found")).Once()
+
+		err := srv.RevokeSession(pToken, 99)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("RevokeSession", uint(1), uint(8)).Return(errors.New("database error")).Once()
+
+		err := srv.RevokeSession(pToken, 8)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
+
 func TestRegister(t *testing.T) {
 	repo := mocks.NewUserData(t)
+	ml := mocks.NewMailer(t)
 
-	srv := New(repo)
+	srv := New(repo, ml, nil, nil, nil)
 
 	// Case: user melakukan pendaftaran akun baru
 	t.Run("Register successfully", func(t *testing.T) {
@@ -317,13 +813,13 @@ func TestRegister(t *testing.T) {
 			ID:       1,
 			Name:     "fajar1411",
 			Email:    "frizky@gmail.com",
-			Password: "12345",
+			Password: "Buku-Langka-77",
 			Alamat:   "jakart",
 			HP:       "0132334343",
 		}
 		input := user.Core{
 			Name:     sample.Name,
-			Email:    sample.Alamat,
+			Email:    sample.Email,
 			Password: sample.Password,
 			Alamat:   sample.Alamat,
 			HP:       sample.HP,
@@ -340,6 +836,10 @@ func TestRegister(t *testing.T) {
 			HP:       sample.HP,
 		}
 		repo.On("Register", mock.Anything).Return(resData, nil).Once()
+		ml.On("Send", input.Email, "Verifikasi email", mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "/verify-email?token=")
+		})).Return(nil).Once()
+		repo.On("SetVerificationSent", resData.ID, mock.Anything).Return(nil).Once()
 		data, err := srv.Register(input)
 
 		assert.Nil(t, err)
@@ -347,6 +847,14 @@ func TestRegister(t *testing.T) {
 		assert.NoError(t, errCompare)
 		assert.Equal(t, data.ID, resData.ID)
 		repo.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+	t.Run("Register error format email", func(t *testing.T) {
+		data, err := srv.Register(user.Core{Name: "fajar1411", Email: "jakart", Password: "Buku-Langka-77"})
+
+		assert.NotNil(t, err)
+		assert.EqualError(t, err, "format email salah")
+		assert.Empty(t, data)
 	})
 	t.Run("Register error data duplicate", func(t *testing.T) {
 		type SampleUsers struct {
@@ -361,13 +869,13 @@ func TestRegister(t *testing.T) {
 			ID:       1,
 			Name:     "fajar1411",
 			Email:    "frizky@gmail.com",
-			Password: "12345",
+			Password: "Buku-Langka-77",
 			Alamat:   "jakart",
 			HP:       "0132334343",
 		}
 		input := user.Core{
 			Name:     sample.Name,
-			Email:    sample.Alamat,
+			Email:    sample.Email,
 			Password: sample.Password,
 			Alamat:   sample.Alamat,
 			HP:       sample.HP,
@@ -398,13 +906,13 @@ func TestRegister(t *testing.T) {
 			ID:       1,
 			Name:     "fajar1411",
 			Email:    "frizky@gmail.com",
-			Password: "12345",
+			Password: "Buku-Langka-77",
 			Alamat:   "jakart",
 			HP:       "0132334343",
 		}
 		input := user.Core{
 			Name:     sample.Name,
-			Email:    sample.Alamat,
+			Email:    sample.Email,
 			Password: sample.Password,
 			Alamat:   sample.Alamat,
 			HP:       sample.HP,
@@ -423,3 +931,1108 @@ func TestRegister(t *testing.T) {
 		repo.AssertExpectations(t)
 	})
 }
+
+func TestForgotPassword(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	ml := mocks.NewMailer(t)
+	srv := New(repo, ml, nil, nil, nil)
+
+	t.Run("Berhasil kirim link reset", func(t *testing.T) {
+		repo.On("Login", "jerry@alterra.id").Return(user.Core{ID: 1, Name: "jerry", Email: "jerry@alterra.id"}, nil).Once()
+		repo.On("SavePasswordReset", mock.MatchedBy(func(reset user.PasswordResetCore) bool {
+			return reset.UserID == 1 && len(reset.TokenHash) == 64 && reset.ExpiresAt.After(time.Now())
+		})).Return(nil).Once()
+		ml.On("Send", "jerry@alterra.id", "Reset password", mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "/password/reset?token=")
+		})).Return(nil).Once()
+
+		err := srv.ForgotPassword("jerry@alterra.id")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+
+	t.Run("email tidak terdaftar tetap sukses", func(t *testing.T) {
+		repo.On("Login", "putra@alterra.id").Return(user.Core{}, errors.New("data not found")).Once()
+
+		err := srv.ForgotPassword("putra@alterra.id")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("gagal kirim email", func(t *testing.T) {
+		repo.On("Login", "jerry@alterra.id").Return(user.Core{ID: 1, Email: "jerry@alterra.id"}, nil).Once()
+		repo.On("SavePasswordReset", mock.Anything).Return(nil).Once()
+		ml.On("Send", "jerry@alterra.id", mock.Anything, mock.Anything).Return(errors.New("gagal mengirim email")).Once()
+
+		err := srv.ForgotPassword("jerry@alterra.id")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	t.Run("Berhasil reset password", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+		oldHash, _ := helper.GeneratePassword("Buku-Lama-01")
+		repo.On("GetPasswordReset", helper.HashToken("reset")).Return(stored, nil).Once()
+		repo.On("RecentPasswords", uint(1), 5).Return([]string{oldHash}, nil).Once()
+		repo.On("UsePasswordReset", uint(3), uint(1), mock.MatchedBy(func(hashed string) bool {
+			return helper.CheckPassword(hashed, "PasswordBaru77") == nil
+		})).Return(nil).Once()
+		expectEvent(repo, 1, user.EventPasswordReset)
+
+		err := srv.ResetPassword("reset", "PasswordBaru77", testClient)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("password gagal diganti", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+		repo.On("GetPasswordReset", helper.HashToken("reset")).Return(stored, nil).Once()
+		repo.On("RecentPasswords", uint(1), 5).Return([]string{}, nil).Once()
+		repo.On("UsePasswordReset", uint(3), uint(1), mock.Anything).Return(errors.New("database error")).Once()
+
+		err := srv.ResetPassword("reset", "PasswordBaru77", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link dipakai bersamaan", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+		repo.On("GetPasswordReset", helper.HashToken("reset")).Return(stored, nil).Once()
+		repo.On("RecentPasswords", uint(1), 5).Return([]string{}, nil).Once()
+		repo.On("UsePasswordReset", uint(3), uint(1), mock.Anything).Return(errors.New("password reset not found")).Once()
+
+		err := srv.ResetPassword("reset", "PasswordBaru77", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("password lemah tidak memakai link", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+		repo.On("GetPasswordReset", helper.HashToken("reset")).Return(stored, nil).Once()
+
+		err := srv.ResetPassword("reset", "passwordbaru", testClient)
+		assert.NotNil(t, err)
+		assert.IsType(t, &helper.ValidationError{}, err)
+		assert.ErrorContains(t, err, "huruf besar")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("password lama dipakai ulang", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}
+		oldHash, _ := helper.GeneratePassword("Buku-Lama-01")
+		repo.On("GetPasswordReset", helper.HashToken("reset")).Return(stored, nil).Once()
+		repo.On("RecentPasswords", uint(1), 5).Return([]string{oldHash}, nil).Once()
+
+		err := srv.ResetPassword("reset", "Buku-Lama-01", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "5 password terakhir")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link sudah dipakai", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(time.Minute), Used: true}
+		repo.On("GetPasswordReset", mock.Anything).Return(stored, nil).Once()
+
+		err := srv.ResetPassword("reset", "PasswordBaru77", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link kedaluwarsa", func(t *testing.T) {
+		stored := user.PasswordResetCore{ID: 3, UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}
+		repo.On("GetPasswordReset", mock.Anything).Return(stored, nil).Once()
+
+		err := srv.ResetPassword("reset", "PasswordBaru77", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "kedaluwarsa")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link tidak ditemukan", func(t *testing.T) {
+		repo.On("GetPasswordReset", mock.Anything).Return(user.PasswordResetCore{}, errors.New("data not found")).Once()
+
+		err := srv.ResetPassword("asal", "PasswordBaru77", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	t.Run("Berhasil verifikasi email", func(t *testing.T) {
+		verifyToken := helper.GenerateSignedToken("verify_email", 1, "jerry@alterra.id", time.Hour)
+		repo.On("SetEmailVerified", uint(1), "jerry@alterra.id").Return(nil).Once()
+
+		err := srv.VerifyEmail(verifyToken)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link kedaluwarsa", func(t *testing.T) {
+		verifyToken := helper.GenerateSignedToken("verify_email", 1, "jerry@alterra.id", -time.Hour)
+
+		err := srv.VerifyEmail(verifyToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+	})
+
+	t.Run("token untuk keperluan lain ditolak", func(t *testing.T) {
+		verifyToken := helper.GenerateSignedToken("lainnya", 1, "jerry@alterra.id", time.Hour)
+
+		err := srv.VerifyEmail(verifyToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+	})
+
+	t.Run("email sudah berubah", func(t *testing.T) {
+		verifyToken := helper.GenerateSignedToken("verify_email", 1, "lama@alterra.id", time.Hour)
+		repo.On("SetEmailVerified", uint(1), "lama@alterra.id").Return(errors.New("record not found")).Once()
+
+		err := srv.VerifyEmail(verifyToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	ml := mocks.NewMailer(t)
+	srv := New(repo, ml, nil, nil, nil)
+
+	t.Run("Berhasil kirim ulang", func(t *testing.T) {
+		resData := user.Core{ID: 1, Email: "jerry@alterra.id", VerificationSentAt: time.Now().Add(-time.Hour)}
+		repo.On("Login", "jerry@alterra.id").Return(resData, nil).Once()
+		ml.On("Send", "jerry@alterra.id", "Verifikasi email", mock.Anything).Return(nil).Once()
+		repo.On("SetVerificationSent", uint(1), mock.Anything).Return(nil).Once()
+
+		err := srv.ResendVerification("jerry@alterra.id")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+
+	t.Run("terlalu sering", func(t *testing.T) {
+		resData := user.Core{ID: 1, Email: "jerry@alterra.id", VerificationSentAt: time.Now().Add(-10 * time.Second)}
+		repo.On("Login", "jerry@alterra.id").Return(resData, nil).Once()
+
+		err := srv.ResendVerification("jerry@alterra.id")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "terlalu banyak")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("sudah terverifikasi", func(t *testing.T) {
+		repo.On("Login", "jerry@alterra.id").Return(user.Core{ID: 1, Verified: true}, nil).Once()
+
+		err := srv.ResendVerification("jerry@alterra.id")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestLoginMFA(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	secret, _ := helper.GenerateTOTPSecret()
+	hashed, _ := helper.GeneratePassword("be1422")
+	resData := user.Core{ID: 1, Email: "jerry@alterra.id", Password: hashed, Verified: true, MFAEnabled: true, MFASecret: secret}
+
+	t.Run("login meminta kode 2FA", func(t *testing.T) {
+		allowLogin(repo)
+		repo.On("Login", "jerry@alterra.id").Return(resData, nil).Once()
+		expectAttempt(repo, user.LoginMFAPending)
+
+		token, _, err := srv.Login("jerry@alterra.id", "be1422", testClient)
+		assert.Nil(t, err)
+		assert.Empty(t, token.AccessToken)
+		assert.NotEmpty(t, token.MFAToken)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Berhasil login dengan kode TOTP", func(t *testing.T) {
+		mfaToken := helper.GenerateSignedToken("mfa_pending", 1, "jerry@alterra.id", time.Minute)
+		code, _ := helper.TOTPCode(secret, helper.TOTPStep(time.Now()))
+		allowLogin(repo)
+		repo.On("GetByID", uint(1)).Return(resData, nil).Once()
+		repo.On("SetMFALastStep", uint(1), mock.Anything).Return(nil).Once()
+		expectSession(repo, 1)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		expectAttempt(repo, user.LoginSuccess)
+		expectEvent(repo, 1, user.EventLoginSuccess)
+
+		token, res, err := srv.LoginMFA(mfaToken, code, testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.Equal(t, uint(1), res.ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("kode TOTP dipakai ulang", func(t *testing.T) {
+		mfaToken := helper.GenerateSignedToken("mfa_pending", 1, "jerry@alterra.id", time.Minute)
+		code, _ := helper.TOTPCode(secret, helper.TOTPStep(time.Now()))
+		allowLogin(repo)
+		repo.On("GetByID", uint(1)).Return(resData, nil).Once()
+		repo.On("SetMFALastStep", uint(1), mock.Anything).Return(errors.New("mfa step not found")).Once()
+		expectAttempt(repo, user.LoginMFAFailed)
+		expectEvent(repo, 1, user.EventLoginFailed)
+
+		token, _, err := srv.LoginMFA(mfaToken, code, testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "kode 2FA tidak valid")
+		assert.Empty(t, token)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Berhasil login dengan recovery code", func(t *testing.T) {
+		mfaToken := helper.GenerateSignedToken("mfa_pending", 1, "jerry@alterra.id", time.Minute)
+		allowLogin(repo)
+		repo.On("GetByID", uint(1)).Return(resData, nil).Once()
+		repo.On("UseRecoveryCode", uint(1), helper.HashToken("abcdefghij")).Return(nil).Once()
+		expectSession(repo, 1)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		expectAttempt(repo, user.LoginSuccess)
+		expectEvent(repo, 1, user.EventLoginSuccess)
+
+		token, _, err := srv.LoginMFA(mfaToken, "ABCDE-FGHIJ", testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("mfa token kedaluwarsa", func(t *testing.T) {
+		mfaToken := helper.GenerateSignedToken("mfa_pending", 1, "jerry@alterra.id", -time.Minute)
+
+		token, _, err := srv.LoginMFA(mfaToken, "123456", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "mfa token tidak valid")
+		assert.Empty(t, token)
+	})
+
+	t.Run("access token tidak bisa dipakai sebagai mfa token", func(t *testing.T) {
+		accessToken, _ := helper.GenerateJWT(1, helper.RoleUser)
+
+		_, _, err := srv.LoginMFA(accessToken, "123456", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "mfa token tidak valid")
+	})
+
+	t.Run("kode 2FA terkunci setelah terlalu banyak gagal", func(t *testing.T) {
+		mfaToken := helper.GenerateSignedToken("mfa_pending", 1, "jerry@alterra.id", time.Minute)
+		repo.On("AccountLoginFailures", "jerry@alterra.id", mock.Anything).Return(user.LoginFailureCore{Count: 10, Last: time.Now()}, nil).Once()
+		repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+		expectAttempt(repo, user.LoginThrottled)
+		expectEvent(repo, 1, user.EventLoginFailed)
+
+		_, _, err := srv.LoginMFA(mfaToken, "123456", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "terlalu banyak percobaan login")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestEnrollMFA(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Berhasil enroll", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Email: "jerry@alterra.id"}, nil).Once()
+		repo.On("SetMFASecret", uint(1), mock.Anything).Return(nil).Once()
+
+		res, err := srv.EnrollMFA(pToken)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, res.Secret)
+		assert.True(t, strings.HasPrefix(res.URI, "otpauth://totp/"))
+		assert.Contains(t, res.URI, "secret="+res.Secret)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("2FA sudah aktif", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, MFAEnabled: true}, nil).Once()
+
+		_, err := srv.EnrollMFA(pToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah aktif")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Berhasil konfirmasi", func(t *testing.T) {
+		secret, _ := helper.GenerateTOTPSecret()
+		step := helper.TOTPStep(time.Now())
+		code, _ := helper.TOTPCode(secret, step)
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, MFASecret: secret}, nil).Once()
+		repo.On("EnableMFA", uint(1), step, mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == 10
+		})).Return(nil).Once()
+		expectEvent(repo, 1, user.EventMFAEnabled)
+
+		codes, err := srv.ConfirmMFA(pToken, code)
+		assert.Nil(t, err)
+		assert.Len(t, codes, 10)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("konfirmasi kode salah", func(t *testing.T) {
+		secret, _ := helper.GenerateTOTPSecret()
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, MFASecret: secret}, nil).Once()
+
+		codes, err := srv.ConfirmMFA(pToken, "000000x")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "kode 2FA tidak valid")
+		assert.Nil(t, codes)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Berhasil nonaktifkan", func(t *testing.T) {
+		secret, _ := helper.GenerateTOTPSecret()
+		code, _ := helper.TOTPCode(secret, helper.TOTPStep(time.Now()))
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, MFASecret: secret, MFAEnabled: true}, nil).Once()
+		repo.On("SetMFALastStep", uint(1), mock.Anything).Return(nil).Once()
+		repo.On("DisableMFA", uint(1)).Return(nil).Once()
+		expectEvent(repo, 1, user.EventMFADisabled)
+
+		err := srv.DisableMFA(pToken, code)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestOIDCLogin(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	idp := mocks.NewIdentityProvider(t)
+	srv := New(repo, mocks.NewMailer(t), idp, nil, nil)
+
+	// start mengembalikan stateToken dan state yang dikirim ke IdP
+	start := func(t *testing.T) (string, string) {
+		var state string
+		idp.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			state = args.String(0)
+		}).Return("https://idp.example.com/authorize", nil).Once()
+
+		authURL, stateToken, err := srv.OIDCStart()
+		assert.Nil(t, err)
+		assert.Equal(t, "https://idp.example.com/authorize", authURL)
+		assert.NotEmpty(t, stateToken)
+		return stateToken, state
+	}
+	claims := oidc.Claims{Subject: "u-123", Email: "alif@example.com", EmailVerified: true, Name: "Alif"}
+
+	t.Run("belum dikonfigurasi", func(t *testing.T) {
+		srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+		_, _, err := srv.OIDCStart()
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "belum dikonfigurasi")
+	})
+
+	t.Run("user sudah tertaut", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{ID: 1, Email: claims.Email, Verified: true, OIDCSubject: "u-123"}, nil).Once()
+		expectSession(repo, 1)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		expectAttempt(repo, user.LoginSuccess)
+		expectEvent(repo, 1, user.EventLoginSuccess)
+
+		token, res, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.NotEmpty(t, token.RefreshToken)
+		assert.Equal(t, uint(1), res.ID)
+		repo.AssertExpectations(t)
+		idp.AssertExpectations(t)
+	})
+
+	t.Run("login oidc ikut dijeda setelah terlalu banyak gagal", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		repo.On("AccountLoginFailures", claims.Email, mock.Anything).Return(user.LoginFailureCore{Count: 10, Last: time.Now()}, nil).Once()
+		repo.On("IPLoginFailures", "127.0.0.1", mock.Anything).Return(user.LoginFailureCore{}, nil).Once()
+		expectAttempt(repo, user.LoginThrottled)
+
+		_, _, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.ErrorContains(t, err, "terlalu banyak")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("gagal membaca user oidc", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{}, errors.New("connection refused")).Once()
+
+		_, _, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("state tidak sesuai", func(t *testing.T) {
+		stateToken, _ := start(t)
+
+		_, _, err := srv.OIDCCallback(stateToken, "state-lain", "kode", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "state login oidc tidak valid")
+	})
+
+	t.Run("tanpa cookie state", func(t *testing.T) {
+		_, state := start(t)
+
+		_, _, err := srv.OIDCCallback("", state, "kode", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "state login oidc tidak valid")
+	})
+
+	t.Run("exchange gagal", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(oidc.Claims{}, errors.New("invalid_grant")).Once()
+
+		_, _, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "token identity provider tidak valid")
+		idp.AssertExpectations(t)
+	})
+
+	t.Run("tautkan ke akun dengan email terverifikasi", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("Login", claims.Email).Return(user.Core{ID: 2, Email: claims.Email, Verified: true}, nil).Once()
+		repo.On("LinkOIDC", uint(2), "u-123").Return(nil).Once()
+		expectSession(repo, 2)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		expectAttempt(repo, user.LoginSuccess)
+		expectEvent(repo, 2, user.EventLoginSuccess)
+
+		token, res, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.Equal(t, "u-123", res.OIDCSubject)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun lokal belum verifikasi email tidak ditautkan", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("Login", claims.Email).Return(user.Core{ID: 2, Email: claims.Email}, nil).Once()
+
+		_, _, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditolak")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("email IdP belum diverifikasi", func(t *testing.T) {
+		stateToken, state := start(t)
+		unverified := claims
+		unverified.EmailVerified = false
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(unverified, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{}, errors.New("data not found")).Once()
+
+		_, _, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "ditolak")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("buat akun baru", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("Login", claims.Email).Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("Register", mock.MatchedBy(func(newUser user.Core) bool {
+			return newUser.Email == claims.Email && newUser.OIDCSubject == "u-123" && newUser.Role == helper.RoleUser && newUser.Password != ""
+		})).Return(user.Core{ID: 3, Name: "Alif", Email: claims.Email, Role: helper.RoleUser, OIDCSubject: "u-123"}, nil).Once()
+		repo.On("SetEmailVerified", uint(3), claims.Email).Return(nil).Once()
+		expectSession(repo, 3)
+		repo.On("SaveRefreshToken", mock.Anything).Return(nil).Once()
+		expectAttempt(repo, user.LoginSuccess)
+		expectEvent(repo, 3, user.EventLoginSuccess)
+
+		token, res, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.Equal(t, uint(3), res.ID)
+		assert.True(t, res.Verified)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun dengan 2FA", func(t *testing.T) {
+		stateToken, state := start(t)
+		idp.On("Exchange", "kode", mock.Anything, mock.Anything).Return(claims, nil).Once()
+		allowLogin(repo)
+		repo.On("GetByOIDCSubject", "u-123").Return(user.Core{ID: 1, Email: claims.Email, Verified: true, MFAEnabled: true}, nil).Once()
+		expectAttempt(repo, user.LoginMFAPending)
+
+		token, _, err := srv.OIDCCallback(stateToken, state, "kode", testClient)
+		assert.Nil(t, err)
+		assert.Empty(t, token.AccessToken)
+		assert.NotEmpty(t, token.MFAToken)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestExport(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Berhasil export data", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Name: "jerry", Email: "jerry@alterra.id"}, nil).Once()
+		repo.On("OwnedBooks", uint(1)).Return([]user.OwnedBookCore{{ID: 3, Judul: "One Piece", TahunTerbit: 1997, Penulis: "Oda"}}, nil).Once()
+
+		res, err := srv.Export(pToken)
+		assert.Nil(t, err)
+		assert.Equal(t, "jerry@alterra.id", res.User.Email)
+		assert.Len(t, res.Books, 1)
+		assert.False(t, res.ExportedAt.IsZero())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("user tidak ditemukan", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{}, errors.New("record not found")).Once()
+
+		_, err := srv.Export(pToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1}, nil).Once()
+		repo.On("OwnedBooks", uint(1)).Return(nil, errors.New("database error")).Once()
+
+		_, err := srv.Export(pToken)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPurgeDeactivated(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	blobs := mocks.NewBlobStore(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, blobs)
+
+	t.Run("hapus akun yang lewat masa tenggang", func(t *testing.T) {
+		repo.On("DeactivatedBefore", mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= config.DELETION_GRACE
+		})).Return([]uint{2, 3}, nil).Once()
+		repo.On("GetByID", uint(2)).Return(user.Core{ID: 2, Avatar: "avatars/2/abc"}, nil).Once()
+		repo.On("GetByID", uint(3)).Return(user.Core{ID: 3, Avatar: "avatars/3/def"}, nil).Once()
+		repo.On("Purge", uint(2), config.PURGE_BOOKS_TO).Return(nil).Once()
+		repo.On("Purge", uint(3), config.PURGE_BOOKS_TO).Return(errors.New("database error")).Once()
+		expectEvent(repo, 2, user.EventPurged)
+		for _, size := range user.AvatarSizes {
+			blobs.On("Delete", user.AvatarKey("avatars/2/abc", size)).Return(nil).Once()
+		}
+
+		n, err := srv.PurgeDeactivated()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("DeactivatedBefore", mock.Anything).Return(nil, errors.New("database error")).Once()
+
+		n, err := srv.PurgeDeactivated()
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, n)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestPatch(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	current := user.Core{ID: 1, Name: "jerry", Email: "jerry@alterra.id", Alamat: "Jakarta", HP: "08123456"}
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("ubah nama dan kosongkan alamat", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+		repo.On("Update", uint(1), user.Core{Name: "tom", HP: "08123456"}, []string{"Name", "Alamat"}).Return(user.Core{}, nil).Once()
+
+		res, err := srv.Patch(pToken, []byte(`{"nama":"tom","alamat":null}`))
+		assert.Nil(t, err)
+		assert.Equal(t, "tom", res.Name)
+		assert.Empty(t, res.Alamat)
+		assert.Equal(t, "08123456", res.HP)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ganti password", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+		repo.On("RecentPasswords", uint(1), 5).Return([]string{}, nil).Once()
+		repo.On("Update", uint(1), mock.MatchedBy(func(updateData user.Core) bool {
+			return helper.CheckPassword(updateData.Password, "PasswordBaru77") == nil
+		}), []string{"Password"}).Return(user.Core{}, nil).Once()
+		expectEvent(repo, 1, user.EventPasswordChanged)
+
+		_, err := srv.Patch(pToken, []byte(`{"password":"PasswordBaru77"}`))
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("tampilkan hp di profil publik", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+		repo.On("Update", uint(1), user.Core{Name: "jerry", Alamat: "Jakarta", HP: "08123456", ShowHP: true}, []string{"ShowHP"}).Return(user.Core{}, nil).Once()
+
+		res, err := srv.Patch(pToken, []byte(`{"show_hp":true}`))
+		assert.Nil(t, err)
+		assert.True(t, res.ShowHP)
+		assert.False(t, res.ShowAlamat)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("tanpa perubahan", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		res, err := srv.Patch(pToken, []byte(`{"nama":"jerry","password":null}`))
+		assert.Nil(t, err)
+		assert.Equal(t, current, res)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("validasi gagal", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		_, err := srv.Patch(pToken, []byte(`{"nama":null,"email":"tom@alterra.id"}`))
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "nama")
+		assert.Contains(t, ve.Fields, "email")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("field tidak bisa diubah", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		_, err := srv.Patch(pToken, []byte(`{"role":"admin"}`))
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "role")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("format patch salah", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		_, err := srv.Patch(pToken, []byte(`{"hp":8123}`))
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format patch salah")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+		repo.On("Update", uint(1), mock.Anything, []string{"HP"}).Return(user.Core{}, errors.New("database error")).Once()
+
+		_, err := srv.Patch(pToken, []byte(`{"hp":"0899"}`))
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	ml := mocks.NewMailer(t)
+	srv := New(repo, ml, nil, nil, nil)
+
+	hashed, _ := helper.GeneratePassword("be1422")
+	current := user.Core{ID: 1, Name: "jerry", Email: "jerry@alterra.id", Password: hashed, Verified: true}
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Berhasil minta ganti email", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+		repo.On("Login", "tom@alterra.id").Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("GetDeactivated", "tom@alterra.id").Return(user.Core{}, errors.New("data not found")).Once()
+		repo.On("SaveEmailChange", mock.MatchedBy(func(change user.EmailChangeCore) bool {
+			return change.UserID == 1 && change.OldEmail == "jerry@alterra.id" && change.NewEmail == "tom@alterra.id" &&
+				change.TokenHash != "" && change.RevertHash != "" && change.TokenHash != change.RevertHash
+		})).Return(nil).Once()
+		ml.On("Send", "tom@alterra.id", mock.Anything, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "/users/email/confirm?token=")
+		})).Return(nil).Once()
+		ml.On("Send", "jerry@alterra.id", mock.Anything, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "/users/email/revert?token=") && strings.Contains(body, "tom@alterra.id")
+		})).Return(nil).Once()
+		expectEvent(repo, 1, user.EventEmailChangeRequested)
+
+		err := srv.RequestEmailChange(pToken, " tom@alterra.id ", "be1422")
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+		ml.AssertExpectations(t)
+	})
+
+	t.Run("password salah", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		err := srv.RequestEmailChange(pToken, "tom@alterra.id", "asal")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "password salah")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun oidc tanpa password setelah login ulang", func(t *testing.T) {
+		oidcUser := current
+		oidcUser.OIDCSubject = "u-123"
+		pSession := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, SessionID: 7}
+		repo.On("Profile", uint(1)).Return(oidcUser, nil).Once()
+		repo.On("GetSession", uint(7)).Return(user.SessionCore{ID: 7, UserID: 1, CreatedAt: time.Now().Add(-time.Minute)}, nil).Once()
+		repo.On("Login", "tom@alterra.id").Return(user.Core{ID: 2}, nil).Once()
+
+		// sampai pengecekan email tersedia berarti re-auth diterima
+		err := srv.RequestEmailChange(pSession, "tom@alterra.id", "")
+		assert.ErrorContains(t, err, "sudah terdaftar")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun oidc dengan sesi lama wajib login ulang", func(t *testing.T) {
+		oidcUser := current
+		oidcUser.OIDCSubject = "u-123"
+		pSession := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}, SessionID: 7}
+		repo.On("Profile", uint(1)).Return(oidcUser, nil).Once()
+		repo.On("GetSession", uint(7)).Return(user.SessionCore{ID: 7, UserID: 1, CreatedAt: time.Now().Add(-time.Hour)}, nil).Once()
+
+		err := srv.RequestEmailChange(pSession, "tom@alterra.id", "")
+		assert.ErrorContains(t, err, "login ulang")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("akun tanpa oidc tetap wajib password", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		err := srv.RequestEmailChange(auth.Principal{UserID: 1, SessionID: 7}, "tom@alterra.id", "")
+		assert.ErrorContains(t, err, "password salah")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("email sudah dipakai akun lain", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+		repo.On("Login", "tom@alterra.id").Return(user.Core{ID: 2}, nil).Once()
+
+		err := srv.RequestEmailChange(pToken, "tom@alterra.id", "be1422")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah terdaftar")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("format email salah", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		err := srv.RequestEmailChange(pToken, "bukan-email", "be1422")
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "email")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("email sama dengan sekarang", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(current, nil).Once()
+
+		err := srv.RequestEmailChange(pToken, "Jerry@alterra.id", "be1422")
+		var ve *helper.ValidationError
+		assert.True(t, errors.As(err, &ve))
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	pending := user.EmailChangeCore{ID: 5, UserID: 1, OldEmail: "jerry@alterra.id", NewEmail: "tom@alterra.id", ExpiresAt: time.Now().Add(time.Hour)}
+
+	t.Run("Berhasil konfirmasi", func(t *testing.T) {
+		repo.On("GetEmailChange", helper.HashToken("konfirmasi")).Return(pending, nil).Once()
+		repo.On("ConfirmEmailChange", uint(5)).Return(nil).Once()
+		expectEvent(repo, 1, user.EventEmailChanged)
+
+		err := srv.ConfirmEmailChange("konfirmasi", testClient)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link sudah dipakai", func(t *testing.T) {
+		used := pending
+		used.Confirmed = true
+		repo.On("GetEmailChange", mock.Anything).Return(used, nil).Once()
+
+		err := srv.ConfirmEmailChange("konfirmasi", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link kedaluwarsa", func(t *testing.T) {
+		expired := pending
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		repo.On("GetEmailChange", mock.Anything).Return(expired, nil).Once()
+
+		err := srv.ConfirmEmailChange("konfirmasi", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "kedaluwarsa")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("email keburu dipakai akun lain", func(t *testing.T) {
+		repo.On("GetEmailChange", mock.Anything).Return(pending, nil).Once()
+		repo.On("ConfirmEmailChange", uint(5)).Return(errors.New("email duplicated")).Once()
+
+		err := srv.ConfirmEmailChange("konfirmasi", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "sudah terdaftar")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestRevertEmailChange(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+
+	confirmed := user.EmailChangeCore{ID: 5, UserID: 1, Confirmed: true, RevertExpiresAt: time.Now().Add(time.Hour)}
+
+	t.Run("Berhasil kembalikan email", func(t *testing.T) {
+		repo.On("GetEmailChangeByRevert", helper.HashToken("batal")).Return(confirmed, nil).Once()
+		repo.On("RevertEmailChange", uint(5)).Return(nil).Once()
+		repo.On("RevokeUserRefreshTokens", uint(1)).Return(nil).Once()
+		repo.On("SetPasswordResetRequired", uint(1), true).Return(nil).Once()
+		expectEvent(repo, 1, user.EventEmailChangeReverted)
+
+		err := srv.RevertEmailChange("batal", testClient)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("sudah dibatalkan", func(t *testing.T) {
+		reverted := confirmed
+		reverted.Reverted = true
+		repo.On("GetEmailChangeByRevert", mock.Anything).Return(reverted, nil).Once()
+
+		err := srv.RevertEmailChange("batal", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("link tidak ditemukan", func(t *testing.T) {
+		repo.On("GetEmailChangeByRevert", mock.Anything).Return(user.EmailChangeCore{}, errors.New("data not found")).Once()
+
+		err := srv.RevertEmailChange("asal", testClient)
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "tidak valid")
+		repo.AssertExpectations(t)
+	})
+}
+
+func avatarPNG(w, h int) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+	return buf.Bytes()
+}
+
+func TestUploadAvatar(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	blobs := mocks.NewBlobStore(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, blobs)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Sukses upload dan hapus avatar lama", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Avatar: "avatars/1/lama"}, nil).Once()
+		blobs.On("Put", mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, "avatars/1/") && !strings.HasPrefix(key, "avatars/1/lama/")
+		}), mock.MatchedBy(func(data []byte) bool {
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			return err == nil && img.Bounds().Dx() == img.Bounds().Dy()
+		}), "image/jpeg").Return(nil).Times(len(user.AvatarSizes))
+		repo.On("Update", uint(1), mock.MatchedBy(func(data user.Core) bool {
+			return strings.HasPrefix(data.Avatar, "avatars/1/")
+		}), []string{"Avatar"}).Return(user.Core{}, nil).Once()
+		for _, size := range user.AvatarSizes {
+			blobs.On("Delete", user.AvatarKey("avatars/1/lama", size)).Return(nil).Once()
+		}
+
+		res, err := srv.UploadAvatar(pToken, avatarPNG(300, 200))
+		assert.Nil(t, err)
+		assert.NotEqual(t, "avatars/1/lama", res.Avatar)
+		assert.True(t, strings.HasPrefix(res.Avatar, "avatars/1/"))
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("bukan gambar", func(t *testing.T) {
+		_, err := srv.UploadAvatar(pToken, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+		assert.ErrorContains(t, err, "tidak didukung")
+	})
+
+	t.Run("file terlalu besar", func(t *testing.T) {
+		_, err := srv.UploadAvatar(pToken, make([]byte, config.AVATAR_MAX_SIZE+1))
+		assert.ErrorContains(t, err, "terlalu besar")
+	})
+
+	t.Run("gagal simpan ke storage", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1}, nil).Once()
+		blobs.On("Put", mock.Anything, mock.Anything, "image/jpeg").Return(errors.New("connection refused")).Once()
+		blobs.On("Delete", mock.Anything).Return(nil).Times(len(user.AvatarSizes))
+
+		_, err := srv.UploadAvatar(pToken, avatarPNG(64, 64))
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+}
+
+func TestAvatar(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	blobs := mocks.NewBlobStore(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, blobs)
+
+	t.Run("ukuran default", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Avatar: "avatars/1/abc"}, nil).Once()
+		blobs.On("Get", "avatars/1/abc/128.jpg").Return([]byte("jpeg"), "image/jpeg", nil).Once()
+
+		data, contentType, err := srv.Avatar(1, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("jpeg"), data)
+		assert.Equal(t, "image/jpeg", contentType)
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("ukuran tidak valid", func(t *testing.T) {
+		_, _, err := srv.Avatar(1, 100)
+		assert.ErrorContains(t, err, "tidak valid")
+	})
+
+	t.Run("belum upload avatar", func(t *testing.T) {
+		repo.On("Profile", uint(2)).Return(user.Core{ID: 2}, nil).Once()
+
+		_, _, err := srv.Avatar(2, 64)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("file hilang dari storage", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Avatar: "avatars/1/abc"}, nil).Once()
+		blobs.On("Get", "avatars/1/abc/256.jpg").Return(nil, "", blobstore.ErrNotFound).Once()
+
+		_, _, err := srv.Avatar(1, 256)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+}
+
+func TestDeleteAvatar(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	blobs := mocks.NewBlobStore(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, blobs)
+
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Sukses hapus avatar", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1, Avatar: "avatars/1/abc"}, nil).Once()
+		repo.On("Update", uint(1), user.Core{}, []string{"Avatar"}).Return(user.Core{}, nil).Once()
+		for _, size := range user.AvatarSizes {
+			blobs.On("Delete", user.AvatarKey("avatars/1/abc", size)).Return(nil).Once()
+		}
+
+		err := srv.DeleteAvatar(pToken)
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("belum upload avatar", func(t *testing.T) {
+		repo.On("Profile", uint(1)).Return(user.Core{ID: 1}, nil).Once()
+
+		err := srv.DeleteAvatar(pToken)
+		assert.ErrorContains(t, err, "not found")
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestSecurityEvents(t *testing.T) {
+	repo := mocks.NewUserData(t)
+	srv := New(repo, mocks.NewMailer(t), nil, nil, nil)
+	pToken := auth.Principal{UserID: 1, Roles: []string{helper.RoleUser}}
+
+	t.Run("Berhasil lihat riwayat keamanan sendiri", func(t *testing.T) {
+		events := []user.SecurityEventCore{{ID: 2, UserID: 1, Type: user.EventLoginFailed, Detail: user.LoginWrongPassword}, {ID: 1, UserID: 1, Type: user.EventLoginSuccess}}
+		repo.On("ListSecurityEvents", user.SecurityEventFilter{UserID: 1, Page: 1, Limit: 20}).Return(events, int64(2), nil).Once()
+
+		// user_id dari filter diabaikan, user hanya bisa melihat riwayat miliknya
+		res, total, err := srv.SecurityEvents(pToken, user.SecurityEventFilter{UserID: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, res, 2)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("filter jenis event", func(t *testing.T) {
+		repo.On("ListSecurityEvents", user.SecurityEventFilter{UserID: 1, Type: user.EventPasswordChanged, Page: 2, Limit: 100}).Return([]user.SecurityEventCore{}, int64(0), nil).Once()
+
+		_, _, err := srv.SecurityEvents(pToken, user.SecurityEventFilter{Type: user.EventPasswordChanged, Page: 2, Limit: 500})
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("jenis event tidak dikenal", func(t *testing.T) {
+		res, _, err := srv.SecurityEvents(pToken, user.SecurityEventFilter{Type: "hack"})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "format")
+		assert.Nil(t, res)
+	})
+
+	t.Run("filter rentang waktu", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		repo.On("ListSecurityEvents", user.SecurityEventFilter{UserID: 1, From: from, To: to, Page: 1, Limit: 20}).Return([]user.SecurityEventCore{}, int64(0), nil).Once()
+
+		_, _, err := srv.SecurityEvents(pToken, user.SecurityEventFilter{From: from, To: to})
+		assert.Nil(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rentang waktu terbalik", func(t *testing.T) {
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		_, _, err := srv.SecurityEvents(pToken, user.SecurityEventFilter{From: from, To: from.AddDate(0, 0, -1)})
+		assert.ErrorContains(t, err, "format")
+	})
+
+	t.Run("masalah di server", func(t *testing.T) {
+		repo.On("ListSecurityEvents", mock.Anything).Return(nil, int64(0), errors.New("database error")).Once()
+
+		_, _, err := srv.SecurityEvents(pToken, user.SecurityEventFilter{})
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "server")
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,11 @@
+package helper
+
+import (
+	"api/config"
+	"fmt"
+)
+
+// AvatarURL alamat publik avatar user. Pemanggil memastikan user sudah upload avatar.
+func AvatarURL(userID uint) string {
+	return fmt.Sprintf("%s/users/%d/avatar", config.APP_URL, userID)
+}
@@ -2,31 +2,38 @@ package helper
 
 import (
 	"api/config"
+	"log"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
-func ExtractToken(t interface{}) int {
-	user := t.(*jwt.Token)
-	userId := -1
-	if user.Valid {
-		claims := user.Claims.(jwt.MapClaims)
-		switch claims["userID"].(type) {
-		case float64:
-			userId = int(claims["userID"].(float64))
-		case int:
-			userId = claims["userID"].(int)
-		}
-	}
-	return userId
+func GenerateJWT(id int, role string) (string, error) {
+	return GenerateSessionJWT(id, role, 0)
 }
 
-func GenerateJWT(id int) (string, interface{}) {
+// GenerateSessionJWT sama dengan GenerateJWT ditambah sid supaya token bisa dicabut per perangkat.
+// Token kosong dan error dikembalikan jika jti tidak bisa dibuat atau token gagal ditandatangani.
+func GenerateSessionJWT(id int, role string, sessionID uint) (string, error) {
+	jti, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{}
 	claims["authorized"] = true
 	claims["userID"] = id
-	// claims["exp"] = time.Now().Add(time.Hour * 1).Unix() //Token expires after 1 hour
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	useToken, _ := token.SignedString([]byte(config.JWT_KEY))
-	return useToken, token
+	claims["role"] = role
+	claims["jti"] = jti
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(config.ACCESS_TOKEN_TTL).Unix()
+	claims["iss"] = config.APP_URL
+	if sessionID > 0 {
+		claims["sid"] = sessionID
+	}
+	useToken, _, err := config.JWTKeys().Sign(claims)
+	if err != nil {
+		log.Println("sign jwt error", err.Error())
+		return "", err
+	}
+	return useToken, nil
 }
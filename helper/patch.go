@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+// MergePatchType content type JSON Merge Patch (RFC 7396).
+const MergePatchType = "application/merge-patch+json"
+
+// maxPatchSize batas ukuran body PATCH.
+const maxPatchSize = 1 << 20
+
+// ReadMergePatch membaca body request PATCH. Selain application/merge-patch+json,
+// application/json juga diterima dan diperlakukan sebagai merge patch.
+func ReadMergePatch(r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != MergePatchType && mediaType != "application/json" {
+		return nil, errors.New("content type tidak didukung, gunakan " + MergePatchType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize+1))
+	if err != nil || len(body) == 0 || len(body) > maxPatchSize {
+		return nil, errors.New("format patch salah")
+	}
+
+	return body, nil
+}
+
+// MergePatch menerapkan patch ke dokumen JSON sesuai RFC 7396: null menghapus key,
+// object digabung rekursif, nilai lain menggantikan nilai lama.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+
+	var d interface{}
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(mergeValue(d, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, val := range p {
+		if val == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergeValue(t[key], val)
+	}
+
+	return t
+}
+
+// ApplyMergePatch menerapkan patch ke v (pointer ke struct dengan tag json) dan menulis hasilnya kembali ke v.
+// Key yang tidak ada di dokumen v ditolak sebagai ValidationError supaya tidak diam-diam diabaikan,
+// field yang di-patch dengan null menjadi zero value.
+func ApplyMergePatch(v interface{}, patch []byte) error {
+	keys := map[string]json.RawMessage{}
+	if err := json.Unmarshal(patch, &keys); err != nil {
+		return errors.New("format patch salah, harus berupa objek JSON")
+	}
+
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return err
+	}
+
+	ve := &ValidationError{}
+	for key := range keys {
+		if _, ok := fields[key]; !ok {
+			ve.Add(key, key+" tidak dikenal atau tidak bisa diubah")
+		}
+	}
+	if ve.HasErrors() {
+		return ve
+	}
+
+	merged, err := MergePatch(doc, patch)
+	if err != nil {
+		return errors.New("format patch salah")
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	if err := json.Unmarshal(merged, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return errors.New("format patch salah, tipe nilai " + typeErr.Field + " tidak sesuai")
+		}
+		return errors.New("format patch salah")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	// contoh dari RFC 7396 lampiran A
+	cases := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, c := range cases {
+		res, err := MergePatch([]byte(c.doc), []byte(c.patch))
+		assert.Nil(t, err)
+		assert.JSONEq(t, c.expected, string(res), c.patch)
+	}
+}
+
+type patchDoc struct {
+	Name   string `json:"nama"`
+	Alamat string `json:"alamat"`
+	Umur   int    `json:"umur"`
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	t.Run("ubah dan hapus field", func(t *testing.T) {
+		doc := patchDoc{Name: "jerry", Alamat: "Jakarta", Umur: 20}
+		err := ApplyMergePatch(&doc, []byte(`{"nama":"tom","alamat":null}`))
+		assert.Nil(t, err)
+		assert.Equal(t, patchDoc{Name: "tom", Umur: 20}, doc)
+	})
+
+	t.Run("field tidak dikenal", func(t *testing.T) {
+		doc := patchDoc{Name: "jerry"}
+		err := ApplyMergePatch(&doc, []byte(`{"id":5}`))
+		var ve *ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Contains(t, ve.Fields, "id")
+		assert.Equal(t, "jerry", doc.Name)
+	})
+
+	t.Run("tipe nilai salah", func(t *testing.T) {
+		doc := patchDoc{}
+		err := ApplyMergePatch(&doc, []byte(`{"umur":"dua puluh"}`))
+		assert.ErrorContains(t, err, "format patch salah")
+	})
+
+	t.Run("patch bukan objek", func(t *testing.T) {
+		doc := patchDoc{}
+		err := ApplyMergePatch(&doc, []byte(`["nama"]`))
+		assert.ErrorContains(t, err, "objek JSON")
+	})
+}
+
+func TestReadMergePatch(t *testing.T) {
+	body, _ := json.Marshal(map[string]string{"nama": "tom"})
+
+	req := httptest.NewRequest("PATCH", "/users", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MergePatchType)
+	res, err := ReadMergePatch(req)
+	assert.Nil(t, err)
+	assert.Equal(t, body, res)
+
+	req = httptest.NewRequest("PATCH", "/users", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	_, err = ReadMergePatch(req)
+	assert.ErrorContains(t, err, "tidak didukung")
+
+	req = httptest.NewRequest("PATCH", "/users", nil)
+	req.Header.Set("Content-Type", MergePatchType)
+	_, err = ReadMergePatch(req)
+	assert.ErrorContains(t, err, "format")
+}
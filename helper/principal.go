@@ -0,0 +1,13 @@
+package helper
+
+import (
+	"api/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Principal mengambil user yang login dari context request, zero value jika route tidak memakai middleware auth.
+func Principal(c echo.Context) auth.Principal {
+	p, _ := auth.FromContext(c.Request().Context())
+	return p
+}
@@ -27,6 +27,33 @@ func PrintSuccessReponse(code int, message string, data ...interface{}) (int, in
 	return code, resp
 }
 
+type Pagination struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func PrintPagingResponse(code int, message string, data interface{}, paging Pagination) (int, interface{}) {
+	resp := map[string]interface{}{}
+	resp["data"] = data
+	resp["pagination"] = paging
+	if message != "" {
+		resp["message"] = message
+	}
+
+	return code, resp
+}
+
+// PrintValidationResponse mengirim pesan validasi per field dengan status 400.
+func PrintValidationResponse(ve *ValidationError) (int, interface{}) {
+	resp := map[string]interface{}{}
+	resp["message"] = ve.Error()
+	resp["errors"] = ve.Fields
+
+	return http.StatusBadRequest, resp
+}
+
 func PrintErrorResponse(msg string) (int, interface{}) {
 	resp := map[string]interface{}{}
 	code := -1
@@ -40,6 +67,22 @@ func PrintErrorResponse(msg string) (int, interface{}) {
 		code = http.StatusBadRequest
 	} else if strings.Contains(msg, "not found") {
 		code = http.StatusNotFound
+	} else if strings.Contains(msg, "token") || strings.Contains(msg, "unauthorized") || strings.Contains(msg, "password salah") {
+		code = http.StatusUnauthorized
+	} else if strings.Contains(msg, "ditolak") {
+		code = http.StatusForbidden
+	} else if strings.Contains(msg, "tidak valid") {
+		code = http.StatusBadRequest
+	} else if strings.Contains(msg, "terlalu banyak") {
+		code = http.StatusTooManyRequests
+	} else if strings.Contains(msg, "terlalu besar") {
+		code = http.StatusRequestEntityTooLarge
+	} else if strings.Contains(msg, "tidak didukung") {
+		code = http.StatusUnsupportedMediaType
+	} else if strings.Contains(msg, "belum") {
+		code = http.StatusBadRequest
+	} else if strings.Contains(msg, "sudah") {
+		code = http.StatusConflict
 	}
 
 	return code, resp
@@ -0,0 +1,7 @@
+package helper
+
+const (
+	RoleUser      = "user"
+	RoleLibrarian = "librarian"
+	RoleAdmin     = "admin"
+)
@@ -0,0 +1,97 @@
+package helper
+
+import (
+	"api/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// GenerateSignedToken membuat token bertanda tangan untuk link di email (verifikasi, dsb).
+// Kuncinya diturunkan dari JWT_KEY per purpose, jadi tidak bisa dipakai sebagai access token.
+func GenerateSignedToken(purpose string, id uint, email string, ttl time.Duration) string {
+	claims := jwt.MapClaims{}
+	claims["purpose"] = purpose
+	claims["sub"] = strconv.Itoa(int(id))
+	claims["email"] = email
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, _ := token.SignedString(purposeKey(purpose))
+	return signed
+}
+
+// ParseSignedToken memvalidasi token dari GenerateSignedToken dan mengembalikan id dan email-nya.
+func ParseSignedToken(purpose, signed string) (uint, string, error) {
+	token, err := jwt.Parse(signed, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return purposeKey(purpose), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, "", errors.New("signed token tidak valid")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["purpose"] != purpose {
+		return 0, "", errors.New("signed token tidak valid")
+	}
+	sub, _ := claims["sub"].(string)
+	id, err := strconv.Atoi(sub)
+	if err != nil || id <= 0 {
+		return 0, "", errors.New("signed token tidak valid")
+	}
+	email, _ := claims["email"].(string)
+
+	return uint(id), email, nil
+}
+
+// GenerateSignedClaims seperti GenerateSignedToken tapi dengan isi bebas (string),
+// dipakai untuk state yang dititipkan ke browser, misalnya cookie login OIDC.
+func GenerateSignedClaims(purpose string, data map[string]string, ttl time.Duration) string {
+	claims := jwt.MapClaims{}
+	for k, v := range data {
+		claims[k] = v
+	}
+	claims["purpose"] = purpose
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, _ := token.SignedString(purposeKey(purpose))
+	return signed
+}
+
+// ParseSignedClaims memvalidasi token dari GenerateSignedClaims dan mengembalikan isinya.
+func ParseSignedClaims(purpose, signed string) (map[string]string, error) {
+	token, err := jwt.Parse(signed, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return purposeKey(purpose), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("signed token tidak valid")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["purpose"] != purpose {
+		return nil, errors.New("signed token tidak valid")
+	}
+	data := map[string]string{}
+	for k, v := range claims {
+		if s, ok := v.(string); ok && k != "purpose" {
+			data[k] = s
+		}
+	}
+
+	return data, nil
+}
+
+func purposeKey(purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.JWT_KEY))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,36 @@
+package helper
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValidationError kumpulan pesan validasi per field, dikirim ke client sebagai "errors".
+type ValidationError struct {
+	Fields map[string][]string
+}
+
+func (ve *ValidationError) Add(field, msg string) {
+	if ve.Fields == nil {
+		ve.Fields = map[string][]string{}
+	}
+	ve.Fields[field] = append(ve.Fields[field], msg)
+}
+
+func (ve *ValidationError) HasErrors() bool {
+	return len(ve.Fields) > 0
+}
+
+func (ve *ValidationError) Error() string {
+	fields := make([]string, 0, len(ve.Fields))
+	for field := range ve.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	msgs := []string{}
+	for _, field := range fields {
+		msgs = append(msgs, ve.Fields[field]...)
+	}
+	return strings.Join(msgs, ", ")
+}
@@ -0,0 +1,140 @@
+// Package imaging memproses gambar upload user hanya dengan paket image bawaan:
+// deteksi tipe dari isi file, batas ukuran, crop persegi, resize dan encode ulang ke JPEG.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+// MaxPixels batas lebar x tinggi gambar yang mau di-decode, mencegah decompression bomb.
+const MaxPixels = 4096 * 4096
+
+var (
+	ErrUnsupported = errors.New("tipe gambar tidak didukung, gunakan JPEG, PNG atau GIF")
+	ErrTooLarge    = errors.New("dimensi gambar terlalu besar")
+)
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// Sniff menentukan content type dari isi file, bukan dari nama file atau header upload.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return "", ErrUnsupported
+	}
+	return contentType, nil
+}
+
+// Decode memeriksa tipe dan dimensi gambar sebelum men-decode seluruh isinya.
+func Decode(data []byte) (image.Image, error) {
+	if _, err := Sniff(data); err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	return img, nil
+}
+
+// Thumbnail crop bagian tengah gambar menjadi persegi lalu resize ke size x size.
+// Area transparan diisi putih karena hasilnya di-encode ke JPEG.
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), src, crop.Min, draw.Over)
+
+	return resize(square, size)
+}
+
+// resize dengan box filter: setiap pixel tujuan adalah rata-rata pixel sumber yang tercakup,
+// saat memperbesar jatuh ke nearest neighbour.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	side := src.Bounds().Dx()
+
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					bl += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// Thumbnails men-decode data lalu membuat JPEG untuk setiap ukuran. Encode ulang sekaligus
+// membuang metadata (EXIF, lokasi) dan konten lain yang ikut tersisip di file asli.
+func Thumbnails(data []byte, sizes []int) (map[int][]byte, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[int][]byte{}
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, Thumbnail(img, size), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		res[size] = buf.Bytes()
+	}
+
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	contentType, err := Sniff(encodePNG(image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	assert.Nil(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	_, err = Sniff([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("file rusak", func(t *testing.T) {
+		data := encodePNG(image.NewRGBA(image.Rect(0, 0, 4, 4)))
+		_, err := Decode(data[:20])
+		assert.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("dimensi terlalu besar", func(t *testing.T) {
+		data := encodePNG(image.NewGray(image.Rect(0, 0, 5000, 5000)))
+		_, err := Decode(data)
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+}
+
+func TestThumbnail(t *testing.T) {
+	// kiri merah, kanan biru, bagian tengah hijau: setelah crop persegi hanya hijau yang tersisa
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{0, 255, 0, 255}
+			if x < 100 {
+				c = color.RGBA{255, 0, 0, 255}
+			} else if x >= 200 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	res := Thumbnail(src, 64)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), res.Bounds())
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, res.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{0, 255, 0, 255}, res.RGBAAt(63, 63))
+
+	t.Run("transparan jadi putih", func(t *testing.T) {
+		res := Thumbnail(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 20)
+		assert.Equal(t, color.RGBA{255, 255, 255, 255}, res.RGBAAt(5, 5))
+	})
+}
+
+func TestThumbnails(t *testing.T) {
+	res, err := Thumbnails(encodePNG(image.NewRGBA(image.Rect(0, 0, 500, 400))), []int{64, 256})
+	assert.Nil(t, err)
+	assert.Len(t, res, 2)
+
+	img, err := jpeg.Decode(bytes.NewReader(res[256]))
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 256), img.Bounds())
+}
@@ -0,0 +1,109 @@
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid ISBN dengan panjang, karakter atau check digit yang salah.
+var ErrInvalid = errors.New("format isbn salah")
+
+// Normalize membersihkan spasi dan tanda hubung lalu memvalidasi check digit.
+// ISBN-10 dan ISBN-13 sama-sama diterima, hasilnya selalu ISBN-13 tanpa pemisah.
+func Normalize(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "ISBN")
+	s = strings.TrimLeft(s, "-: ")
+	s = strings.NewReplacer("-", "", " ", "").Replace(s)
+
+	switch len(s) {
+	case 10:
+		if !Valid10(s) {
+			return "", ErrInvalid
+		}
+		return To13(s)
+	case 13:
+		if !Valid13(s) {
+			return "", ErrInvalid
+		}
+		return s, nil
+	default:
+		return "", ErrInvalid
+	}
+}
+
+// Valid10 check digit ISBN-10 (modulo 11), digit terakhir boleh X untuk nilai 10.
+func Valid10(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var d int
+		switch {
+		case s[i] >= '0' && s[i] <= '9':
+			d = int(s[i] - '0')
+		case s[i] == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += d * (10 - i)
+	}
+	return sum%11 == 0
+}
+
+// Valid13 check digit ISBN-13 (EAN-13) dengan awalan 978 atau 979.
+func Valid13(s string) bool {
+	if len(s) != 13 || !digits(s) || (!strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979")) {
+		return false
+	}
+	return check13(s[:12]) == s[12]
+}
+
+// To13 mengubah ISBN-10 yang valid menjadi ISBN-13 dengan awalan 978.
+func To13(s string) (string, error) {
+	if !Valid10(s) {
+		return "", ErrInvalid
+	}
+	base := "978" + s[:9]
+	return base + string(check13(base)), nil
+}
+
+// To10 mengubah ISBN-13 menjadi ISBN-10, ok false untuk awalan 979 yang tidak punya bentuk ISBN-10.
+func To10(s string) (string, bool) {
+	if !Valid13(s) || !strings.HasPrefix(s, "978") {
+		return "", false
+	}
+	base := s[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(base[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return base + "X", true
+	}
+	return base + string(rune('0'+check)), true
+}
+
+func check13(base string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(base[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func digits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
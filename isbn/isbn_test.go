@@ -0,0 +1,55 @@
+package isbn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Run("isbn-13 dengan tanda hubung", func(t *testing.T) {
+		res, err := Normalize("978-0-306-40615-7")
+		assert.Nil(t, err)
+		assert.Equal(t, "9780306406157", res)
+	})
+
+	t.Run("isbn-10 diubah ke isbn-13", func(t *testing.T) {
+		res, err := Normalize("0-306-40615-2")
+		assert.Nil(t, err)
+		assert.Equal(t, "9780306406157", res)
+	})
+
+	t.Run("isbn-10 dengan check digit X", func(t *testing.T) {
+		res, err := Normalize("ISBN 0-8044-2957-x")
+		assert.Nil(t, err)
+		assert.Equal(t, "9780804429573", res)
+	})
+
+	t.Run("check digit salah", func(t *testing.T) {
+		_, err := Normalize("978-0-306-40615-8")
+		assert.ErrorIs(t, err, ErrInvalid)
+
+		_, err = Normalize("0-306-40615-3")
+		assert.ErrorIs(t, err, ErrInvalid)
+	})
+
+	t.Run("panjang atau karakter salah", func(t *testing.T) {
+		for _, val := range []string{"", "12345", "97803064061577", "0X06406152", "1230306406157"} {
+			_, err := Normalize(val)
+			assert.ErrorIs(t, err, ErrInvalid, val)
+		}
+	})
+}
+
+func TestTo10(t *testing.T) {
+	res, ok := To10("9780306406157")
+	assert.True(t, ok)
+	assert.Equal(t, "0306406152", res)
+
+	res, ok = To10("9780804429573")
+	assert.True(t, ok)
+	assert.Equal(t, "080442957X", res)
+
+	_, ok = To10("9791090636071")
+	assert.False(t, ok)
+}
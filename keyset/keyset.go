@@ -0,0 +1,231 @@
+// Package keyset mengelola kunci penanda tangan JWT dengan kid. Satu kunci aktif dipakai
+// untuk menandatangani, kunci lain tetap dipakai memverifikasi token lama selama rotasi.
+//
+// Rotasi kunci:
+//  1. tambahkan file kunci baru, kunci ini langsung terbit di JWKS tanpa dipakai menandatangani
+//  2. setelah layanan lain sempat mengambil JWKS baru, jadikan kunci baru aktif (JWT_ACTIVE_KID)
+//  3. hapus kunci lama setelah ACCESS_TOKEN_TTL, saat semua token lama sudah kedaluwarsa
+package keyset
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+type Key struct {
+	ID        string
+	Algorithm string
+	// NotAfter batas kunci dipakai memverifikasi, zero berarti tanpa batas
+	NotAfter time.Time
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign false untuk kunci yang hanya berisi public key.
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+func NewHMAC(kid string, secret []byte) *Key {
+	return &Key{ID: kid, Algorithm: AlgHS256, signKey: secret, verifyKey: secret}
+}
+
+// ParsePEM membaca private key RSA (PKCS#1/PKCS#8), private key Ed25519 (PKCS#8)
+// atau public key PKIX untuk kunci yang hanya dipakai verifikasi.
+func ParsePEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("kunci %s: bukan PEM", kid)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("kunci %s: tipe PEM %q tidak didukung", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kunci %s: %w", kid, err)
+	}
+
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.signKey, key.verifyKey = AlgRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.verifyKey = AlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.signKey, key.verifyKey = AlgEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.verifyKey = AlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("kunci %s: hanya RSA dan Ed25519 yang didukung", kid)
+	}
+	if k, ok := key.verifyKey.(*rsa.PublicKey); ok && k.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("kunci %s: RSA minimal 2048 bit", kid)
+	}
+
+	return key, nil
+}
+
+// LoadDir membaca semua file *.pem di dir, nama file tanpa ekstensi menjadi kid.
+func LoadDir(dir string) ([]*Key, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	keys := []*Key{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePEM(strings.TrimSuffix(filepath.Base(file), ".pem"), data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+type KeySet struct {
+	keys   map[string]*Key
+	active string
+}
+
+func New() *KeySet {
+	return &KeySet{keys: map[string]*Key{}}
+}
+
+func (ks *KeySet) Add(key *Key) {
+	ks.keys[key.ID] = key
+}
+
+func (ks *KeySet) SetActive(kid string) error {
+	key, ok := ks.keys[kid]
+	if !ok {
+		return fmt.Errorf("kid %q tidak ada di key set", kid)
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("kid %q hanya berisi public key", kid)
+	}
+	ks.active = kid
+	return nil
+}
+
+func (ks *KeySet) Active() *Key {
+	return ks.keys[ks.active]
+}
+
+// Sign menandatangani claims dengan kunci aktif dan menuliskan kid di header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, *jwt.Token, error) {
+	key := ks.Active()
+	if key == nil {
+		return "", nil, errors.New("tidak ada kunci aktif")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, token, nil
+}
+
+// Keyfunc untuk jwt.Parse. Algoritma token harus sama dengan algoritma kunci,
+// supaya public key RSA tidak bisa dipakai sebagai secret HS256.
+func (ks *KeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+	}
+	if !key.NotAfter.IsZero() && time.Now().After(key.NotAfter) {
+		return nil, fmt.Errorf("kid %q sudah tidak berlaku", kid)
+	}
+	return key.verifyKey, nil
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS hanya berisi public key RSA dan Ed25519 yang masih berlaku, secret HS256 tidak pernah dibuka.
+func (ks *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	res := JWKS{Keys: []JWK{}}
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		if !key.NotAfter.IsZero() && time.Now().After(key.NotAfter) {
+			continue
+		}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			res.Keys = append(res.Keys, JWK{
+				Kty: "RSA", Use: "sig", Alg: key.Algorithm, Kid: key.ID,
+				N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			res.Keys = append(res.Keys, JWK{
+				Kty: "OKP", Use: "sig", Alg: key.Algorithm, Kid: key.ID,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,138 @@
+package keyset
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKeys(t *testing.T) (string, *rsa.PrivateKey) {
+	dir := t.TempDir()
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2026-01.pem"), rsaPEM, 0o600))
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	edPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2026-02.pem"), edPEM, 0o600))
+
+	return dir, rsaKey
+}
+
+func parse(ks *KeySet, signed string) (*jwt.Token, error) {
+	return jwt.Parse(signed, ks.Keyfunc)
+}
+
+func TestRotation(t *testing.T) {
+	dir, _ := writeKeys(t)
+	keys, err := LoadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	ks := New()
+	for _, key := range keys {
+		ks.Add(key)
+	}
+
+	t.Run("RS256", func(t *testing.T) {
+		assert.NoError(t, ks.SetActive("2026-01"))
+		signed, token, err := ks.Sign(jwt.MapClaims{"userID": 1})
+		assert.NoError(t, err)
+		assert.Equal(t, "2026-01", token.Header["kid"])
+		assert.Equal(t, "RS256", token.Header["alg"])
+
+		parsed, err := parse(ks, signed)
+		assert.NoError(t, err)
+		assert.True(t, parsed.Valid)
+	})
+
+	t.Run("token lama tetap valid setelah rotasi ke EdDSA", func(t *testing.T) {
+		assert.NoError(t, ks.SetActive("2026-01"))
+		old, _, _ := ks.Sign(jwt.MapClaims{"userID": 1})
+
+		assert.NoError(t, ks.SetActive("2026-02"))
+		signed, token, err := ks.Sign(jwt.MapClaims{"userID": 1})
+		assert.NoError(t, err)
+		assert.Equal(t, "EdDSA", token.Header["alg"])
+
+		_, err = parse(ks, signed)
+		assert.NoError(t, err)
+		_, err = parse(ks, old)
+		assert.NoError(t, err)
+	})
+
+	t.Run("kid tidak dikenal", func(t *testing.T) {
+		other := New()
+		other.Add(NewHMAC("lain", []byte("rahasia")))
+		other.SetActive("lain")
+		signed, _, _ := other.Sign(jwt.MapClaims{"userID": 1})
+
+		_, err := parse(ks, signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("public key RSA tidak bisa dipakai sebagai secret HS256", func(t *testing.T) {
+		pub := x509.MarshalPKCS1PublicKey(ks.keys["2026-01"].verifyKey.(*rsa.PublicKey))
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userID": 1})
+		token.Header["kid"] = "2026-01"
+		signed, _ := token.SignedString(pub)
+
+		_, err := parse(ks, signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("kunci lewat NotAfter ditolak", func(t *testing.T) {
+		legacy := NewHMAC("", []byte("rahasia"))
+		ks.Add(legacy)
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userID": 1})
+		signed, _ := token.SignedString([]byte("rahasia"))
+
+		_, err := parse(ks, signed)
+		assert.NoError(t, err)
+
+		legacy.NotAfter = time.Now().Add(-time.Second)
+		_, err = parse(ks, signed)
+		assert.Error(t, err)
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	dir, rsaKey := writeKeys(t)
+	keys, _ := LoadDir(dir)
+	ks := New()
+	for _, key := range keys {
+		ks.Add(key)
+	}
+	ks.Add(NewHMAC("hmac", []byte("rahasia")))
+
+	set := ks.JWKS()
+	assert.Len(t, set.Keys, 2)
+	assert.Equal(t, "2026-01", set.Keys[0].Kid)
+	assert.Equal(t, "RSA", set.Keys[0].Kty)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.NotEmpty(t, set.Keys[0].N)
+	assert.Equal(t, "2026-02", set.Keys[1].Kid)
+	assert.Equal(t, "OKP", set.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", set.Keys[1].Crv)
+
+	t.Run("public key saja hanya untuk verifikasi", func(t *testing.T) {
+		der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		key, err := ParsePEM("lama", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		assert.NoError(t, err)
+		assert.False(t, key.CanSign())
+
+		ks.Add(key)
+		assert.Error(t, ks.SetActive("lama"))
+	})
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFile menulis setiap email ke file path (append), atau ke log jika path kosong.
+// Dipakai saat development supaya link verifikasi/reset bisa dibaca tanpa SMTP.
+func NewFile(path string) Mailer {
+	return &fileMailer{
+		path: path,
+	}
+}
+
+func (fm *fileMailer) Send(to, subject, body string) error {
+	msg := fmt.Sprintf("Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), to, subject, body)
+	if fm.path == "" {
+		log.Print("mail\n", msg)
+		return nil
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	f, err := os.OpenFile(fm.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Println("mail file error", err.Error())
+		return errors.New("gagal mengirim email")
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(msg); err != nil {
+		log.Println("mail file error", err.Error())
+		return errors.New("gagal mengirim email")
+	}
+
+	return nil
+}
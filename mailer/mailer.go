@@ -0,0 +1,7 @@
+package mailer
+
+// Mailer mengirim email teks biasa. Implementasi: SMTP untuk produksi,
+// File untuk development dan testing.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
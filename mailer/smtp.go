@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTP(host string, port int, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (sm *smtpMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("format email salah")
+	}
+
+	msg := "From: " + sm.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=\"utf-8\"\r\n" +
+		"\r\n" + body
+
+	if err := smtp.SendMail(sm.addr, sm.auth, sm.from, []string{to}, []byte(msg)); err != nil {
+		log.Println("smtp send error", err.Error())
+		return errors.New("gagal mengirim email")
+	}
+
+	return nil
+}
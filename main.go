@@ -2,13 +2,27 @@ package main
 
 import (
 	"api/config"
+	ahl "api/features/admin/handler"
+	asrv "api/features/admin/services"
+	"api/features/apikey"
+	kd "api/features/apikey/data"
+	khl "api/features/apikey/handler"
+	ksrv "api/features/apikey/services"
 	bd "api/features/book/data"
 	bhl "api/features/book/handler"
 	bsrv "api/features/book/services"
+	phl "api/features/profile/handler"
+	psrv "api/features/profile/services"
+	"api/features/user"
 	"api/features/user/data"
 	"api/features/user/handler"
 	"api/features/user/services"
+	"api/helper"
+	"api/middlewares"
 	"log"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -17,17 +31,43 @@ import (
 func main() {
 	e := echo.New()
 	cfg := config.InitConfig()
+	if err := config.InitJWTKeys(*cfg); err != nil {
+		log.Fatal("load jwt keys error : ", err.Error())
+	}
 	db := config.InitDB(*cfg)
 	config.Migrate(db)
 
+	blobs := config.InitBlobStore(*cfg)
 	userData := data.New(db)
-	userSrv := services.New(userData)
+	// penerima buku akun yang dihapus permanen harus user aktif, kalau tidak Purge selalu gagal
+	if config.PURGE_BOOKS_TO > 0 {
+		if _, err := userData.Profile(config.PURGE_BOOKS_TO); err != nil {
+			log.Fatal("ACCOUNT_PURGE_BOOKS_TO bukan user aktif : ", err.Error())
+		}
+	}
+	userSrv := services.New(userData, config.InitMailer(*cfg), config.InitOIDC(*cfg), config.InitPasswordPolicy(*cfg), blobs)
 	userHdl := handler.New(userSrv)
 
+	auth := middlewares.Auth(userSrv)
+	go cleanupTokens(userData)
+	go purgeDeactivated(userSrv)
+
+	keySrv := ksrv.New(kd.New(db), userData)
+	keyHdl := khl.New(keySrv)
+	apiAuth := middlewares.AuthOrAPIKey(userSrv, keySrv)
+
+	adminSrv := asrv.New(userData, blobs)
+	adminHdl := ahl.New(adminSrv)
+
 	bookData := bd.New(db)
-	bookSrv := bsrv.New(bookData)
+	profileSrv := psrv.New(userData, bookData)
+	profileHdl := phl.New(profileSrv)
+
+	bookSrv := bsrv.New(bookData, config.InitSearcher(*cfg, db), profileSrv, config.InitMetadata(*cfg))
 	bookHdl := bhl.New(bookSrv)
 
+	// X-Forwarded-For hanya dipercaya dari proxy di jaringan privat, IP dipakai untuk throttling login
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
 	e.Pre(middleware.RemoveTrailingSlash())
 	e.Use(middleware.CORS())
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
@@ -36,16 +76,94 @@ func main() {
 
 	e.POST("/register", userHdl.Register())
 	e.POST("/login", userHdl.Login())
-	e.GET("/users", userHdl.Profile(), middleware.JWT([]byte(config.JWT_KEY)))
-	e.PUT("/users", userHdl.Update(), middleware.JWT([]byte(config.JWT_KEY)))
-	e.DELETE("/users", userHdl.Deactive(), middleware.JWT([]byte(config.JWT_KEY)))
+	e.POST("/login/mfa", userHdl.LoginMFA())
+	e.GET("/login/oidc", userHdl.OIDCLogin())
+	e.GET("/login/oidc/callback", userHdl.OIDCCallback())
+	e.POST("/auth/refresh", userHdl.Refresh())
+	e.POST("/logout", userHdl.Logout(), auth)
+	e.POST("/password/forgot", userHdl.ForgotPassword())
+	e.POST("/password/reset", userHdl.ResetPassword())
+	e.GET("/verify-email", userHdl.VerifyEmail())
+	e.POST("/verify-email/resend", userHdl.ResendVerification())
+	e.GET("/users", userHdl.Profile(), auth)
+	e.PUT("/users", userHdl.Update(), auth)
+	e.PATCH("/users", userHdl.Patch(), auth)
+	e.DELETE("/users", userHdl.Deactive(), auth)
+	e.GET("/users/export", userHdl.Export(), auth)
+	e.POST("/users/email", userHdl.ChangeEmail(), auth)
+	// link di email membuka halaman konfirmasi, email baru diganti atau dibatalkan lewat POST dari halaman itu
+	e.GET("/users/email/confirm", userHdl.ConfirmEmailChangePage())
+	e.POST("/users/email/confirm", userHdl.ConfirmEmailChange())
+	e.GET("/users/email/revert", userHdl.RevertEmailChangePage())
+	e.POST("/users/email/revert", userHdl.RevertEmailChange())
+	e.POST("/users/mfa", userHdl.EnrollMFA(), auth)
+	e.POST("/users/mfa/confirm", userHdl.ConfirmMFA(), auth)
+	e.POST("/users/mfa/disable", userHdl.DisableMFA(), auth)
+	e.GET("/users/sessions", userHdl.Sessions(), auth)
+	e.DELETE("/users/sessions/:id", userHdl.RevokeSession(), auth)
+	e.GET("/users/security-events", userHdl.SecurityEvents(), auth)
+	// batas body sedikit di atas AVATAR_MAX_SIZE untuk overhead multipart, ukuran file dicek lagi di handler
+	e.POST("/users/avatar", userHdl.UploadAvatar(), auth, middleware.BodyLimit(strconv.FormatInt(config.AVATAR_MAX_SIZE+64<<10, 10)))
+	e.DELETE("/users/avatar", userHdl.DeleteAvatar(), auth)
+	e.GET("/users/:id/avatar", userHdl.Avatar())
+	e.GET("/users/:id/profile", profileHdl.Profile())
+	e.GET("/users/:id/books", profileHdl.Books())
+	e.POST("/users/api-keys", keyHdl.Create(), auth)
+	e.GET("/users/api-keys", keyHdl.List(), auth)
+	e.DELETE("/users/api-keys/:id", keyHdl.Revoke(), auth)
+
+	e.GET("/.well-known/jwks.json", jwks)
 
 	e.GET("/books", bookHdl.AllBook())
-	e.POST("/books", bookHdl.Add(), middleware.JWT([]byte(config.JWT_KEY)))
-	e.PUT("/books/:id", bookHdl.Update(), middleware.JWT([]byte(config.JWT_KEY)))
-	e.DELETE("/books/:id", bookHdl.Delete(), middleware.JWT([]byte(config.JWT_KEY)))
-	e.GET("/user/books", bookHdl.MyBook(), middleware.JWT([]byte(config.JWT_KEY)))
+	e.GET("/books/search", bookHdl.Search())
+	e.GET("/books/isbn/:isbn", bookHdl.ByISBN())
+	e.GET("/books/:id", bookHdl.Detail())
+	e.POST("/books", bookHdl.Add(), apiAuth, middlewares.RequireScope(apikey.ScopeBooksWrite))
+	e.PUT("/books/:id", bookHdl.Update(), apiAuth, middlewares.RequireScope(apikey.ScopeBooksWrite))
+	e.PATCH("/books/:id", bookHdl.Patch(), apiAuth, middlewares.RequireScope(apikey.ScopeBooksWrite))
+	e.DELETE("/books/:id", bookHdl.Delete(), apiAuth, middlewares.RequireScope(apikey.ScopeBooksWrite))
+	e.GET("/user/books", bookHdl.MyBook(), apiAuth, middlewares.RequireScope(apikey.ScopeBooksRead))
+
+	adm := e.Group("/admin", auth, middlewares.RequireRole(helper.RoleAdmin))
+	adm.GET("/users", adminHdl.ListUsers())
+	adm.GET("/users/:id", adminHdl.GetUser())
+	adm.POST("/users/:id/suspend", adminHdl.Suspend())
+	adm.POST("/users/:id/unsuspend", adminHdl.Unsuspend())
+	adm.POST("/users/:id/restore", adminHdl.Restore())
+	adm.POST("/users/:id/force-password-reset", adminHdl.ForcePasswordReset())
+	adm.DELETE("/users/:id", adminHdl.Purge())
+	adm.GET("/security-events", adminHdl.SecurityEvents())
+
 	if err := e.Start(":8000"); err != nil {
 		log.Println(err.Error())
 	}
 }
+
+// jwks menerbitkan public key penanda tangan access token untuk diverifikasi layanan lain.
+func jwks(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, config.JWTKeys().JWKS())
+}
+
+// cleanupTokens membersihkan denylist token dan refresh token yang sudah kedaluwarsa secara berkala.
+func cleanupTokens(ud user.UserData) {
+	for range time.Tick(time.Hour) {
+		if err := ud.CleanupExpiredTokens(); err != nil {
+			log.Println("cleanup token error : ", err.Error())
+		}
+	}
+}
+
+// purgeDeactivated menghapus permanen akun yang sudah lewat masa tenggang penghapusan secara berkala.
+func purgeDeactivated(srv user.UserService) {
+	for range time.Tick(time.Hour) {
+		n, err := srv.PurgeDeactivated()
+		if err != nil {
+			log.Println("purge deactivated user error : ", err.Error())
+			continue
+		}
+		if n > 0 {
+			log.Println("purged deactivated users : ", n)
+		}
+	}
+}
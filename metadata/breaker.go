@@ -0,0 +1,85 @@
+package metadata
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Breaker membatasi waktu setiap Lookup dan memutus provider setelah threshold kegagalan
+// berturut-turut. Selama cooldown semua Lookup langsung mengembalikan ErrUnavailable.
+// Setelah cooldown hanya satu Lookup yang diteruskan ke provider sebagai percobaan, Lookup lain
+// tetap ErrUnavailable sampai percobaan selesai. Sukses menutup breaker, gagal memutusnya lagi.
+type Breaker struct {
+	next      Provider
+	timeout   time.Duration
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewBreaker(next Provider, timeout time.Duration, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		next:      next,
+		timeout:   timeout,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+type lookupResult struct {
+	book Book
+	err  error
+}
+
+func (b *Breaker) Lookup(isbn string) (Book, error) {
+	b.mu.Lock()
+	if b.now().Before(b.openUntil) || b.probing {
+		b.mu.Unlock()
+		return Book{}, ErrUnavailable
+	}
+	probe := !b.openUntil.IsZero()
+	b.probing = probe
+	b.mu.Unlock()
+
+	// channel diberi buffer supaya goroutine tetap selesai walau hasilnya sudah tidak ditunggu
+	done := make(chan lookupResult, 1)
+	go func() {
+		book, err := b.next.Lookup(isbn)
+		done <- lookupResult{book: book, err: err}
+	}()
+
+	var res lookupResult
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+	select {
+	case res = <-done:
+	case <-timer.C:
+		res.err = errors.New("timeout")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if res.err == nil || errors.Is(res.err, ErrNotFound) {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return res.book, res.err
+	}
+
+	b.failures++
+	log.Println("metadata lookup error", res.err.Error())
+	if probe || b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+		b.failures = 0
+		log.Println("metadata provider diputus sampai", b.openUntil.Format(time.RFC3339))
+	}
+	return Book{}, ErrUnavailable
+}
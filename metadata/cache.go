@@ -0,0 +1,67 @@
+package metadata
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// cacheSize batas jumlah ISBN di cache, entri yang paling lama disimpan dibuang lebih dulu.
+const cacheSize = 10000
+
+type cacheEntry struct {
+	book    Book
+	found   bool
+	expires time.Time
+}
+
+// Cache menyimpan hasil Lookup di memori selama ttl, termasuk ISBN yang tidak ditemukan
+// supaya ISBN yang sama tidak terus ditanyakan. Error lain tidak disimpan.
+type Cache struct {
+	next Provider
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	order   []string
+}
+
+func NewCache(next Provider, ttl time.Duration) *Cache {
+	return &Cache{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+func (c *Cache) Lookup(isbn string) (Book, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[isbn]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		if !entry.found {
+			return Book{}, ErrNotFound
+		}
+		return entry.book, nil
+	}
+
+	book, err := c.next.Lookup(isbn)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Book{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[isbn]; !ok {
+		c.order = append(c.order, isbn)
+		if len(c.order) > cacheSize {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	c.entries[isbn] = cacheEntry{book: book, found: err == nil, expires: c.now().Add(c.ttl)}
+
+	return book, err
+}
@@ -0,0 +1,40 @@
+package metadata
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Local provider dari daftar buku tetap, untuk development tanpa internet dan testing.
+type Local struct {
+	books map[string]Book
+}
+
+func NewLocal(books ...Book) *Local {
+	l := &Local{books: map[string]Book{}}
+	for _, val := range books {
+		l.books[val.ISBN] = val
+	}
+	return l
+}
+
+// LoadLocal membaca fixture berupa array JSON Book.
+func LoadLocal(path string) (*Local, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	books := []Book{}
+	if err := json.Unmarshal(raw, &books); err != nil {
+		return nil, err
+	}
+	return NewLocal(books...), nil
+}
+
+func (l *Local) Lookup(isbn string) (Book, error) {
+	book, ok := l.books[isbn]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+	return book, nil
+}
@@ -0,0 +1,28 @@
+package metadata
+
+import "errors"
+
+var (
+	// ErrNotFound dikembalikan Lookup jika ISBN tidak dikenal provider.
+	ErrNotFound = errors.New("metadata buku not found")
+	// ErrUnavailable dikembalikan jika provider gagal, terlalu lama atau sedang diputus Breaker.
+	ErrUnavailable = errors.New("provider metadata buku sedang tidak tersedia")
+)
+
+// Book metadata satu edisi buku dari provider. Field yang tidak diketahui dibiarkan kosong.
+type Book struct {
+	ISBN      string   `json:"isbn"`
+	Title     string   `json:"title"`
+	Authors   []string `json:"authors"`
+	Publisher string   `json:"publisher"`
+	Year      int      `json:"year"`
+	Pages     int      `json:"pages"`
+	CoverURL  string   `json:"cover_url"`
+}
+
+// Provider sumber metadata buku berdasarkan ISBN-13 tanpa pemisah.
+// Implementasi: OpenLibrary (HTTP) dan Local (fixture untuk development dan testing),
+// dibungkus Cache dan Breaker supaya provider yang lambat atau mati tidak menahan request.
+type Provider interface {
+	Lookup(isbn string) (Book, error)
+}
@@ -0,0 +1,221 @@
+package metadata
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenLibrary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/books", r.URL.Path)
+		assert.Equal(t, "data", r.URL.Query().Get("jscmd"))
+		switch r.URL.Query().Get("bibkeys") {
+		case "ISBN:9780306406157":
+			w.Write([]byte(`{"ISBN:9780306406157":{"title":"Naruto","authors":[{"name":"Masashi Kishimoto"}],
+				"publishers":[{"name":"Shueisha"},{"name":"VIZ"}],"publish_date":"March 3, 1999","number_of_pages":192,
+				"cover":{"small":"https://covers.example.com/s.jpg","medium":"https://covers.example.com/m.jpg"}}}`))
+		case "ISBN:9780000000002":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+	ol := NewOpenLibrary(srv.URL + "/")
+
+	t.Run("buku ditemukan", func(t *testing.T) {
+		book, err := ol.Lookup("9780306406157")
+		assert.Nil(t, err)
+		assert.Equal(t, "Naruto", book.Title)
+		assert.Equal(t, []string{"Masashi Kishimoto"}, book.Authors)
+		assert.Equal(t, "Shueisha", book.Publisher)
+		assert.Equal(t, 1999, book.Year)
+		assert.Equal(t, 192, book.Pages)
+		assert.Equal(t, "https://covers.example.com/m.jpg", book.CoverURL)
+	})
+
+	t.Run("isbn tidak dikenal", func(t *testing.T) {
+		_, err := ol.Lookup("9789793062792")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		_, err := ol.Lookup("9780000000002")
+		assert.NotNil(t, err)
+		assert.False(t, errors.Is(err, ErrNotFound))
+	})
+}
+
+func TestLocal(t *testing.T) {
+	local, err := LoadLocal("testdata/books.json")
+	assert.Nil(t, err)
+
+	book, err := local.Lookup("9789793062792")
+	assert.Nil(t, err)
+	assert.Equal(t, "Laskar Pelangi", book.Title)
+	assert.Equal(t, 2005, book.Year)
+
+	_, err = local.Lookup("9780000000002")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = LoadLocal("testdata/tidak-ada.json")
+	assert.NotNil(t, err)
+}
+
+// countProvider provider palsu yang menghitung pemanggilan dan bisa dibuat gagal atau lambat.
+type countProvider struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+	delay time.Duration
+}
+
+func (p *countProvider) Lookup(isbn string) (Book, error) {
+	p.mu.Lock()
+	p.calls++
+	err, delay := p.err, p.delay
+	p.mu.Unlock()
+
+	time.Sleep(delay)
+	if err != nil {
+		return Book{}, err
+	}
+	if isbn == "9780000000002" {
+		return Book{}, ErrNotFound
+	}
+	return Book{ISBN: isbn, Title: "Naruto"}, nil
+}
+
+func (p *countProvider) set(err error, delay time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err, p.delay = err, delay
+}
+
+func (p *countProvider) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func TestCache(t *testing.T) {
+	next := &countProvider{}
+	cache := NewCache(next, time.Hour)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	t.Run("hasil disimpan", func(t *testing.T) {
+		book, err := cache.Lookup("9780306406157")
+		assert.Nil(t, err)
+		book, err = cache.Lookup("9780306406157")
+		assert.Nil(t, err)
+		assert.Equal(t, "Naruto", book.Title)
+		assert.Equal(t, 1, next.count())
+	})
+
+	t.Run("isbn tidak dikenal juga disimpan", func(t *testing.T) {
+		_, err := cache.Lookup("9780000000002")
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = cache.Lookup("9780000000002")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 2, next.count())
+	})
+
+	t.Run("cache kedaluwarsa", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+		_, err := cache.Lookup("9780306406157")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, next.count())
+	})
+
+	t.Run("error provider tidak disimpan", func(t *testing.T) {
+		next.set(errors.New("connection refused"), 0)
+		_, err := cache.Lookup("9789793062792")
+		assert.NotNil(t, err)
+
+		next.set(nil, 0)
+		_, err = cache.Lookup("9789793062792")
+		assert.Nil(t, err)
+		assert.Equal(t, 5, next.count())
+	})
+}
+
+func TestBreaker(t *testing.T) {
+	next := &countProvider{}
+	breaker := NewBreaker(next, 50*time.Millisecond, 2, time.Minute)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	t.Run("lookup berhasil", func(t *testing.T) {
+		book, err := breaker.Lookup("9780306406157")
+		assert.Nil(t, err)
+		assert.Equal(t, "Naruto", book.Title)
+	})
+
+	t.Run("isbn tidak dikenal bukan kegagalan", func(t *testing.T) {
+		_, err := breaker.Lookup("9780000000002")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		next.set(nil, time.Second)
+		start := time.Now()
+		_, err := breaker.Lookup("9780306406157")
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		next.set(nil, 0)
+	})
+
+	t.Run("diputus setelah gagal berturut-turut", func(t *testing.T) {
+		next.set(errors.New("connection refused"), 0)
+		_, err := breaker.Lookup("9780306406157")
+		assert.ErrorIs(t, err, ErrUnavailable)
+
+		calls := next.count()
+		next.set(nil, 0)
+		_, err = breaker.Lookup("9780306406157")
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, calls, next.count())
+	})
+
+	t.Run("percobaan gagal memutus lagi", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		next.set(errors.New("connection refused"), 0)
+		_, err := breaker.Lookup("9780306406157")
+		assert.ErrorIs(t, err, ErrUnavailable)
+
+		calls := next.count()
+		next.set(nil, 0)
+		_, err = breaker.Lookup("9780306406157")
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Equal(t, calls, next.count())
+	})
+
+	t.Run("hanya satu percobaan setelah cooldown", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		next.set(nil, 30*time.Millisecond)
+		calls := next.count()
+
+		probe := make(chan error, 1)
+		go func() {
+			_, err := breaker.Lookup("9780306406157")
+			probe <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		_, err := breaker.Lookup("9780306406157")
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.Nil(t, <-probe)
+		assert.Equal(t, calls+1, next.count())
+
+		next.set(nil, 0)
+		book, err := breaker.Lookup("9780306406157")
+		assert.Nil(t, err)
+		assert.Equal(t, "Naruto", book.Title)
+	})
+}
@@ -0,0 +1,97 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenLibrary provider untuk API books Open Library (/api/books?bibkeys=ISBN:...&jscmd=data)
+// atau layanan lain dengan format yang sama.
+type OpenLibrary struct {
+	baseURL string
+	http    *http.Client
+}
+
+func NewOpenLibrary(baseURL string) *OpenLibrary {
+	return &OpenLibrary{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type olName struct {
+	Name string `json:"name"`
+}
+
+type olBook struct {
+	Title         string   `json:"title"`
+	Authors       []olName `json:"authors"`
+	Publishers    []olName `json:"publishers"`
+	PublishDate   string   `json:"publish_date"`
+	NumberOfPages int      `json:"number_of_pages"`
+	Cover         struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"cover"`
+}
+
+// yearPattern tahun empat digit pada publish_date, formatnya bebas mis. "1999" atau "March 3, 2005".
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+func (ol *OpenLibrary) Lookup(isbn string) (Book, error) {
+	key := "ISBN:" + isbn
+	q := url.Values{}
+	q.Set("bibkeys", key)
+	q.Set("format", "json")
+	q.Set("jscmd", "data")
+
+	resp, err := ol.http.Get(ol.baseURL + "/api/books?" + q.Encode())
+	if err != nil {
+		return Book{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Book{}, fmt.Errorf("open library: status %d", resp.StatusCode)
+	}
+
+	res := map[string]olBook{}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return Book{}, fmt.Errorf("open library: %w", err)
+	}
+	data, ok := res[key]
+	if !ok {
+		return Book{}, ErrNotFound
+	}
+
+	book := Book{
+		ISBN:  isbn,
+		Title: data.Title,
+		Pages: data.NumberOfPages,
+	}
+	for _, val := range data.Authors {
+		book.Authors = append(book.Authors, val.Name)
+	}
+	if len(data.Publishers) > 0 {
+		book.Publisher = data.Publishers[0].Name
+	}
+	if year := yearPattern.FindString(data.PublishDate); year != "" {
+		book.Year, _ = strconv.Atoi(year)
+	}
+	switch {
+	case data.Cover.Large != "":
+		book.CoverURL = data.Cover.Large
+	case data.Cover.Medium != "":
+		book.CoverURL = data.Cover.Medium
+	default:
+		book.CoverURL = data.Cover.Small
+	}
+
+	return book, nil
+}
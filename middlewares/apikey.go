@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"api/auth"
+	"api/helper"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyChecker menukar API key menjadi principal pemilik key beserta scope-nya.
+type APIKeyChecker interface {
+	CheckAPIKey(key string) (auth.Principal, error)
+}
+
+// AuthOrAPIKey menerima header X-API-Key, jika tidak ada request diteruskan ke Auth (Bearer JWT).
+// Hanya dipasang di route yang juga memakai RequireScope.
+func AuthOrAPIKey(checker TokenChecker, keys APIKeyChecker) echo.MiddlewareFunc {
+	auth := Auth(checker)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withJWT := auth(next)
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get("X-API-Key")
+			if key == "" {
+				return withJWT(c)
+			}
+
+			p, err := keys.CheckAPIKey(key)
+			if err != nil {
+				return c.JSON(helper.PrintErrorResponse(err.Error()))
+			}
+			setPrincipal(c, p)
+			return next(c)
+		}
+	}
+}
+
+// RequireScope menolak API key yang tidak punya scope, JWT login biasa selalu lolos.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !helper.Principal(c).HasScope(scope) {
+				return c.JSON(http.StatusForbidden, map[string]interface{}{"message": "akses ditolak, api key tidak punya scope " + scope})
+			}
+			return next(c)
+		}
+	}
+}
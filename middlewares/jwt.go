@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"api/config"
+	"errors"
+	"net/http"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// JWT sama dengan middleware.JWT bawaan echo, tetapi kunci verifikasi dipilih dari key set
+// berdasarkan kid, dan selalu membalas 401 dengan pesan yang membedakan token kosong,
+// token tidak valid dan token kedaluwarsa.
+func JWT() echo.MiddlewareFunc {
+	return middleware.JWTWithConfig(middleware.JWTConfig{
+		KeyFunc: func(t *jwt.Token) (interface{}, error) {
+			return config.JWTKeys().Keyfunc(t)
+		},
+		ErrorHandlerWithContext: jwtErrorHandler,
+	})
+}
+
+func jwtErrorHandler(err error, c echo.Context) error {
+	msg := "token tidak valid"
+
+	var vErr *jwt.ValidationError
+	if errors.Is(err, middleware.ErrJWTMissing) {
+		msg = "token tidak ditemukan"
+	} else if errors.As(err, &vErr) && vErr.Errors&jwt.ValidationErrorExpired != 0 {
+		msg = "token sudah kedaluwarsa, silakan refresh token"
+	}
+
+	return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": msg})
+}
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"api/auth"
+	"api/config"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+// principalFromJWT membaca claim access token yang sudah diverifikasi middleware JWT.
+// ok false jika token tidak valid, bukan diterbitkan APP_URL ini (iss) atau tidak berisi userID.
+func principalFromJWT(t interface{}) (auth.Principal, bool) {
+	token, ok := t.(*jwt.Token)
+	if !ok || !token.Valid {
+		return auth.Principal{}, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyIssuer(config.APP_URL, true) {
+		return auth.Principal{}, false
+	}
+
+	p := auth.Principal{
+		UserID:    claimUint(claims["userID"]),
+		SessionID: claimUint(claims["sid"]),
+	}
+	if p.UserID == 0 {
+		return auth.Principal{}, false
+	}
+	if role, _ := claims["role"].(string); role != "" {
+		p.Roles = []string{role}
+	}
+	p.TokenID, _ = claims["jti"].(string)
+	if exp, ok := claims["exp"].(float64); ok {
+		p.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+
+	return p, true
+}
+
+// claimUint angka di MapClaims hasil parse JSON selalu float64.
+func claimUint(val interface{}) uint {
+	if num, ok := val.(float64); ok && num > 0 {
+		return uint(num)
+	}
+	return 0
+}
+
+// setPrincipal menaruh principal di context request untuk dibaca handler lewat helper.Principal,
+// lengkap dengan IP dan user agent request.
+func setPrincipal(c echo.Context, p auth.Principal) {
+	req := c.Request()
+	p.IP, p.UserAgent = c.RealIP(), req.UserAgent()
+	c.SetRequest(req.WithContext(auth.NewContext(req.Context(), p)))
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"api/config"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipalFromJWT(t *testing.T) {
+	token := func(iss interface{}) *jwt.Token {
+		claims := jwt.MapClaims{"userID": float64(1), "role": "user", "sid": float64(7)}
+		if iss != nil {
+			claims["iss"] = iss
+		}
+		return &jwt.Token{Valid: true, Claims: claims}
+	}
+
+	t.Run("issuer sesuai", func(t *testing.T) {
+		p, ok := principalFromJWT(token(config.APP_URL))
+		assert.True(t, ok)
+		assert.Equal(t, uint(1), p.UserID)
+		assert.Equal(t, uint(7), p.SessionID)
+	})
+
+	t.Run("issuer lain ditolak", func(t *testing.T) {
+		_, ok := principalFromJWT(token("https://aplikasi-lain.example.com"))
+		assert.False(t, ok)
+	})
+
+	t.Run("tanpa issuer ditolak", func(t *testing.T) {
+		_, ok := principalFromJWT(token(nil))
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"api/auth"
+	"api/helper"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TokenChecker memutuskan apakah JWT yang signature-nya valid masih boleh dipakai.
+type TokenChecker interface {
+	CheckToken(p auth.Principal) error
+}
+
+// Auth membungkus JWT dengan pengecekan revocation (logout, akun nonaktif),
+// lalu menaruh auth.Principal dari token di context request.
+func Auth(checker TokenChecker) echo.MiddlewareFunc {
+	jwtMiddleware := JWT()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtMiddleware(func(c echo.Context) error {
+			p, ok := principalFromJWT(c.Get("user"))
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]interface{}{"message": "token tidak valid"})
+			}
+			if err := checker.CheckToken(p); err != nil {
+				return c.JSON(helper.PrintErrorResponse(err.Error()))
+			}
+			setPrincipal(c, p)
+			return next(c)
+		})
+	}
+}
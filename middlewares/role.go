@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"api/helper"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRole menolak request (403) jika role principal tidak termasuk roles.
+// Harus dipasang setelah Auth karena membaca principal dari context request.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !helper.Principal(c).HasRole(roles...) {
+				return c.JSON(http.StatusForbidden, map[string]interface{}{"message": "akses ditolak"})
+			}
+			return next(c)
+		}
+	}
+}
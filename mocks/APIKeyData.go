@@ -0,0 +1,124 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	apikey "api/features/apikey"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// APIKeyData is an autogenerated mock type for the APIKeyData type
+type APIKeyData struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: newKey
+func (_m *APIKeyData) Create(newKey apikey.Core) (apikey.Core, error) {
+	ret := _m.Called(newKey)
+
+	var r0 apikey.Core
+	if rf, ok := ret.Get(0).(func(apikey.Core) apikey.Core); ok {
+		r0 = rf(newKey)
+	} else {
+		r0 = ret.Get(0).(apikey.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(apikey.Core) error); ok {
+		r1 = rf(newKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByHash provides a mock function with given fields: keyHash
+func (_m *APIKeyData) GetByHash(keyHash string) (apikey.Core, error) {
+	ret := _m.Called(keyHash)
+
+	var r0 apikey.Core
+	if rf, ok := ret.Get(0).(func(string) apikey.Core); ok {
+		r0 = rf(keyHash)
+	} else {
+		r0 = ret.Get(0).(apikey.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: userID
+func (_m *APIKeyData) List(userID uint) ([]apikey.Core, error) {
+	ret := _m.Called(userID)
+
+	var r0 []apikey.Core
+	if rf, ok := ret.Get(0).(func(uint) []apikey.Core); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]apikey.Core)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: userID, keyID
+func (_m *APIKeyData) Revoke(userID uint, keyID uint) error {
+	ret := _m.Called(userID, keyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, uint) error); ok {
+		r0 = rf(userID, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Touch provides a mock function with given fields: keyID, usedAt
+func (_m *APIKeyData) Touch(keyID uint, usedAt time.Time) error {
+	ret := _m.Called(keyID, usedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, time.Time) error); ok {
+		r0 = rf(keyID, usedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAPIKeyData interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAPIKeyData creates a new instance of APIKeyData. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAPIKeyData(t mockConstructorTestingTNewAPIKeyData) *APIKeyData {
+	mock := &APIKeyData{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,76 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	echo "github.com/labstack/echo/v4"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// APIKeyHandler is an autogenerated mock type for the APIKeyHandler type
+type APIKeyHandler struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields:
+func (_m *APIKeyHandler) Create() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// List provides a mock function with given fields:
+func (_m *APIKeyHandler) List() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Revoke provides a mock function with given fields:
+func (_m *APIKeyHandler) Revoke() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAPIKeyHandler interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAPIKeyHandler creates a new instance of APIKeyHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAPIKeyHandler(t mockConstructorTestingTNewAPIKeyHandler) *APIKeyHandler {
+	mock := &APIKeyHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
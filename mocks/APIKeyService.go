@@ -0,0 +1,116 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	auth "api/auth"
+	apikey "api/features/apikey"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// APIKeyService is an autogenerated mock type for the APIKeyService type
+type APIKeyService struct {
+	mock.Mock
+}
+
+// CheckAPIKey provides a mock function with given fields: key
+func (_m *APIKeyService) CheckAPIKey(key string) (auth.Principal, error) {
+	ret := _m.Called(key)
+
+	var r0 auth.Principal
+	if rf, ok := ret.Get(0).(func(string) auth.Principal); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(auth.Principal)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: p, newKey
+func (_m *APIKeyService) Create(p auth.Principal, newKey apikey.Core) (apikey.Core, string, error) {
+	ret := _m.Called(p, newKey)
+
+	var r0 apikey.Core
+	if rf, ok := ret.Get(0).(func(auth.Principal, apikey.Core) apikey.Core); ok {
+		r0 = rf(p, newKey)
+	} else {
+		r0 = ret.Get(0).(apikey.Core)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(auth.Principal, apikey.Core) string); ok {
+		r1 = rf(p, newKey)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(auth.Principal, apikey.Core) error); ok {
+		r2 = rf(p, newKey)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// List provides a mock function with given fields: p
+func (_m *APIKeyService) List(p auth.Principal) ([]apikey.Core, error) {
+	ret := _m.Called(p)
+
+	var r0 []apikey.Core
+	if rf, ok := ret.Get(0).(func(auth.Principal) []apikey.Core); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]apikey.Core)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: p, keyID
+func (_m *APIKeyService) Revoke(p auth.Principal, keyID uint) error {
+	ret := _m.Called(p, keyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAPIKeyService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAPIKeyService(t mockConstructorTestingTNewAPIKeyService) *APIKeyService {
+	mock := &APIKeyService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,181 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	auth "api/auth"
+	user "api/features/user"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AdminService is an autogenerated mock type for the AdminService type
+type AdminService struct {
+	mock.Mock
+}
+
+// ForcePasswordReset provides a mock function with given fields: p, userID
+func (_m *AdminService) ForcePasswordReset(p auth.Principal, userID uint) error {
+	ret := _m.Called(p, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetUser provides a mock function with given fields: userID
+func (_m *AdminService) GetUser(userID uint) (user.Core, error) {
+	ret := _m.Called(userID)
+
+	var r0 user.Core
+	if rf, ok := ret.Get(0).(func(uint) user.Core); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(user.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: filter
+func (_m *AdminService) ListUsers(filter user.UserFilter) ([]user.Core, int64, error) {
+	ret := _m.Called(filter)
+
+	var r0 []user.Core
+	if rf, ok := ret.Get(0).(func(user.UserFilter) []user.Core); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.Core)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(user.UserFilter) int64); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(user.UserFilter) error); ok {
+		r2 = rf(filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Purge provides a mock function with given fields: p, userID
+func (_m *AdminService) Purge(p auth.Principal, userID uint) error {
+	ret := _m.Called(p, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: p, userID
+func (_m *AdminService) Restore(p auth.Principal, userID uint) error {
+	ret := _m.Called(p, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SecurityEvents provides a mock function with given fields: filter
+func (_m *AdminService) SecurityEvents(filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error) {
+	ret := _m.Called(filter)
+
+	var r0 []user.SecurityEventCore
+	if rf, ok := ret.Get(0).(func(user.SecurityEventFilter) []user.SecurityEventCore); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.SecurityEventCore)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(user.SecurityEventFilter) int64); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(user.SecurityEventFilter) error); ok {
+		r2 = rf(filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Suspend provides a mock function with given fields: p, userID
+func (_m *AdminService) Suspend(p auth.Principal, userID uint) error {
+	ret := _m.Called(p, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unsuspend provides a mock function with given fields: p, userID
+func (_m *AdminService) Unsuspend(p auth.Principal, userID uint) error {
+	ret := _m.Called(p, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAdminService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAdminService creates a new instance of AdminService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAdminService(t mockConstructorTestingTNewAdminService) *AdminService {
+	mock := &AdminService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
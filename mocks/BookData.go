@@ -34,22 +34,50 @@ func (_m *BookData) Add(userID int, newBook book.Core) (book.Core, error) {
 	return r0, r1
 }
 
-// AllBook provides a mock function with given fields:
-func (_m *BookData) AllBook() ([]book.Core, error) {
-	ret := _m.Called()
+// AllBook provides a mock function with given fields: filter
+func (_m *BookData) AllBook(filter book.BookFilter) ([]book.Core, int64, error) {
+	ret := _m.Called(filter)
 
 	var r0 []book.Core
-	if rf, ok := ret.Get(0).(func() []book.Core); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(book.BookFilter) []book.Core); ok {
+		r0 = rf(filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]book.Core)
 		}
 	}
 
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(book.BookFilter) int64); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(book.BookFilter) error); ok {
+		r2 = rf(filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CountByOwner provides a mock function with given fields: userID
+func (_m *BookData) CountByOwner(userID int) (int64, error) {
+	ret := _m.Called(userID)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(int) int64); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -71,6 +99,94 @@ func (_m *BookData) Delete(userID int, bookID int) error {
 	return r0
 }
 
+// Detail provides a mock function with given fields: bookID
+func (_m *BookData) Detail(bookID int) (book.Core, error) {
+	ret := _m.Called(bookID)
+
+	var r0 book.Core
+	if rf, ok := ret.Get(0).(func(int) book.Core); ok {
+		r0 = rf(bookID)
+	} else {
+		r0 = ret.Get(0).(book.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(bookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: userID, bookID
+func (_m *BookData) Get(userID int, bookID int) (book.Core, error) {
+	ret := _m.Called(userID, bookID)
+
+	var r0 book.Core
+	if rf, ok := ret.Get(0).(func(int, int) book.Core); ok {
+		r0 = rf(userID, bookID)
+	} else {
+		r0 = ret.Get(0).(book.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(userID, bookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByIDs provides a mock function with given fields: ids
+func (_m *BookData) GetByIDs(ids []uint) ([]book.Core, error) {
+	ret := _m.Called(ids)
+
+	var r0 []book.Core
+	if rf, ok := ret.Get(0).(func([]uint) []book.Core); ok {
+		r0 = rf(ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]book.Core)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]uint) error); ok {
+		r1 = rf(ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByISBN provides a mock function with given fields: isbn
+func (_m *BookData) GetByISBN(isbn string) ([]book.Core, error) {
+	ret := _m.Called(isbn)
+
+	var r0 []book.Core
+	if rf, ok := ret.Get(0).(func(string) []book.Core); ok {
+		r0 = rf(isbn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]book.Core)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(isbn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // MyBook provides a mock function with given fields: userID
 func (_m *BookData) MyBook(userID int) ([]book.Core, error) {
 	ret := _m.Called(userID)
@@ -94,20 +210,20 @@ func (_m *BookData) MyBook(userID int) ([]book.Core, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: userID, bookID, updatedData
-func (_m *BookData) Update(userID int, bookID int, updatedData book.Core) (book.Core, error) {
-	ret := _m.Called(userID, bookID, updatedData)
+// Update provides a mock function with given fields: userID, bookID, updatedData, fields
+func (_m *BookData) Update(userID int, bookID int, updatedData book.Core, fields []string) (book.Core, error) {
+	ret := _m.Called(userID, bookID, updatedData, fields)
 
 	var r0 book.Core
-	if rf, ok := ret.Get(0).(func(int, int, book.Core) book.Core); ok {
-		r0 = rf(userID, bookID, updatedData)
+	if rf, ok := ret.Get(0).(func(int, int, book.Core, []string) book.Core); ok {
+		r0 = rf(userID, bookID, updatedData, fields)
 	} else {
 		r0 = ret.Get(0).(book.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, int, book.Core) error); ok {
-		r1 = rf(userID, bookID, updatedData)
+	if rf, ok := ret.Get(1).(func(int, int, book.Core, []string) error); ok {
+		r1 = rf(userID, bookID, updatedData, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,6 +44,22 @@ func (_m *BookHandler) AllBook() echo.HandlerFunc {
 	return r0
 }
 
+// ByISBN provides a mock function with given fields:
+func (_m *BookHandler) ByISBN() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields:
 func (_m *BookHandler) Delete() echo.HandlerFunc {
 	ret := _m.Called()
@@ -60,6 +76,22 @@ func (_m *BookHandler) Delete() echo.HandlerFunc {
 	return r0
 }
 
+// Detail provides a mock function with given fields:
+func (_m *BookHandler) Detail() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // MyBook provides a mock function with given fields:
 func (_m *BookHandler) MyBook() echo.HandlerFunc {
 	ret := _m.Called()
@@ -76,6 +108,38 @@ func (_m *BookHandler) MyBook() echo.HandlerFunc {
 	return r0
 }
 
+// Patch provides a mock function with given fields:
+func (_m *BookHandler) Patch() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields:
+func (_m *BookHandler) Search() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields:
 func (_m *BookHandler) Update() echo.HandlerFunc {
 	ret := _m.Called()
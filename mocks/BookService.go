@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	auth "api/auth"
 	book "api/features/book"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,20 +14,20 @@ type BookService struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: token, newBook
-func (_m *BookService) Add(token interface{}, newBook book.Core) (book.Core, error) {
-	ret := _m.Called(token, newBook)
+// Add provides a mock function with given fields: p, newBook
+func (_m *BookService) Add(p auth.Principal, newBook book.Core) (book.Core, error) {
+	ret := _m.Called(p, newBook)
 
 	var r0 book.Core
-	if rf, ok := ret.Get(0).(func(interface{}, book.Core) book.Core); ok {
-		r0 = rf(token, newBook)
+	if rf, ok := ret.Get(0).(func(auth.Principal, book.Core) book.Core); ok {
+		r0 = rf(p, newBook)
 	} else {
 		r0 = ret.Get(0).(book.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(interface{}, book.Core) error); ok {
-		r1 = rf(token, newBook)
+	if rf, ok := ret.Get(1).(func(auth.Principal, book.Core) error); ok {
+		r1 = rf(p, newBook)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,13 +35,43 @@ func (_m *BookService) Add(token interface{}, newBook book.Core) (book.Core, err
 	return r0, r1
 }
 
-// AllBook provides a mock function with given fields:
-func (_m *BookService) AllBook() ([]book.Core, error) {
-	ret := _m.Called()
+// AllBook provides a mock function with given fields: filter
+func (_m *BookService) AllBook(filter book.BookFilter) ([]book.Core, book.PageCore, error) {
+	ret := _m.Called(filter)
 
 	var r0 []book.Core
-	if rf, ok := ret.Get(0).(func() []book.Core); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(book.BookFilter) []book.Core); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]book.Core)
+		}
+	}
+
+	var r1 book.PageCore
+	if rf, ok := ret.Get(1).(func(book.BookFilter) book.PageCore); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Get(1).(book.PageCore)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(book.BookFilter) error); ok {
+		r2 = rf(filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ByISBN provides a mock function with given fields: isbn
+func (_m *BookService) ByISBN(isbn string) ([]book.Core, error) {
+	ret := _m.Called(isbn)
+
+	var r0 []book.Core
+	if rf, ok := ret.Get(0).(func(string) []book.Core); ok {
+		r0 = rf(isbn)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]book.Core)
@@ -48,8 +79,8 @@ func (_m *BookService) AllBook() ([]book.Core, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(isbn)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -57,13 +88,13 @@ func (_m *BookService) AllBook() ([]book.Core, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: token, bookID
-func (_m *BookService) Delete(token interface{}, bookID int) error {
-	ret := _m.Called(token, bookID)
+// Delete provides a mock function with given fields: p, bookID
+func (_m *BookService) Delete(p auth.Principal, bookID int) error {
+	ret := _m.Called(p, bookID)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(interface{}, int) error); ok {
-		r0 = rf(token, bookID)
+	if rf, ok := ret.Get(0).(func(auth.Principal, int) error); ok {
+		r0 = rf(p, bookID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -71,22 +102,71 @@ func (_m *BookService) Delete(token interface{}, bookID int) error {
 	return r0
 }
 
-// MyBook provides a mock function with given fields: token
-func (_m *BookService) MyBook(token interface{}) ([]book.Core, error) {
-	ret := _m.Called(token)
+// Detail provides a mock function with given fields: bookID
+func (_m *BookService) Detail(bookID int) (book.DetailCore, error) {
+	ret := _m.Called(bookID)
+
+	var r0 book.DetailCore
+	if rf, ok := ret.Get(0).(func(int) book.DetailCore); ok {
+		r0 = rf(bookID)
+	} else {
+		r0 = ret.Get(0).(book.DetailCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(bookID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MyBook provides a mock function with given fields: p, filter
+func (_m *BookService) MyBook(p auth.Principal, filter book.BookFilter) ([]book.Core, book.PageCore, error) {
+	ret := _m.Called(p, filter)
 
 	var r0 []book.Core
-	if rf, ok := ret.Get(0).(func(interface{}) []book.Core); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(auth.Principal, book.BookFilter) []book.Core); ok {
+		r0 = rf(p, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]book.Core)
 		}
 	}
 
+	var r1 book.PageCore
+	if rf, ok := ret.Get(1).(func(auth.Principal, book.BookFilter) book.PageCore); ok {
+		r1 = rf(p, filter)
+	} else {
+		r1 = ret.Get(1).(book.PageCore)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(auth.Principal, book.BookFilter) error); ok {
+		r2 = rf(p, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Patch provides a mock function with given fields: p, bookID, patch
+func (_m *BookService) Patch(p auth.Principal, bookID int, patch []byte) (book.Core, error) {
+	ret := _m.Called(p, bookID, patch)
+
+	var r0 book.Core
+	if rf, ok := ret.Get(0).(func(auth.Principal, int, []byte) book.Core); ok {
+		r0 = rf(p, bookID, patch)
+	} else {
+		r0 = ret.Get(0).(book.Core)
+	}
+
 	var r1 error
-	if rf, ok := ret.Get(1).(func(interface{}) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(auth.Principal, int, []byte) error); ok {
+		r1 = rf(p, bookID, patch)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,20 +174,50 @@ func (_m *BookService) MyBook(token interface{}) ([]book.Core, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: token, bookID, updatedData
-func (_m *BookService) Update(token interface{}, bookID int, updatedData book.Core) (book.Core, error) {
-	ret := _m.Called(token, bookID, updatedData)
+// Search provides a mock function with given fields: query, page, limit
+func (_m *BookService) Search(query string, page int, limit int) ([]book.SearchCore, book.PageCore, error) {
+	ret := _m.Called(query, page, limit)
+
+	var r0 []book.SearchCore
+	if rf, ok := ret.Get(0).(func(string, int, int) []book.SearchCore); ok {
+		r0 = rf(query, page, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]book.SearchCore)
+		}
+	}
+
+	var r1 book.PageCore
+	if rf, ok := ret.Get(1).(func(string, int, int) book.PageCore); ok {
+		r1 = rf(query, page, limit)
+	} else {
+		r1 = ret.Get(1).(book.PageCore)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, int, int) error); ok {
+		r2 = rf(query, page, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Update provides a mock function with given fields: p, bookID, updatedData
+func (_m *BookService) Update(p auth.Principal, bookID int, updatedData book.Core) (book.Core, error) {
+	ret := _m.Called(p, bookID, updatedData)
 
 	var r0 book.Core
-	if rf, ok := ret.Get(0).(func(interface{}, int, book.Core) book.Core); ok {
-		r0 = rf(token, bookID, updatedData)
+	if rf, ok := ret.Get(0).(func(auth.Principal, int, book.Core) book.Core); ok {
+		r0 = rf(p, bookID, updatedData)
 	} else {
 		r0 = ret.Get(0).(book.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(interface{}, int, book.Core) error); ok {
-		r1 = rf(token, bookID, updatedData)
+	if rf, ok := ret.Get(1).(func(auth.Principal, int, book.Core) error); ok {
+		r1 = rf(p, bookID, updatedData)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,71 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	oidc "api/oidc"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdentityProvider is an autogenerated mock type for the IdentityProvider type
+type IdentityProvider struct {
+	mock.Mock
+}
+
+// AuthCodeURL provides a mock function with given fields: state, nonce, codeChallenge
+func (_m *IdentityProvider) AuthCodeURL(state string, nonce string, codeChallenge string) (string, error) {
+	ret := _m.Called(state, nonce, codeChallenge)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(state, nonce, codeChallenge)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(state, nonce, codeChallenge)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Exchange provides a mock function with given fields: code, codeVerifier, nonce
+func (_m *IdentityProvider) Exchange(code string, codeVerifier string, nonce string) (oidc.Claims, error) {
+	ret := _m.Called(code, codeVerifier, nonce)
+
+	var r0 oidc.Claims
+	if rf, ok := ret.Get(0).(func(string, string, string) oidc.Claims); ok {
+		r0 = rf(code, codeVerifier, nonce)
+	} else {
+		r0 = ret.Get(0).(oidc.Claims)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(code, codeVerifier, nonce)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewIdentityProvider interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewIdentityProvider creates a new instance of IdentityProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewIdentityProvider(t mockConstructorTestingTNewIdentityProvider) *IdentityProvider {
+	mock := &IdentityProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,60 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	echo "github.com/labstack/echo/v4"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProfileHandler is an autogenerated mock type for the ProfileHandler type
+type ProfileHandler struct {
+	mock.Mock
+}
+
+// Books provides a mock function with given fields:
+func (_m *ProfileHandler) Books() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+// Profile provides a mock function with given fields:
+func (_m *ProfileHandler) Profile() echo.HandlerFunc {
+	ret := _m.Called()
+
+	var r0 echo.HandlerFunc
+	if rf, ok := ret.Get(0).(func() echo.HandlerFunc); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(echo.HandlerFunc)
+		}
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewProfileHandler interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewProfileHandler creates a new instance of ProfileHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewProfileHandler(t mockConstructorTestingTNewProfileHandler) *ProfileHandler {
+	mock := &ProfileHandler{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,74 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	book "api/features/book"
+	profile "api/features/profile"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProfileService is an autogenerated mock type for the ProfileService type
+type ProfileService struct {
+	mock.Mock
+}
+
+// Books provides a mock function with given fields: userID
+func (_m *ProfileService) Books(userID uint) ([]book.Core, error) {
+	ret := _m.Called(userID)
+
+	var r0 []book.Core
+	if rf, ok := ret.Get(0).(func(uint) []book.Core); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]book.Core)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Profile provides a mock function with given fields: userID
+func (_m *ProfileService) Profile(userID uint) (profile.Core, error) {
+	ret := _m.Called(userID)
+
+	var r0 profile.Core
+	if rf, ok := ret.Get(0).(func(uint) profile.Core); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Get(0).(profile.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewProfileService interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewProfileService creates a new instance of ProfileService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewProfileService(t mockConstructorTestingTNewProfileService) *ProfileService {
+	mock := &ProfileService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,87 @@
+// Code generated by mockery v2.16.0. DO NOT EDIT.
+
+package mocks
+
+import (
+	search "api/search"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Searcher is an autogenerated mock type for the Searcher type
+type Searcher struct {
+	mock.Mock
+}
+
+// Index provides a mock function with given fields: doc
+func (_m *Searcher) Index(doc search.Document) error {
+	ret := _m.Called(doc)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(search.Document) error); ok {
+		r0 = rf(doc)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Remove provides a mock function with given fields: id
+func (_m *Searcher) Remove(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Search provides a mock function with given fields: query, limit, offset
+func (_m *Searcher) Search(query string, limit int, offset int) ([]search.Hit, int, error) {
+	ret := _m.Called(query, limit, offset)
+
+	var r0 []search.Hit
+	if rf, ok := ret.Get(0).(func(string, int, int) []search.Hit); ok {
+		r0 = rf(query, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]search.Hit)
+		}
+	}
+
+	var r1 int
+	if rf, ok := ret.Get(1).(func(string, int, int) int); ok {
+		r1 = rf(query, limit, offset)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, int, int) error); ok {
+		r2 = rf(query, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+type mockConstructorTestingTNewSearcher interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSearcher creates a new instance of Searcher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSearcher(t mockConstructorTestingTNewSearcher) *Searcher {
+	mock := &Searcher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
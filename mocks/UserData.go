@@ -3,9 +3,11 @@
 package mocks
 
 import (
-	user "api/features/user"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
+
+	user "api/features/user"
 )
 
 // UserData is an autogenerated mock type for the UserData type
@@ -13,6 +15,78 @@ type UserData struct {
 	mock.Mock
 }
 
+// AccountLoginFailures provides a mock function with given fields: email, since
+func (_m *UserData) AccountLoginFailures(email string, since time.Time) (user.LoginFailureCore, error) {
+	ret := _m.Called(email, since)
+
+	var r0 user.LoginFailureCore
+	if rf, ok := ret.Get(0).(func(string, time.Time) user.LoginFailureCore); ok {
+		r0 = rf(email, since)
+	} else {
+		r0 = ret.Get(0).(user.LoginFailureCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(email, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CleanupExpiredTokens provides a mock function with given fields:
+func (_m *UserData) CleanupExpiredTokens() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmEmailChange provides a mock function with given fields: id
+func (_m *UserData) ConfirmEmailChange(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeactivatedBefore provides a mock function with given fields: before
+func (_m *UserData) DeactivatedBefore(before time.Time) ([]uint, error) {
+	ret := _m.Called(before)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(time.Time) []uint); ok {
+		r0 = rf(before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Deactive provides a mock function with given fields: id
 func (_m *UserData) Deactive(id uint) (user.Core, error) {
 	ret := _m.Called(id)
@@ -34,6 +108,362 @@ func (_m *UserData) Deactive(id uint) (user.Core, error) {
 	return r0, r1
 }
 
+// DisableMFA provides a mock function with given fields: id
+func (_m *UserData) DisableMFA(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableMFA provides a mock function with given fields: id, step, recoveryHashes
+func (_m *UserData) EnableMFA(id uint, step int64, recoveryHashes []string) error {
+	ret := _m.Called(id, step, recoveryHashes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, int64, []string) error); ok {
+		r0 = rf(id, step, recoveryHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetByID provides a mock function with given fields: id
+func (_m *UserData) GetByID(id uint) (user.Core, error) {
+	ret := _m.Called(id)
+
+	var r0 user.Core
+	if rf, ok := ret.Get(0).(func(uint) user.Core); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(user.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByOIDCSubject provides a mock function with given fields: subject
+func (_m *UserData) GetByOIDCSubject(subject string) (user.Core, error) {
+	ret := _m.Called(subject)
+
+	var r0 user.Core
+	if rf, ok := ret.Get(0).(func(string) user.Core); ok {
+		r0 = rf(subject)
+	} else {
+		r0 = ret.Get(0).(user.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(subject)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeactivated provides a mock function with given fields: email
+func (_m *UserData) GetDeactivated(email string) (user.Core, error) {
+	ret := _m.Called(email)
+
+	var r0 user.Core
+	if rf, ok := ret.Get(0).(func(string) user.Core); ok {
+		r0 = rf(email)
+	} else {
+		r0 = ret.Get(0).(user.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEmailChange provides a mock function with given fields: tokenHash
+func (_m *UserData) GetEmailChange(tokenHash string) (user.EmailChangeCore, error) {
+	ret := _m.Called(tokenHash)
+
+	var r0 user.EmailChangeCore
+	if rf, ok := ret.Get(0).(func(string) user.EmailChangeCore); ok {
+		r0 = rf(tokenHash)
+	} else {
+		r0 = ret.Get(0).(user.EmailChangeCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEmailChangeByRevert provides a mock function with given fields: revertHash
+func (_m *UserData) GetEmailChangeByRevert(revertHash string) (user.EmailChangeCore, error) {
+	ret := _m.Called(revertHash)
+
+	var r0 user.EmailChangeCore
+	if rf, ok := ret.Get(0).(func(string) user.EmailChangeCore); ok {
+		r0 = rf(revertHash)
+	} else {
+		r0 = ret.Get(0).(user.EmailChangeCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(revertHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPasswordReset provides a mock function with given fields: tokenHash
+func (_m *UserData) GetPasswordReset(tokenHash string) (user.PasswordResetCore, error) {
+	ret := _m.Called(tokenHash)
+
+	var r0 user.PasswordResetCore
+	if rf, ok := ret.Get(0).(func(string) user.PasswordResetCore); ok {
+		r0 = rf(tokenHash)
+	} else {
+		r0 = ret.Get(0).(user.PasswordResetCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshToken provides a mock function with given fields: tokenHash
+func (_m *UserData) GetRefreshToken(tokenHash string) (user.RefreshTokenCore, error) {
+	ret := _m.Called(tokenHash)
+
+	var r0 user.RefreshTokenCore
+	if rf, ok := ret.Get(0).(func(string) user.RefreshTokenCore); ok {
+		r0 = rf(tokenHash)
+	} else {
+		r0 = ret.Get(0).(user.RefreshTokenCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSession provides a mock function with given fields: id
+func (_m *UserData) GetSession(id uint) (user.SessionCore, error) {
+	ret := _m.Called(id)
+
+	var r0 user.SessionCore
+	if rf, ok := ret.Get(0).(func(uint) user.SessionCore); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(user.SessionCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessionByFamily provides a mock function with given fields: familyID
+func (_m *UserData) GetSessionByFamily(familyID string) (user.SessionCore, error) {
+	ret := _m.Called(familyID)
+
+	var r0 user.SessionCore
+	if rf, ok := ret.Get(0).(func(string) user.SessionCore); ok {
+		r0 = rf(familyID)
+	} else {
+		r0 = ret.Get(0).(user.SessionCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(familyID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IPLoginFailures provides a mock function with given fields: ip, since
+func (_m *UserData) IPLoginFailures(ip string, since time.Time) (user.LoginFailureCore, error) {
+	ret := _m.Called(ip, since)
+
+	var r0 user.LoginFailureCore
+	if rf, ok := ret.Get(0).(func(string, time.Time) user.LoginFailureCore); ok {
+		r0 = rf(ip, since)
+	} else {
+		r0 = ret.Get(0).(user.LoginFailureCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(ip, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTokenRevoked provides a mock function with given fields: jti
+func (_m *UserData) IsTokenRevoked(jti string) (bool, error) {
+	ret := _m.Called(jti)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(jti)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(jti)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkOIDC provides a mock function with given fields: id, subject
+func (_m *UserData) LinkOIDC(id uint, subject string) error {
+	ret := _m.Called(id, subject)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string) error); ok {
+		r0 = rf(id, subject)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListSecurityEvents provides a mock function with given fields: filter
+func (_m *UserData) ListSecurityEvents(filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error) {
+	ret := _m.Called(filter)
+
+	var r0 []user.SecurityEventCore
+	if rf, ok := ret.Get(0).(func(user.SecurityEventFilter) []user.SecurityEventCore); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.SecurityEventCore)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(user.SecurityEventFilter) int64); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(user.SecurityEventFilter) error); ok {
+		r2 = rf(filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListSessions provides a mock function with given fields: userID, since
+func (_m *UserData) ListSessions(userID uint, since time.Time) ([]user.SessionCore, error) {
+	ret := _m.Called(userID, since)
+
+	var r0 []user.SessionCore
+	if rf, ok := ret.Get(0).(func(uint, time.Time) []user.SessionCore); ok {
+		r0 = rf(userID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.SessionCore)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint, time.Time) error); ok {
+		r1 = rf(userID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: filter
+func (_m *UserData) ListUsers(filter user.UserFilter) ([]user.Core, int64, error) {
+	ret := _m.Called(filter)
+
+	var r0 []user.Core
+	if rf, ok := ret.Get(0).(func(user.UserFilter) []user.Core); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.Core)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(user.UserFilter) int64); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(user.UserFilter) error); ok {
+		r2 = rf(filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Login provides a mock function with given fields: email
 func (_m *UserData) Login(email string) (user.Core, error) {
 	ret := _m.Called(email)
@@ -55,6 +485,29 @@ func (_m *UserData) Login(email string) (user.Core, error) {
 	return r0, r1
 }
 
+// OwnedBooks provides a mock function with given fields: userID
+func (_m *UserData) OwnedBooks(userID uint) ([]user.OwnedBookCore, error) {
+	ret := _m.Called(userID)
+
+	var r0 []user.OwnedBookCore
+	if rf, ok := ret.Get(0).(func(uint) []user.OwnedBookCore); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.OwnedBookCore)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Profile provides a mock function with given fields: id
 func (_m *UserData) Profile(id uint) (user.Core, error) {
 	ret := _m.Called(id)
@@ -76,6 +529,57 @@ func (_m *UserData) Profile(id uint) (user.Core, error) {
 	return r0, r1
 }
 
+// Purge provides a mock function with given fields: id, bookOwner
+func (_m *UserData) Purge(id uint, bookOwner uint) error {
+	ret := _m.Called(id, bookOwner)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, uint) error); ok {
+		r0 = rf(id, bookOwner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecentPasswords provides a mock function with given fields: id, n
+func (_m *UserData) RecentPasswords(id uint, n int) ([]string, error) {
+	ret := _m.Called(id, n)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(uint, int) []string); ok {
+		r0 = rf(id, n)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint, int) error); ok {
+		r1 = rf(id, n)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordLoginAttempt provides a mock function with given fields: attempt
+func (_m *UserData) RecordLoginAttempt(attempt user.LoginAttemptCore) error {
+	ret := _m.Called(attempt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.LoginAttemptCore) error); ok {
+		r0 = rf(attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Register provides a mock function with given fields: newUser
 func (_m *UserData) Register(newUser user.Core) (user.Core, error) {
 	ret := _m.Called(newUser)
@@ -97,20 +601,279 @@ func (_m *UserData) Register(newUser user.Core) (user.Core, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, updateData
-func (_m *UserData) Update(id uint, updateData user.Core) (user.Core, error) {
-	ret := _m.Called(id, updateData)
+// Restore provides a mock function with given fields: id
+func (_m *UserData) Restore(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevertEmailChange provides a mock function with given fields: id
+func (_m *UserData) RevertEmailChange(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: userID, id
+func (_m *UserData) RevokeSession(userID uint, id uint) error {
+	ret := _m.Called(userID, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, uint) error); ok {
+		r0 = rf(userID, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: jti, userID, expiresAt
+func (_m *UserData) RevokeToken(jti string, userID uint, expiresAt time.Time) error {
+	ret := _m.Called(jti, userID, expiresAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, uint, time.Time) error); ok {
+		r0 = rf(jti, userID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeTokenFamily provides a mock function with given fields: familyID
+func (_m *UserData) RevokeTokenFamily(familyID string) error {
+	ret := _m.Called(familyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserRefreshTokens provides a mock function with given fields: userID
+func (_m *UserData) RevokeUserRefreshTokens(userID uint) error {
+	ret := _m.Called(userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveEmailChange provides a mock function with given fields: change
+func (_m *UserData) SaveEmailChange(change user.EmailChangeCore) error {
+	ret := _m.Called(change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.EmailChangeCore) error); ok {
+		r0 = rf(change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SavePasswordReset provides a mock function with given fields: newReset
+func (_m *UserData) SavePasswordReset(newReset user.PasswordResetCore) error {
+	ret := _m.Called(newReset)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.PasswordResetCore) error); ok {
+		r0 = rf(newReset)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveRefreshToken provides a mock function with given fields: newToken
+func (_m *UserData) SaveRefreshToken(newToken user.RefreshTokenCore) error {
+	ret := _m.Called(newToken)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.RefreshTokenCore) error); ok {
+		r0 = rf(newToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveSecurityEvent provides a mock function with given fields: event
+func (_m *UserData) SaveSecurityEvent(event user.SecurityEventCore) error {
+	ret := _m.Called(event)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(user.SecurityEventCore) error); ok {
+		r0 = rf(event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveSession provides a mock function with given fields: newSession
+func (_m *UserData) SaveSession(newSession user.SessionCore) (user.SessionCore, error) {
+	ret := _m.Called(newSession)
+
+	var r0 user.SessionCore
+	if rf, ok := ret.Get(0).(func(user.SessionCore) user.SessionCore); ok {
+		r0 = rf(newSession)
+	} else {
+		r0 = ret.Get(0).(user.SessionCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(user.SessionCore) error); ok {
+		r1 = rf(newSession)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetEmailVerified provides a mock function with given fields: id, email
+func (_m *UserData) SetEmailVerified(id uint, email string) error {
+	ret := _m.Called(id, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string) error); ok {
+		r0 = rf(id, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMFALastStep provides a mock function with given fields: id, step
+func (_m *UserData) SetMFALastStep(id uint, step int64) error {
+	ret := _m.Called(id, step)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, int64) error); ok {
+		r0 = rf(id, step)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMFASecret provides a mock function with given fields: id, secret
+func (_m *UserData) SetMFASecret(id uint, secret string) error {
+	ret := _m.Called(id, secret)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string) error); ok {
+		r0 = rf(id, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPasswordResetRequired provides a mock function with given fields: id, required
+func (_m *UserData) SetPasswordResetRequired(id uint, required bool) error {
+	ret := _m.Called(id, required)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, bool) error); ok {
+		r0 = rf(id, required)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSuspended provides a mock function with given fields: id, suspended
+func (_m *UserData) SetSuspended(id uint, suspended bool) error {
+	ret := _m.Called(id, suspended)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, bool) error); ok {
+		r0 = rf(id, suspended)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetVerificationSent provides a mock function with given fields: id, sentAt
+func (_m *UserData) SetVerificationSent(id uint, sentAt time.Time) error {
+	ret := _m.Called(id, sentAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, time.Time) error); ok {
+		r0 = rf(id, sentAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TouchSession provides a mock function with given fields: id, client, at
+func (_m *UserData) TouchSession(id uint, client user.ClientInfo, at time.Time) error {
+	ret := _m.Called(id, client, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, user.ClientInfo, time.Time) error); ok {
+		r0 = rf(id, client, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: id, updateData, fields
+func (_m *UserData) Update(id uint, updateData user.Core, fields []string) (user.Core, error) {
+	ret := _m.Called(id, updateData, fields)
 
 	var r0 user.Core
-	if rf, ok := ret.Get(0).(func(uint, user.Core) user.Core); ok {
-		r0 = rf(id, updateData)
+	if rf, ok := ret.Get(0).(func(uint, user.Core, []string) user.Core); ok {
+		r0 = rf(id, updateData, fields)
 	} else {
 		r0 = ret.Get(0).(user.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(uint, user.Core) error); ok {
-		r1 = rf(id, updateData)
+	if rf, ok := ret.Get(1).(func(uint, user.Core, []string) error); ok {
+		r1 = rf(id, updateData, fields)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,6 +881,62 @@ func (_m *UserData) Update(id uint, updateData user.Core) (user.Core, error) {
 	return r0, r1
 }
 
+// UpdatePassword provides a mock function with given fields: id, hashed
+func (_m *UserData) UpdatePassword(id uint, hashed string) error {
+	ret := _m.Called(id, hashed)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string) error); ok {
+		r0 = rf(id, hashed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UsePasswordReset provides a mock function with given fields: id, userID, hashed
+func (_m *UserData) UsePasswordReset(id uint, userID uint, hashed string) error {
+	ret := _m.Called(id, userID, hashed)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, uint, string) error); ok {
+		r0 = rf(id, userID, hashed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: id, codeHash
+func (_m *UserData) UseRecoveryCode(id uint, codeHash string) error {
+	ret := _m.Called(id, codeHash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string) error); ok {
+		r0 = rf(id, codeHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRefreshToken provides a mock function with given fields: id
+func (_m *UserData) UseRefreshToken(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewUserData interface {
 	mock.TestingT
 	Cleanup(func())
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	auth "api/auth"
 	user "api/features/user"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,20 +14,101 @@ type UserService struct {
 	mock.Mock
 }
 
-// Deactive provides a mock function with given fields: token
-func (_m *UserService) Deactive(token interface{}) (user.Core, error) {
-	ret := _m.Called(token)
+// Avatar provides a mock function with given fields: userID, size
+func (_m *UserService) Avatar(userID uint, size int) ([]byte, string, error) {
+	ret := _m.Called(userID, size)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(uint, int) []byte); ok {
+		r0 = rf(userID, size)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(uint, int) string); ok {
+		r1 = rf(userID, size)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(uint, int) error); ok {
+		r2 = rf(userID, size)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CheckToken provides a mock function with given fields: p
+func (_m *UserService) CheckToken(p auth.Principal) error {
+	ret := _m.Called(p)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal) error); ok {
+		r0 = rf(p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmEmailChange provides a mock function with given fields: confirmToken, client
+func (_m *UserService) ConfirmEmailChange(confirmToken string, client user.ClientInfo) error {
+	ret := _m.Called(confirmToken, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, user.ClientInfo) error); ok {
+		r0 = rf(confirmToken, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmMFA provides a mock function with given fields: p, code
+func (_m *UserService) ConfirmMFA(p auth.Principal, code string) ([]string, error) {
+	ret := _m.Called(p, code)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(auth.Principal, string) []string); ok {
+		r0 = rf(p, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal, string) error); ok {
+		r1 = rf(p, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Deactive provides a mock function with given fields: p
+func (_m *UserService) Deactive(p auth.Principal) (user.Core, error) {
+	ret := _m.Called(p)
 
 	var r0 user.Core
-	if rf, ok := ret.Get(0).(func(interface{}) user.Core); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(auth.Principal) user.Core); ok {
+		r0 = rf(p)
 	} else {
 		r0 = ret.Get(0).(user.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(interface{}) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(auth.Principal) error); ok {
+		r1 = rf(p)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -34,27 +116,181 @@ func (_m *UserService) Deactive(token interface{}) (user.Core, error) {
 	return r0, r1
 }
 
-// Login provides a mock function with given fields: email, password
-func (_m *UserService) Login(email string, password string) (string, user.Core, error) {
-	ret := _m.Called(email, password)
+// DeleteAvatar provides a mock function with given fields: p
+func (_m *UserService) DeleteAvatar(p auth.Principal) error {
+	ret := _m.Called(p)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(string, string) string); ok {
-		r0 = rf(email, password)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal) error); ok {
+		r0 = rf(p)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DisableMFA provides a mock function with given fields: p, code
+func (_m *UserService) DisableMFA(p auth.Principal, code string) error {
+	ret := _m.Called(p, code)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, string) error); ok {
+		r0 = rf(p, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollMFA provides a mock function with given fields: p
+func (_m *UserService) EnrollMFA(p auth.Principal) (user.MFAEnrollCore, error) {
+	ret := _m.Called(p)
+
+	var r0 user.MFAEnrollCore
+	if rf, ok := ret.Get(0).(func(auth.Principal) user.MFAEnrollCore); ok {
+		r0 = rf(p)
+	} else {
+		r0 = ret.Get(0).(user.MFAEnrollCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Export provides a mock function with given fields: p
+func (_m *UserService) Export(p auth.Principal) (user.ExportCore, error) {
+	ret := _m.Called(p)
+
+	var r0 user.ExportCore
+	if rf, ok := ret.Get(0).(func(auth.Principal) user.ExportCore); ok {
+		r0 = rf(p)
+	} else {
+		r0 = ret.Get(0).(user.ExportCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForgotPassword provides a mock function with given fields: email
+func (_m *UserService) ForgotPassword(email string) error {
+	ret := _m.Called(email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Login provides a mock function with given fields: email, password, client
+func (_m *UserService) Login(email string, password string, client user.ClientInfo) (user.TokenCore, user.Core, error) {
+	ret := _m.Called(email, password, client)
+
+	var r0 user.TokenCore
+	if rf, ok := ret.Get(0).(func(string, string, user.ClientInfo) user.TokenCore); ok {
+		r0 = rf(email, password, client)
+	} else {
+		r0 = ret.Get(0).(user.TokenCore)
+	}
+
+	var r1 user.Core
+	if rf, ok := ret.Get(1).(func(string, string, user.ClientInfo) user.Core); ok {
+		r1 = rf(email, password, client)
+	} else {
+		r1 = ret.Get(1).(user.Core)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, user.ClientInfo) error); ok {
+		r2 = rf(email, password, client)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// LoginMFA provides a mock function with given fields: mfaToken, code, client
+func (_m *UserService) LoginMFA(mfaToken string, code string, client user.ClientInfo) (user.TokenCore, user.Core, error) {
+	ret := _m.Called(mfaToken, code, client)
+
+	var r0 user.TokenCore
+	if rf, ok := ret.Get(0).(func(string, string, user.ClientInfo) user.TokenCore); ok {
+		r0 = rf(mfaToken, code, client)
+	} else {
+		r0 = ret.Get(0).(user.TokenCore)
+	}
+
+	var r1 user.Core
+	if rf, ok := ret.Get(1).(func(string, string, user.ClientInfo) user.Core); ok {
+		r1 = rf(mfaToken, code, client)
+	} else {
+		r1 = ret.Get(1).(user.Core)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(string, string, user.ClientInfo) error); ok {
+		r2 = rf(mfaToken, code, client)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Logout provides a mock function with given fields: p, refreshToken
+func (_m *UserService) Logout(p auth.Principal, refreshToken string) error {
+	ret := _m.Called(p, refreshToken)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, string) error); ok {
+		r0 = rf(p, refreshToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OIDCCallback provides a mock function with given fields: stateToken, state, code, client
+func (_m *UserService) OIDCCallback(stateToken string, state string, code string, client user.ClientInfo) (user.TokenCore, user.Core, error) {
+	ret := _m.Called(stateToken, state, code, client)
+
+	var r0 user.TokenCore
+	if rf, ok := ret.Get(0).(func(string, string, string, user.ClientInfo) user.TokenCore); ok {
+		r0 = rf(stateToken, state, code, client)
+	} else {
+		r0 = ret.Get(0).(user.TokenCore)
 	}
 
 	var r1 user.Core
-	if rf, ok := ret.Get(1).(func(string, string) user.Core); ok {
-		r1 = rf(email, password)
+	if rf, ok := ret.Get(1).(func(string, string, string, user.ClientInfo) user.Core); ok {
+		r1 = rf(stateToken, state, code, client)
 	} else {
 		r1 = ret.Get(1).(user.Core)
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(string, string) error); ok {
-		r2 = rf(email, password)
+	if rf, ok := ret.Get(2).(func(string, string, string, user.ClientInfo) error); ok {
+		r2 = rf(stateToken, state, code, client)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -62,20 +298,111 @@ func (_m *UserService) Login(email string, password string) (string, user.Core,
 	return r0, r1, r2
 }
 
-// Profile provides a mock function with given fields: token
-func (_m *UserService) Profile(token interface{}) (user.Core, error) {
-	ret := _m.Called(token)
+// OIDCStart provides a mock function with given fields:
+func (_m *UserService) OIDCStart() (string, string, error) {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func() string); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func() error); ok {
+		r2 = rf()
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Patch provides a mock function with given fields: p, patch
+func (_m *UserService) Patch(p auth.Principal, patch []byte) (user.Core, error) {
+	ret := _m.Called(p, patch)
+
+	var r0 user.Core
+	if rf, ok := ret.Get(0).(func(auth.Principal, []byte) user.Core); ok {
+		r0 = rf(p, patch)
+	} else {
+		r0 = ret.Get(0).(user.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal, []byte) error); ok {
+		r1 = rf(p, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Profile provides a mock function with given fields: p
+func (_m *UserService) Profile(p auth.Principal) (user.Core, error) {
+	ret := _m.Called(p)
 
 	var r0 user.Core
-	if rf, ok := ret.Get(0).(func(interface{}) user.Core); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(auth.Principal) user.Core); ok {
+		r0 = rf(p)
 	} else {
 		r0 = ret.Get(0).(user.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(interface{}) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(auth.Principal) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeDeactivated provides a mock function with given fields:
+func (_m *UserService) PurgeDeactivated() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Refresh provides a mock function with given fields: refreshToken, client
+func (_m *UserService) Refresh(refreshToken string, client user.ClientInfo) (user.TokenCore, error) {
+	ret := _m.Called(refreshToken, client)
+
+	var r0 user.TokenCore
+	if rf, ok := ret.Get(0).(func(string, user.ClientInfo) user.TokenCore); ok {
+		r0 = rf(refreshToken, client)
+	} else {
+		r0 = ret.Get(0).(user.TokenCore)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, user.ClientInfo) error); ok {
+		r1 = rf(refreshToken, client)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -104,20 +431,143 @@ func (_m *UserService) Register(newUser user.Core) (user.Core, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: token, updateData
-func (_m *UserService) Update(token interface{}, updateData user.Core) (user.Core, error) {
-	ret := _m.Called(token, updateData)
+// RequestEmailChange provides a mock function with given fields: p, newEmail, password
+func (_m *UserService) RequestEmailChange(p auth.Principal, newEmail string, password string) error {
+	ret := _m.Called(p, newEmail, password)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, string, string) error); ok {
+		r0 = rf(p, newEmail, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendVerification provides a mock function with given fields: email
+func (_m *UserService) ResendVerification(email string) error {
+	ret := _m.Called(email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: resetToken, newPassword, client
+func (_m *UserService) ResetPassword(resetToken string, newPassword string, client user.ClientInfo) error {
+	ret := _m.Called(resetToken, newPassword, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, user.ClientInfo) error); ok {
+		r0 = rf(resetToken, newPassword, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevertEmailChange provides a mock function with given fields: revertToken, client
+func (_m *UserService) RevertEmailChange(revertToken string, client user.ClientInfo) error {
+	ret := _m.Called(revertToken, client)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, user.ClientInfo) error); ok {
+		r0 = rf(revertToken, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSession provides a mock function with given fields: p, sessionID
+func (_m *UserService) RevokeSession(p auth.Principal, sessionID uint) error {
+	ret := _m.Called(p, sessionID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(auth.Principal, uint) error); ok {
+		r0 = rf(p, sessionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SecurityEvents provides a mock function with given fields: p, filter
+func (_m *UserService) SecurityEvents(p auth.Principal, filter user.SecurityEventFilter) ([]user.SecurityEventCore, int64, error) {
+	ret := _m.Called(p, filter)
+
+	var r0 []user.SecurityEventCore
+	if rf, ok := ret.Get(0).(func(auth.Principal, user.SecurityEventFilter) []user.SecurityEventCore); ok {
+		r0 = rf(p, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.SecurityEventCore)
+		}
+	}
+
+	var r1 int64
+	if rf, ok := ret.Get(1).(func(auth.Principal, user.SecurityEventFilter) int64); ok {
+		r1 = rf(p, filter)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(auth.Principal, user.SecurityEventFilter) error); ok {
+		r2 = rf(p, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Sessions provides a mock function with given fields: p
+func (_m *UserService) Sessions(p auth.Principal) ([]user.SessionCore, error) {
+	ret := _m.Called(p)
+
+	var r0 []user.SessionCore
+	if rf, ok := ret.Get(0).(func(auth.Principal) []user.SessionCore); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]user.SessionCore)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: p, updateData
+func (_m *UserService) Update(p auth.Principal, updateData user.Core) (user.Core, error) {
+	ret := _m.Called(p, updateData)
 
 	var r0 user.Core
-	if rf, ok := ret.Get(0).(func(interface{}, user.Core) user.Core); ok {
-		r0 = rf(token, updateData)
+	if rf, ok := ret.Get(0).(func(auth.Principal, user.Core) user.Core); ok {
+		r0 = rf(p, updateData)
 	} else {
 		r0 = ret.Get(0).(user.Core)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(interface{}, user.Core) error); ok {
-		r1 = rf(token, updateData)
+	if rf, ok := ret.Get(1).(func(auth.Principal, user.Core) error); ok {
+		r1 = rf(p, updateData)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -125,6 +575,41 @@ func (_m *UserService) Update(token interface{}, updateData user.Core) (user.Cor
 	return r0, r1
 }
 
+// UploadAvatar provides a mock function with given fields: p, data
+func (_m *UserService) UploadAvatar(p auth.Principal, data []byte) (user.Core, error) {
+	ret := _m.Called(p, data)
+
+	var r0 user.Core
+	if rf, ok := ret.Get(0).(func(auth.Principal, []byte) user.Core); ok {
+		r0 = rf(p, data)
+	} else {
+		r0 = ret.Get(0).(user.Core)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(auth.Principal, []byte) error); ok {
+		r1 = rf(p, data)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyEmail provides a mock function with given fields: verifyToken
+func (_m *UserService) VerifyEmail(verifyToken string) error {
+	ret := _m.Called(verifyToken)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(verifyToken)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewUserService interface {
 	mock.TestingT
 	Cleanup(func())
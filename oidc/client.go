@@ -0,0 +1,272 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// jwksRefreshInterval batas minimal antar pengambilan ulang JWKS saat kid tidak dikenal.
+const jwksRefreshInterval = time.Minute
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client IdentityProvider yang membaca konfigurasi dari discovery document issuer.
+// Discovery dan JWKS diambil saat pertama dipakai lalu disimpan di memori.
+type Client struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	http         *http.Client
+
+	mu          sync.Mutex
+	disc        *discovery
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+func NewClient(issuer, clientID, clientSecret, redirectURL string) *Client {
+	return &Client{
+		issuer:       strings.TrimRight(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		http:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	disc, err := c.discover()
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.clientID)
+	q.Set("redirect_uri", c.redirectURL)
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return disc.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange menukar authorization code ke token endpoint lalu memvalidasi ID token-nya.
+func (c *Client) Exchange(code, codeVerifier, nonce string) (Claims, error) {
+	disc, err := c.discover()
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", c.clientID)
+
+	req, err := http.NewRequest(http.MethodPost, disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Claims{}, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Claims{}, fmt.Errorf("token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Claims{}, fmt.Errorf("token endpoint %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return Claims{}, errors.New("token response tanpa id_token")
+	}
+
+	return c.verify(body.IDToken, nonce)
+}
+
+func (c *Client) verify(idToken, nonce string) (Claims, error) {
+	token, err := jwt.Parse(idToken, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return c.key(kid)
+	})
+	if err != nil || !token.Valid {
+		return Claims{}, fmt.Errorf("id token tidak valid: %v", err)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if iss, _ := claims["iss"].(string); iss != c.issuer {
+		return Claims{}, errors.New("id token issuer tidak sesuai")
+	}
+	if !hasAudience(claims["aud"], c.clientID) {
+		return Claims{}, errors.New("id token audience tidak sesuai")
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != c.clientID {
+		return Claims{}, errors.New("id token azp tidak sesuai")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return Claims{}, errors.New("id token tanpa exp")
+	}
+	if got, _ := claims["nonce"].(string); nonce == "" || got != nonce {
+		return Claims{}, errors.New("id token nonce tidak sesuai")
+	}
+
+	res := Claims{}
+	res.Subject, _ = claims["sub"].(string)
+	res.Email, _ = claims["email"].(string)
+	res.Name, _ = claims["name"].(string)
+	// beberapa provider mengirim email_verified sebagai string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		res.EmailVerified = v
+	case string:
+		res.EmailVerified = v == "true"
+	}
+	if res.Subject == "" {
+		return Claims{}, errors.New("id token tanpa sub")
+	}
+
+	return res, nil
+}
+
+func hasAudience(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if s, _ := a.(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Client) discover() (*discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disc != nil {
+		return c.disc, nil
+	}
+
+	disc := discovery{}
+	if err := c.getJSON(c.issuer+"/.well-known/openid-configuration", &disc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(disc.Issuer, "/") != c.issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q tidak sesuai", disc.Issuer)
+	}
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: endpoint tidak lengkap")
+	}
+
+	c.disc = &disc
+	return c.disc, nil
+}
+
+// key mengembalikan public key untuk kid, JWKS diambil ulang sekali kalau kid belum dikenal
+// (provider sedang rotasi key).
+func (c *Client) key(kid string) (*rsa.PublicKey, error) {
+	disc, err := c.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if k, ok := c.keys[kid]; ok {
+		return k, nil
+	}
+	if time.Since(c.keysFetched) < jwksRefreshInterval {
+		return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(disc.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	c.keys = keys
+	c.keysFetched = time.Now()
+
+	if k, ok := c.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+}
+
+func (c *Client) getJSON(u string, out interface{}) error {
+	resp, err := c.http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,104 @@
+package oidc_test
+
+import (
+	"api/oidc"
+	"api/oidc/oidctest"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURL = "http://localhost:8000/login/oidc/callback"
+
+// authorize menjalankan langkah browser: buka auth URL lalu ambil code dari redirect.
+func authorize(t *testing.T, client *oidc.Client, state, nonce, verifier string) string {
+	authURL, err := client.AuthCodeURL(state, nonce, oidc.S256Challenge(verifier))
+	assert.Nil(t, err)
+
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := browser.Get(authURL)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, state, loc.Query().Get("state"))
+	return loc.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	idp := oidctest.NewServer("buku-api", "rahasia")
+	defer idp.Close()
+	idp.SetUser(oidc.Claims{Subject: "u-123", Email: "alif@example.com", EmailVerified: true, Name: "Alif"})
+
+	client := oidc.NewClient(idp.URL, "buku-api", "rahasia", redirectURL)
+	verifier := "verifier-yang-cukup-panjang-untuk-pkce-1234567890"
+
+	t.Run("Berhasil login", func(t *testing.T) {
+		code := authorize(t, client, "state-1", "nonce-1", verifier)
+
+		claims, err := client.Exchange(code, verifier, "nonce-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "u-123", claims.Subject)
+		assert.Equal(t, "alif@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+		assert.Equal(t, "Alif", claims.Name)
+	})
+
+	t.Run("code tidak bisa dipakai ulang", func(t *testing.T) {
+		code := authorize(t, client, "state-2", "nonce-2", verifier)
+
+		_, err := client.Exchange(code, verifier, "nonce-2")
+		assert.Nil(t, err)
+		_, err = client.Exchange(code, verifier, "nonce-2")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("code verifier salah", func(t *testing.T) {
+		code := authorize(t, client, "state-3", "nonce-3", verifier)
+
+		_, err := client.Exchange(code, "verifier-lain-yang-cukup-panjang-untuk-pkce-123456", "nonce-3")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("nonce tidak sesuai", func(t *testing.T) {
+		code := authorize(t, client, "state-4", "nonce-4", verifier)
+
+		_, err := client.Exchange(code, verifier, "nonce-lain")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("client secret salah", func(t *testing.T) {
+		other := oidc.NewClient(idp.URL, "buku-api", "salah", redirectURL)
+		code := authorize(t, other, "state-5", "nonce-5", verifier)
+
+		_, err := other.Exchange(code, verifier, "nonce-5")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "invalid_client")
+	})
+
+	t.Run("audience untuk client lain", func(t *testing.T) {
+		idp.Audience = "aplikasi-lain"
+		defer func() { idp.Audience = "" }()
+		code := authorize(t, client, "state-6", "nonce-6", verifier)
+
+		_, err := client.Exchange(code, verifier, "nonce-6")
+		assert.NotNil(t, err)
+		assert.ErrorContains(t, err, "audience")
+	})
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("buku-api", "")
+	defer idp.Close()
+
+	client := oidc.NewClient(idp.URL+"/tenant", "buku-api", "", redirectURL)
+	_, err := client.AuthCodeURL("state", "nonce", "challenge")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,27 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Claims identitas user hasil validasi ID token dari identity provider.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IdentityProvider login OpenID Connect dengan authorization code + PKCE.
+// Implementasi: Client, untuk testing lihat paket oidctest.
+type IdentityProvider interface {
+	AuthCodeURL(state, nonce, codeChallenge string) (string, error)
+	Exchange(code, codeVerifier, nonce string) (Claims, error)
+}
+
+// S256Challenge menghitung code_challenge PKCE (metode S256) dari code_verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
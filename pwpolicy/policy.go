@@ -0,0 +1,153 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt hanya memakai 72 byte pertama, sisanya diabaikan diam-diam
+const maxLength = 72
+
+//go:embed common.txt
+var commonPasswords string
+
+// Policy aturan password baru. Daftar password bocor berisi password biasa (satu per baris)
+// atau hash SHA-1 hex seperti dump Have I Been Pwned (HASH atau HASH:jumlah).
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// History jumlah password terakhir (termasuk yang sekarang) yang tidak boleh dipakai ulang
+	History int
+
+	plain map[string]struct{}
+	sha1  map[string]struct{}
+}
+
+// Default minimal 8 karakter dengan huruf besar, huruf kecil dan angka, riwayat 5 password,
+// dan daftar password umum bawaan.
+func Default() *Policy {
+	p := &Policy{
+		MinLength:    8,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+		History:      5,
+	}
+	p.load(strings.NewReader(commonPasswords))
+	return p
+}
+
+// LoadBreachedList menambahkan isi file ke daftar password yang ditolak.
+func (p *Policy) LoadBreachedList(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.load(f)
+}
+
+func (p *Policy) load(r io.Reader) error {
+	if p.plain == nil {
+		p.plain = map[string]struct{}{}
+		p.sha1 = map[string]struct{}{}
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1(hash) {
+			p.sha1[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		p.plain[strings.ToLower(line)] = struct{}{}
+	}
+
+	return sc.Err()
+}
+
+func isSHA1(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Validate mengembalikan daftar pelanggaran, kosong jika password memenuhi aturan.
+func (p *Policy) Validate(password string) []string {
+	violations := []string{}
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password minimal %d karakter", p.MinLength))
+	}
+	if len(password) > maxLength {
+		violations = append(violations, fmt.Sprintf("password maksimal %d karakter", maxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "password harus mengandung huruf besar")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "password harus mengandung huruf kecil")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "password harus mengandung angka")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "password harus mengandung simbol")
+	}
+
+	if p.Breached(password) {
+		violations = append(violations, "password terlalu umum atau pernah bocor, gunakan password lain")
+	}
+
+	return violations
+}
+
+func (p *Policy) Breached(password string) bool {
+	if _, ok := p.plain[strings.ToLower(password)]; ok {
+		return true
+	}
+	sum := sha1.Sum([]byte(password))
+	_, ok := p.sha1[strings.ToUpper(hex.EncodeToString(sum[:]))]
+	return ok
+}
+
+// Reused membandingkan password dengan hash bcrypt dari riwayat password user.
+func (p *Policy) Reused(password string, hashes []string) bool {
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
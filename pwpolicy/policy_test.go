@@ -0,0 +1,66 @@
+package pwpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidate(t *testing.T) {
+	p := Default()
+
+	t.Run("password memenuhi aturan", func(t *testing.T) {
+		assert.Empty(t, p.Validate("Buku-Langka-77"))
+	})
+
+	t.Run("password kosong", func(t *testing.T) {
+		violations := p.Validate("")
+		assert.Contains(t, violations, "password minimal 8 karakter")
+		assert.Contains(t, violations, "password harus mengandung angka")
+	})
+
+	t.Run("tanpa huruf besar", func(t *testing.T) {
+		assert.Equal(t, []string{"password harus mengandung huruf besar"}, p.Validate("bukulangka77"))
+	})
+
+	t.Run("simbol wajib", func(t *testing.T) {
+		strict := Default()
+		strict.RequireSymbol = true
+		assert.Equal(t, []string{"password harus mengandung simbol"}, strict.Validate("BukuLangka77"))
+	})
+
+	t.Run("lebih dari 72 byte", func(t *testing.T) {
+		long := "Aa1" + string(make([]byte, 70))
+		assert.Contains(t, p.Validate(long), "password maksimal 72 karakter")
+	})
+
+	t.Run("password umum", func(t *testing.T) {
+		assert.Contains(t, p.Validate("Password123"), "password terlalu umum atau pernah bocor, gunakan password lain")
+	})
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	// SHA-1 dari "Buku-Langka-77" dalam format HIBP
+	content := "# daftar tambahan\nPerpustakaan2023\n65203934F28B5E52911FC6EA29922FF61B5D82F6:12\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	p := Default()
+	assert.NoError(t, p.LoadBreachedList(path))
+	assert.True(t, p.Breached("perpustakaan2023"))
+	assert.True(t, p.Breached("Buku-Langka-77"))
+	assert.False(t, p.Breached("Buku-Langka-78"))
+
+	assert.Error(t, p.LoadBreachedList(filepath.Join(t.TempDir(), "tidak-ada.txt")))
+}
+
+func TestReused(t *testing.T) {
+	p := Default()
+	old, _ := bcrypt.GenerateFromPassword([]byte("Buku-Langka-77"), bcrypt.MinCost)
+
+	assert.True(t, p.Reused("Buku-Langka-77", []string{string(old)}))
+	assert.False(t, p.Reused("Buku-Langka-78", []string{string(old)}))
+}
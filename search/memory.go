@@ -0,0 +1,101 @@
+package search
+
+import "sync"
+
+// Memory inverted index di memori. Index harus diisi ulang setiap aplikasi start
+// dan dijaga tetap sinkron lewat Index/Remove setiap dokumen berubah.
+type Memory struct {
+	mu       sync.RWMutex
+	fields   []Field
+	docs     map[uint]Document
+	postings map[string]map[uint]struct{}
+}
+
+func NewMemory(fields ...Field) *Memory {
+	return &Memory{
+		fields:   fields,
+		docs:     map[uint]Document{},
+		postings: map[string]map[uint]struct{}{},
+	}
+}
+
+// Index menambah dokumen baru atau mengganti dokumen dengan ID yang sama.
+func (m *Memory) Index(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(doc.ID)
+	stored := Document{ID: doc.ID, Fields: map[string]string{}}
+	for _, f := range m.fields {
+		text := doc.Fields[f.Name]
+		stored.Fields[f.Name] = text
+		for _, tok := range tokenize(text) {
+			ids, ok := m.postings[tok.term]
+			if !ok {
+				ids = map[uint]struct{}{}
+				m.postings[tok.term] = ids
+			}
+			ids[doc.ID] = struct{}{}
+		}
+	}
+	m.docs[doc.ID] = stored
+
+	return nil
+}
+
+func (m *Memory) Remove(id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+	return nil
+}
+
+func (m *Memory) remove(id uint) {
+	doc, ok := m.docs[id]
+	if !ok {
+		return
+	}
+	for _, text := range doc.Fields {
+		for _, tok := range tokenize(text) {
+			if ids, ok := m.postings[tok.term]; ok {
+				delete(ids, id)
+				if len(ids) == 0 {
+					delete(m.postings, tok.term)
+				}
+			}
+		}
+	}
+	delete(m.docs, id)
+}
+
+func (m *Memory) Search(query string, limit, offset int) ([]Hit, int, error) {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []Hit{}, 0, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// kandidat dari semua kata di index yang cocok persis, sebagai awalan atau salah ketik
+	candidates := map[uint]struct{}{}
+	for term, ids := range m.postings {
+		for _, q := range terms {
+			if matchTerm(q, term) > 0 {
+				for id := range ids {
+					candidates[id] = struct{}{}
+				}
+				break
+			}
+		}
+	}
+
+	docs := make([]Document, 0, len(candidates))
+	for id := range candidates {
+		docs = append(docs, m.docs[id])
+	}
+	hits, total := rankAll(m.fields, docs, terms, limit, offset)
+
+	return hits, total, nil
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mysqlCandidates batas kandidat dari FULLTEXT yang dinilai ulang oleh rank.
+const mysqlCandidates = 500
+
+// MySQL mencari di tabel gorm (kolom id dan deleted_at) memakai FULLTEXT index atas semua
+// fields. Index dan Remove tidak melakukan apa-apa karena index dijaga MySQL sendiri.
+type MySQL struct {
+	db     *gorm.DB
+	table  string
+	fields []Field
+}
+
+func NewMySQL(db *gorm.DB, table string, fields ...Field) *MySQL {
+	return &MySQL{
+		db:     db,
+		table:  table,
+		fields: fields,
+	}
+}
+
+func (ms *MySQL) Index(doc Document) error {
+	return nil
+}
+
+func (ms *MySQL) Remove(id uint) error {
+	return nil
+}
+
+// Search mengambil kandidat lewat MATCH ... AGAINST lalu menilai ulang dengan rank,
+// sehingga ranking dan highlight sama dengan Memory.
+func (ms *MySQL) Search(query string, limit, offset int) ([]Hit, int, error) {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []Hit{}, 0, nil
+	}
+
+	names := make([]string, 0, len(ms.fields))
+	for _, f := range ms.fields {
+		names = append(names, f.Name)
+	}
+	cols := strings.Join(names, ", ")
+	match := clause.Expr{SQL: fmt.Sprintf("MATCH(%s) AGAINST (? IN BOOLEAN MODE)", cols), Vars: []interface{}{booleanQuery(terms)}}
+
+	rows := []map[string]interface{}{}
+	err := ms.db.Table(ms.table).Select("id, " + cols).Where("deleted_at IS NULL").Where(match).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: match.SQL + " DESC", Vars: match.Vars}}).
+		Limit(mysqlCandidates).Find(&rows).Error
+	if err != nil {
+		log.Println("fulltext search query error", err.Error())
+		return nil, 0, err
+	}
+
+	docs := make([]Document, 0, len(rows))
+	for _, row := range rows {
+		id, _ := strconv.ParseUint(toString(row["id"]), 10, 64)
+		doc := Document{ID: uint(id), Fields: map[string]string{}}
+		for _, name := range names {
+			doc.Fields[name] = toString(row[name])
+		}
+		docs = append(docs, doc)
+	}
+	hits, total := rankAll(ms.fields, docs, terms, limit, offset)
+
+	return hits, total, nil
+}
+
+// booleanQuery kata query sebagai wildcard awalan. Kata yang boleh salah ketik hanya memakai
+// tiga huruf awal supaya salah ketik setelahnya tetap jadi kandidat.
+func booleanQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		r := []rune(term)
+		if maxEdits(len(r)) > 0 {
+			r = r[:3]
+		}
+		parts = append(parts, string(r)+"*")
+	}
+	return strings.Join(parts, " ")
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
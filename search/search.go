@@ -0,0 +1,278 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field kolom dokumen yang diindeks beserta bobotnya pada ranking.
+type Field struct {
+	Name   string
+	Weight float64
+}
+
+// Document satu dokumen yang diindeks, Fields berisi teks per nama Field.
+type Document struct {
+	ID     uint
+	Fields map[string]string
+}
+
+// Hit satu hasil pencarian. Highlights berisi potongan teks per field yang cocok,
+// teks sudah di-escape HTML dan kata yang cocok dibungkus <em>.
+type Hit struct {
+	ID         uint
+	Score      float64
+	Highlights map[string]string
+}
+
+// Searcher pencarian full-text dengan ranking, toleransi salah ketik dan highlight.
+// Implementasi: MySQL (FULLTEXT index, Index/Remove tidak perlu dipanggil) dan Memory
+// (inverted index di memori untuk database tanpa FULLTEXT dan testing).
+//
+// Ranking dan highlight kedua implementasi sama, tetapi MySQL hanya menemukan salah ketik
+// setelah tiga huruf pertama kata ("nartuo" ketemu, "anruto" tidak) karena kandidat diambil
+// dengan wildcard awalan tiga huruf itu. Memory menoleransi salah ketik di posisi mana pun.
+type Searcher interface {
+	Index(doc Document) error
+	Remove(id uint) error
+	// Search mengembalikan satu halaman hit terurut skor beserta total hit.
+	Search(query string, limit, offset int) ([]Hit, int, error)
+}
+
+const (
+	exactWeight  = 1.0
+	prefixWeight = 0.8
+	typoWeight   = 0.5
+
+	// minPrefix panjang minimal kata query untuk dicocokkan sebagai awalan kata.
+	minPrefix = 2
+	// maxTerms batas jumlah kata query yang dipakai.
+	maxTerms = 10
+	// snippetRunes panjang maksimal potongan highlight.
+	snippetRunes = 80
+)
+
+type token struct {
+	term       string
+	start, end int // posisi rune pada teks asli
+}
+
+// tokenize memecah teks jadi kata huruf/angka dalam huruf kecil beserta posisinya.
+func tokenize(text string) []token {
+	res := []token{}
+	runes := []rune(text)
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			res = append(res, token{term: strings.ToLower(string(runes[start:i])), start: start, end: i})
+			start = -1
+		}
+	}
+	return res
+}
+
+// Terms kata unik pada query sesuai urutan, dibatasi maxTerms.
+func Terms(query string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, tok := range tokenize(query) {
+		if seen[tok.term] {
+			continue
+		}
+		seen[tok.term] = true
+		terms = append(terms, tok.term)
+		if len(terms) == maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// maxEdits jumlah salah ketik yang ditoleransi sesuai panjang kata.
+func maxEdits(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchTerm bobot kecocokan kata query q dengan kata dokumen, 0 jika tidak cocok.
+func matchTerm(q, term string) float64 {
+	if q == term {
+		return exactWeight
+	}
+	qr, tr := []rune(q), []rune(term)
+	if len(qr) >= minPrefix && len(tr) > len(qr) && strings.HasPrefix(term, q) {
+		return prefixWeight
+	}
+
+	edits := maxEdits(len(qr))
+	if edits == 0 {
+		return 0
+	}
+	if diff := len(tr) - len(qr); diff <= edits && diff >= -edits && distance(qr, tr) <= edits {
+		return typoWeight
+	}
+	// salah ketik pada kata yang belum selesai diketik, mis. "kishm" untuk "kishimoto"
+	if len(tr) > len(qr) && distance(qr, tr[:len(qr)]) <= edits {
+		return typoWeight * prefixWeight
+	}
+	return 0
+}
+
+// distance jarak Damerau-Levenshtein (optimal string alignment), pertukaran dua huruf dihitung satu.
+func distance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func min(vals ...int) int {
+	res := vals[0]
+	for _, v := range vals[1:] {
+		if v < res {
+			res = v
+		}
+	}
+	return res
+}
+
+// rank menghitung skor dan highlight dokumen untuk kata query. Skor tiap kata diambil dari
+// field dengan kecocokan terbaik lalu dikali proporsi kata query yang cocok,
+// ok false jika tidak ada kata yang cocok.
+func rank(fields []Field, doc Document, terms []string) (Hit, bool) {
+	hit := Hit{ID: doc.ID, Highlights: map[string]string{}}
+	best := make([]float64, len(terms))
+
+	for _, f := range fields {
+		text := doc.Fields[f.Name]
+		spans := []token{}
+		for _, tok := range tokenize(text) {
+			hitTok := false
+			for i, q := range terms {
+				w := matchTerm(q, tok.term)
+				if w == 0 {
+					continue
+				}
+				hitTok = true
+				if w*f.Weight > best[i] {
+					best[i] = w * f.Weight
+				}
+			}
+			if hitTok {
+				spans = append(spans, tok)
+			}
+		}
+		if len(spans) > 0 {
+			hit.Highlights[f.Name] = highlight(text, spans)
+		}
+	}
+
+	matched := 0
+	for _, val := range best {
+		if val > 0 {
+			matched++
+			hit.Score += val
+		}
+	}
+	if matched == 0 {
+		return Hit{}, false
+	}
+	hit.Score *= float64(matched) / float64(len(terms))
+
+	return hit, true
+}
+
+// highlight potongan teks di sekitar kata pertama yang cocok dengan semua kata cocok dibungkus <em>.
+func highlight(text string, spans []token) string {
+	runes := []rune(text)
+	from, to := 0, len(runes)
+	if len(runes) > snippetRunes {
+		from = spans[0].start - snippetRunes/3
+		if from < 0 {
+			from = 0
+		}
+		to = from + snippetRunes
+		if to > len(runes) {
+			to = len(runes)
+			from = to - snippetRunes
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, sp := range spans {
+		if sp.start < pos || sp.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:sp.start])))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(string(runes[sp.start:sp.end])))
+		b.WriteString("</em>")
+		pos = sp.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// rankAll menilai semua kandidat lalu mengembalikan satu halaman hit dan totalnya.
+func rankAll(fields []Field, docs []Document, terms []string, limit, offset int) ([]Hit, int) {
+	hits := []Hit{}
+	for _, doc := range docs {
+		if hit, ok := rank(fields, doc, terms); ok {
+			hits = append(hits, hit)
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	total := len(hits)
+	if offset >= total {
+		return []Hit{}, total
+	}
+	end := offset + limit
+	if limit <= 0 || end > total {
+		end = total
+	}
+	return hits[offset:end], total
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var bookFields = []Field{{Name: "judul", Weight: 2}, {Name: "penulis", Weight: 1}}
+
+func newIndex() *Memory {
+	idx := NewMemory(bookFields...)
+	idx.Index(Document{ID: 1, Fields: map[string]string{"judul": "Naruto", "penulis": "Masashi Kishimoto"}})
+	idx.Index(Document{ID: 2, Fields: map[string]string{"judul": "Boruto: Naruto Next Generations", "penulis": "Ukyo Kodachi"}})
+	idx.Index(Document{ID: 3, Fields: map[string]string{"judul": "One Piece", "penulis": "Eiichiro Oda"}})
+	idx.Index(Document{ID: 4, Fields: map[string]string{"judul": "Laskar Pelangi", "penulis": "Andrea Hirata"}})
+	return idx
+}
+
+func ids(hits []Hit) []uint {
+	res := []uint{}
+	for _, hit := range hits {
+		res = append(res, hit.ID)
+	}
+	return res
+}
+
+func TestMemorySearch(t *testing.T) {
+	idx := newIndex()
+
+	t.Run("judul lebih relevan dari judul panjang", func(t *testing.T) {
+		hits, total, err := idx.Search("naruto", 10, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []uint{1, 2}, ids(hits))
+	})
+
+	t.Run("cocok di judul lebih tinggi dari penulis", func(t *testing.T) {
+		idx := NewMemory(bookFields...)
+		idx.Index(Document{ID: 1, Fields: map[string]string{"judul": "Biografi", "penulis": "Pelangi"}})
+		idx.Index(Document{ID: 2, Fields: map[string]string{"judul": "Pelangi", "penulis": "Andrea"}})
+
+		hits, _, _ := idx.Search("pelangi", 10, 0)
+		assert.Equal(t, []uint{2, 1}, ids(hits))
+	})
+
+	t.Run("sebagian kata", func(t *testing.T) {
+		hits, _, _ := idx.Search("kishi", 10, 0)
+		assert.Equal(t, []uint{1}, ids(hits))
+	})
+
+	t.Run("salah ketik", func(t *testing.T) {
+		hits, _, _ := idx.Search("narutp", 10, 0)
+		assert.Equal(t, []uint{1, 2}, ids(hits))
+
+		hits, _, _ = idx.Search("lasakr pleangi", 10, 0)
+		assert.Equal(t, []uint{4}, ids(hits))
+	})
+
+	t.Run("kata pendek harus persis atau awalan", func(t *testing.T) {
+		hits, _, _ := idx.Search("odo", 10, 0)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("semua kata cocok lebih tinggi", func(t *testing.T) {
+		hits, _, _ := idx.Search("naruto kishimoto", 10, 0)
+		assert.Equal(t, []uint{1, 2}, ids(hits))
+		assert.Greater(t, hits[0].Score, hits[1].Score)
+	})
+
+	t.Run("highlight", func(t *testing.T) {
+		hits, _, _ := idx.Search("naruto kishimoto", 10, 0)
+		assert.Equal(t, "<em>Naruto</em>", hits[0].Highlights["judul"])
+		assert.Equal(t, "Masashi <em>Kishimoto</em>", hits[0].Highlights["penulis"])
+		_, ok := hits[1].Highlights["penulis"]
+		assert.False(t, ok)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		hits, total, _ := idx.Search("naruto", 1, 1)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []uint{2}, ids(hits))
+
+		hits, _, _ = idx.Search("naruto", 1, 5)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("query tanpa kata", func(t *testing.T) {
+		hits, total, err := idx.Search(" ?! ", 10, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, hits)
+	})
+}
+
+func TestMemorySync(t *testing.T) {
+	idx := newIndex()
+
+	idx.Index(Document{ID: 1, Fields: map[string]string{"judul": "Dragon Ball", "penulis": "Akira Toriyama"}})
+	hits, _, _ := idx.Search("naruto", 10, 0)
+	assert.Equal(t, []uint{2}, ids(hits))
+	hits, _, _ = idx.Search("dragon", 10, 0)
+	assert.Equal(t, []uint{1}, ids(hits))
+
+	idx.Remove(1)
+	hits, _, _ = idx.Search("dragon", 10, 0)
+	assert.Empty(t, hits)
+	assert.Nil(t, idx.Remove(99))
+}
+
+func TestHighlight(t *testing.T) {
+	t.Run("html di-escape", func(t *testing.T) {
+		hit, ok := rank(bookFields, Document{ID: 1, Fields: map[string]string{"judul": "<b>Naruto</b> & teman"}}, []string{"naruto"})
+		assert.True(t, ok)
+		assert.Equal(t, "&lt;b&gt;<em>Naruto</em>&lt;/b&gt; &amp; teman", hit.Highlights["judul"])
+	})
+
+	t.Run("teks panjang dipotong di sekitar kata", func(t *testing.T) {
+		text := "Kisah panjang tentang seorang anak yang tumbuh di desa kecil dan belajar banyak hal sebelum akhirnya bertemu Naruto di akhir cerita yang sangat panjang sekali"
+		hit, _ := rank(bookFields, Document{ID: 1, Fields: map[string]string{"judul": text}}, []string{"naruto"})
+		snippet := hit.Highlights["judul"]
+		assert.Contains(t, snippet, "<em>Naruto</em>")
+		assert.True(t, len([]rune(snippet)) < len([]rune(text)))
+		assert.Equal(t, "…", string([]rune(snippet)[0]))
+	})
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance([]rune("naruto"), []rune("naruto")))
+	assert.Equal(t, 1, distance([]rune("narto"), []rune("naruto")))
+	assert.Equal(t, 1, distance([]rune("nrauto"), []rune("naruto")))
+	assert.Equal(t, 2, distance([]rune("kishimto"), []rune("kisihmoto")))
+}
+
+func TestBooleanQuery(t *testing.T) {
+	assert.Equal(t, "one* nar* kis*", booleanQuery(Terms("One narutp, Kishimoto one")))
+}